@@ -17,19 +17,41 @@ import (
 func main() {
 	// Parse command line flags
 	purge := flag.Bool("purge", false, "Delete all data and start fresh")
+	configDir := flag.String("config-dir", "", "Directory for config and data (overrides $"+storage.ConfigDirEnv+" and XDG directories)")
 	flag.Parse()
 
+	paths, err := storage.ResolvePaths(*configDir)
+	if err != nil {
+		log.Fatalf("Failed to resolve config directory: %v", err)
+	}
+
 	// Handle purge command
 	if *purge {
-		if err := handlePurge(); err != nil {
+		if err := handlePurge(paths); err != nil {
 			log.Fatalf("Failed to purge data: %v", err)
 		}
 		return
 	}
 
+	// Handle the export subcommand
+	if flag.Arg(0) == "export" {
+		if err := handleExport(paths, flag.Args()[1:]); err != nil {
+			log.Fatalf("Failed to export tasks: %v", err)
+		}
+		return
+	}
+
+	// Initialize the configured storage backend
+	backend, err := storage.NewBackend(paths)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	defer backend.Close()
+	
 	// Initialize the application model
-	model, err := app.NewModel()
+	model, err := app.NewModel(backend)
 	if err != nil {
+		backend.Close()
 		log.Fatalf("Failed to initialize application: %v", err)
 	}
 	
@@ -37,11 +59,12 @@ func main() {
 	p := tea.NewProgram(model, tea.WithAltScreen())
 	
 	if _, err := p.Run(); err != nil {
+		backend.Close()
 		log.Fatal(err)
 	}
 }
 
-func handlePurge() error {
+func handlePurge(paths storage.Paths) error {
 	fmt.Print("Are you sure you want to delete all data? This cannot be undone. [Y/n]: ")
 	
 	reader := bufio.NewReader(os.Stdin)
@@ -57,15 +80,52 @@ func handlePurge() error {
 	}
 	
 	// Initialize storage to get the purge functionality
-	storage, err := storage.NewStorage()
+	backend, err := storage.NewBackend(paths)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
+	defer backend.Close()
 	
-	if err := storage.PurgeData(); err != nil {
+	if err := backend.PurgeData(); err != nil {
 		return fmt.Errorf("failed to purge data: %w", err)
 	}
 	
 	fmt.Println("All data has been successfully deleted.")
 	return nil
-}
\ No newline at end of file
+}
+
+// handleExport writes all tasks as an iCalendar file, or to standard output
+func handleExport(paths storage.Paths, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	output := flags.String("o", "", "File to write (default: standard output)")
+	format := flags.String("format", "", "Export tasks as \"todo\" or all-day \"event\" (default: export_format from config, else todo)")
+	flags.Parse(args)
+	
+	// Read the data without the backend, which would migrate and save it
+	// and rewrite the configured export file
+	s, err := storage.NewStorageWithPaths(paths)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	
+	if *format == "" {
+		*format = s.GetConfig().ExportFormat
+	}
+	switch *format {
+	case "", storage.ExportTodo, storage.ExportEvent:
+	default:
+		return fmt.Errorf("unknown format %q, expected %q or %q", *format, storage.ExportTodo, storage.ExportEvent)
+	}
+	
+	data, err := s.ReadData()
+	if err != nil {
+		return fmt.Errorf("failed to load data: %w", err)
+	}
+	
+	ics := storage.ICalendar(data.Tasks, *format)
+	if *output == "" {
+		_, err = os.Stdout.Write(ics)
+		return err
+	}
+	return storage.WriteFileAtomic(*output, ics, 0644)
+}
@@ -3,7 +3,6 @@ package app
 import (
 	"fmt"
 	"io"
-	"os"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -33,14 +32,29 @@ const (
 	ModeHistory
 	ModeHelp
 	ModeDeleteConfirm
+	ModeRecurrence
+	ModeEventDetail
+	ModePrepare
+	ModeTimeline
 )
 
+// dataCheckInterval is how often the data file is checked for changes made by other instances
+const dataCheckInterval = 2 * time.Second
+
+// dataCheckMsg triggers a check for external data changes
+type dataCheckMsg time.Time
+
 // ListItem represents an item in the list (either a task or a day header)
 type ListItem struct {
 	ItemType   string        // "day_header", "task", "add_button", "spacer"
 	Date       time.Time     // The date this item belongs to
 	Task       *storage.Task // The task (nil for day headers and add buttons)
 	IsSelected bool          // Whether this item is currently selected
+	Prepared   bool          // Calendar event with an open preparation task
+	
+	// Progress of the subtasks hidden below a collapsed task
+	HiddenDone  int
+	HiddenTotal int
 }
 
 // FilterValue implements list.Item interface
@@ -100,8 +114,8 @@ func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 
 func (d ItemDelegate) renderDayHeader(w io.Writer, item ListItem, selected bool) {
 	dateHeader := item.Date.Format("Monday, January 2")
-	isToday := item.Date.Truncate(24*time.Hour).Equal(time.Now().Truncate(24*time.Hour))
-	isTomorrow := item.Date.Truncate(24*time.Hour).Equal(time.Now().Add(24*time.Hour).Truncate(24*time.Hour))
+	isToday := storage.SameDay(item.Date, time.Now())
+	isTomorrow := storage.SameDay(item.Date, storage.AddDays(time.Now(), 1))
 	
 	if isToday {
 		dateHeader = "Today - " + dateHeader
@@ -130,8 +144,40 @@ func (d ItemDelegate) renderTask(w io.Writer, item ListItem, selected bool) {
 	
 	// Handle calendar events differently
 	if task.IsCalendar {
-		timeStr := task.StartTime.Format("15:04")
-		text := d.styles.Calendar.Render(fmt.Sprintf("%s %s", timeStr, task.Text))
+		marker := eventDayMarker(task)
+		textStyle, bannerStyle := d.eventStyles(task)
+		
+		// Whole-day events are a banner without a time
+		if isBannerEvent(task) {
+			banner := "📅 "
+			if task.CalendarName != "" {
+				banner += "[" + task.CalendarName + "] "
+			}
+			banner += task.Text
+			if marker != "" {
+				banner += " (" + marker + ")"
+			}
+			if item.Prepared {
+				banner += " 🔗"
+			}
+			if d.width > len(prefix) {
+				bannerStyle = bannerStyle.Width(d.width - len(prefix))
+			}
+			fmt.Fprintf(w, "%s%s", prefix, bannerStyle.Render(banner))
+			return
+		}
+		
+		text := textStyle.Render(eventTimeLabel(task)) + " "
+		if task.CalendarName != "" {
+			text += textStyle.Bold(true).Render("["+task.CalendarName+"]") + " "
+		}
+		text += textStyle.Render(task.Text)
+		if marker != "" {
+			text += d.styles.Secondary.Render(" (" + marker + ")")
+		}
+		if item.Prepared {
+			text += d.styles.Secondary.Render(" 🔗")
+		}
 		fmt.Fprintf(w, "%s%s📅 %s", prefix, indent, text)
 		return
 	}
@@ -148,10 +194,41 @@ func (d ItemDelegate) renderTask(w io.Writer, item ListItem, selected bool) {
 		textStyle = d.styles.TaskActive
 	}
 	
+	// Projected occurrences of recurring tasks are shown dimmed
+	if task.Virtual {
+		checkbox = d.styles.Secondary.Render("☐")
+		textStyle = d.styles.Secondary
+	}
+	
 	text := textStyle.Render(task.Text)
+	if start, end, ok := task.Schedule(); ok {
+		text += d.styles.Secondary.Render(" " + start.Format("15:04") + "–" + end.Format("15:04"))
+	}
+	if task.Recurrence != nil {
+		text += d.styles.Secondary.Render(" ↻")
+	}
+	if overdue := overdueLabel(task); overdue != "" {
+		text += d.styles.Overdue.Render(" " + overdue)
+	}
+	if item.HiddenTotal > 0 {
+		text += d.styles.Secondary.Render(fmt.Sprintf(" ▸ (%d/%d)", item.HiddenDone, item.HiddenTotal))
+	}
 	fmt.Fprintf(w, "%s%s%s %s", prefix, indent, checkbox, text)
 }
 
+// overdueLabel describes how long an unfinished task has been carried forward
+func overdueLabel(task storage.Task) string {
+	if task.Done || task.OverdueSince.IsZero() {
+		return ""
+	}
+	
+	label := "overdue since " + task.OverdueSince.Format("Jan 2")
+	if task.DeferCount > 1 {
+		label += fmt.Sprintf(" (deferred %d×)", task.DeferCount)
+	}
+	return label
+}
+
 func (d ItemDelegate) renderAddButton(w io.Writer, item ListItem, selected bool) {
 	addButton := "+ Add new task"
 	if selected {
@@ -170,10 +247,12 @@ type Model struct {
 	height     int
 	
 	// Data
-	storage       *storage.Storage
-	appData       *storage.AppData
-	tasks         []storage.Task
-	calendarTasks []storage.Task
+	storage        storage.Backend
+	appData        *storage.AppData
+	syncedTasks    []storage.Task // Tasks as last loaded from or written to storage
+	tasks          []storage.Task
+	calendarTasks  []storage.Task
+	calendarEvents map[int64][]storage.Task // Events of the listed days, keyed by the day's start in Unix seconds
 	
 	// Managers
 	themeManager    *theme.Manager
@@ -199,24 +278,43 @@ type Model struct {
 	editDate        time.Time
 	editTaskForDate *storage.Task
 	
+	// Recurrence edit state
+	recurrenceTaskID string
+	
 	// Delete confirmation state
 	deleteTaskID string
 	
+	// Calendar event shown in the detail view
+	detailTask *storage.Task
+	
+	// Calendar event a preparation task is being created for
+	prepareEvent *storage.Task
+	
+	// Day shown in the timeline and its selection
+	timeline timelineState
+	
+	// Whether tasks are being synced with CalDAV servers
+	taskSyncing bool
+	
+	// Whether local calendar files are being checked for changes
+	checkingLocal bool
+	
+	// When the last key was pressed, to defer work until typing stops
+	lastKeyAt time.Time
+	
+	// Undo/redo history of task mutations
+	history history
+	
 	// Quote state
 	currentQuote *parser.Quote
 	
 	// Error handling
 	lastError string
+	notice    string
 }
 
-// NewModel creates a new application model
-func NewModel() (*Model, error) {
-	// Initialize storage
-	storage, err := storage.NewStorage()
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize storage: %w", err)
-	}
-	
+// NewModel creates a new application model on top of the given storage backend
+func NewModel(storage storage.Backend) (*Model, error) {
 	// Load application data
 	appData, err := storage.LoadData()
 	if err != nil {
@@ -224,8 +322,7 @@ func NewModel() (*Model, error) {
 	}
 	
 	// Get config directory
-	homeDir, _ := os.UserHomeDir()
-	configDir := filepath.Join(homeDir, ".config", "personal-disorganizer")
+	configDir := storage.ConfigDir()
 	
 	// Initialize theme manager
 	themeManager, err := theme.NewManager(configDir)
@@ -241,8 +338,12 @@ func NewModel() (*Model, error) {
 	}
 	
 	// Initialize calendar manager
-	calendarManager := calendar.NewManager(config.CalendarURLs)
+	calendarManager := calendar.NewManagerWithSources(config.CalendarURLs)
 	calendarManager.SetLogger(storage)
+	calendarManager.SetCacheDir(filepath.Join(configDir, "calendar-cache"))
+	
+	// Show the last downloaded events until the first refresh completes
+	calendarManager.LoadCache()
 	
 	// Initialize search engine
 	searchEngine := search.NewEngine()
@@ -279,9 +380,20 @@ func NewModel() (*Model, error) {
 		textInput:       ti,
 		list:            taskList,
 		delegate:        delegate,
-		currentDate:     time.Now().Truncate(24 * time.Hour),
+		currentDate:     startOfToday(),
 		showHistory:     false,
 	}
+	m.markAllSynced()
+	
+	// Carry unfinished tasks from earlier days forward when enabled
+	if config.Rollover {
+		m.rolloverOpenTasks(m.currentDate)
+	}
+	
+	// Tell the user when data had to be restored from a backup
+	if backup := storage.RecoveredFrom(); backup != "" {
+		m.notice = fmt.Sprintf("data.json was unreadable - restored from backup %s", filepath.Base(backup))
+	}
 	
 	// Initialize quote if available
 	if quoteManager.HasQuotes() {
@@ -296,7 +408,14 @@ func NewModel() (*Model, error) {
 
 // Init initializes the application
 func (m *Model) Init() tea.Cmd {
-	return nil
+	return tea.Batch(m.scheduleDataCheck(), m.scheduleMidnight(), m.refreshCalendars(), m.scheduleCalendarRefresh())
+}
+
+// scheduleDataCheck schedules the next check for external data changes
+func (m *Model) scheduleDataCheck() tea.Cmd {
+	return tea.Tick(dataCheckInterval, func(t time.Time) tea.Msg {
+		return dataCheckMsg(t)
+	})
 }
 
 // Update handles messages and updates the model
@@ -315,9 +434,43 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Update list height based on current footer size
 		m.updateListHeight()
 		
+	case dataCheckMsg:
+		m.reloadExternalChanges()
+		
+		// Rewrite the export once typing stops rather than on every keystroke
+		if time.Since(m.lastKeyAt) >= dataCheckInterval {
+			m.storage.FlushExport()
+		}
+		return m, tea.Batch(m.scheduleDataCheck(), m.checkLocalCalendars())
+		
+	case midnightMsg:
+		m.handleMidnight()
+		return m, m.scheduleMidnight()
+		
+	case calendarRefreshMsg:
+		return m, tea.Batch(m.refreshCalendars(), m.scheduleCalendarRefresh())
+		
+	case localCalendarsChangedMsg:
+		return m, m.refreshChangedCalendars(msg)
+		
+	case calendarFeedMsg:
+		m.handleCalendarFeed(calendar.FeedUpdate(msg))
+		
+	case taskSyncMsg:
+		m.handleTaskSync(msg)
+		
+	case openURLMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Could not open %s: %v", msg.url, msg.err)
+		}
+		
 	case tea.KeyMsg:
-		// Handle text input first if in edit mode (except for special keys)
-		if m.mode == ModeEdit {
+		// Notices are informational and go away with the next keypress
+		m.notice = ""
+		m.lastKeyAt = time.Now()
+		
+		// Handle text input first if in an input mode (except for special keys)
+		if m.mode == ModeEdit || m.mode == ModeRecurrence || m.mode == ModePrepare {
 			switch msg.String() {
 			case "esc", "enter":
 				// Let these be handled by the mode handler
@@ -350,12 +503,29 @@ func (m *Model) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleHelpMode(msg)
 	case ModeDeleteConfirm:
 		return m.handleDeleteConfirmMode(msg)
+	case ModeRecurrence:
+		return m.handleRecurrenceMode(msg)
+	case ModeEventDetail:
+		return m.handleEventDetailMode(msg)
+	case ModePrepare:
+		return m.handlePrepareMode(msg)
+	case ModeTimeline:
+		return m.handleTimelineMode(msg)
 	}
 	return m, nil
 }
 
 // handleViewMode handles input in view mode
 func (m *Model) handleViewMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Projected occurrences of recurring tasks cannot be changed on their own
+	if selectedItem := m.getSelectedListItem(); selectedItem != nil && selectedItem.Task != nil && selectedItem.Task.Virtual {
+		switch msg.String() {
+		case "enter", " ", "d", "tab", "shift+tab", "shift+up", "shift+down", "R", "c":
+			m.notice = "This is a future occurrence - change the open occurrence of the recurring task instead"
+			return m, nil
+		}
+	}
+	
 	switch msg.String() {
 	case "ctrl+c", "q":
 		return m, tea.Quit
@@ -371,7 +541,9 @@ func (m *Model) handleViewMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case "add_button":
 			m.startEditingNewTaskForDate(selectedItem.Date)
 		case "task":
-			if selectedItem.Task != nil {
+			if selectedItem.Task != nil && selectedItem.Task.IsCalendar {
+				m.showEventDetail(selectedItem.Task)
+			} else if selectedItem.Task != nil {
 				m.startEditingExistingTask(selectedItem.Task, selectedItem.Date)
 			}
 		}
@@ -380,15 +552,18 @@ func (m *Model) handleViewMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Toggle task completion
 		selectedItem := m.getSelectedListItem()
 		if selectedItem != nil && selectedItem.ItemType == "task" && selectedItem.Task != nil {
-			m.toggleTaskById(selectedItem.Task.ID)
-			m.saveData()
+			taskID := selectedItem.Task.ID
+			changed := m.recordChange("toggle", func() {
+				m.toggleTaskById(taskID)
+			})
+			m.persistTasks(changed)
 			m.rebuildListItemsPreservingSelection()
 		}
 		
 	case "d":
 		// Delete task - show confirmation
 		selectedItem := m.getSelectedListItem()
-		if selectedItem != nil && selectedItem.ItemType == "task" && selectedItem.Task != nil {
+		if selectedItem != nil && selectedItem.ItemType == "task" && selectedItem.Task != nil && !selectedItem.Task.IsCalendar {
 			m.deleteTaskID = selectedItem.Task.ID
 			m.mode = ModeDeleteConfirm
 		}
@@ -397,8 +572,11 @@ func (m *Model) handleViewMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Indent task (increase hierarchy level)
 		selectedItem := m.getSelectedListItem()
 		if selectedItem != nil && selectedItem.ItemType == "task" && selectedItem.Task != nil {
-			m.adjustTaskLevel(selectedItem.Task.ID, 1)
-			m.saveData()
+			taskID := selectedItem.Task.ID
+			changed := m.recordChange("indent", func() {
+				m.adjustTaskLevel(taskID, 1)
+			})
+			m.persistTasks(changed)
 			m.rebuildListItemsPreservingSelection()
 		}
 		
@@ -406,8 +584,11 @@ func (m *Model) handleViewMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Outdent task (decrease hierarchy level)
 		selectedItem := m.getSelectedListItem()
 		if selectedItem != nil && selectedItem.ItemType == "task" && selectedItem.Task != nil {
-			m.adjustTaskLevel(selectedItem.Task.ID, -1)
-			m.saveData()
+			taskID := selectedItem.Task.ID
+			changed := m.recordChange("outdent", func() {
+				m.adjustTaskLevel(taskID, -1)
+			})
+			m.persistTasks(changed)
 			m.rebuildListItemsPreservingSelection()
 		}
 		
@@ -415,8 +596,11 @@ func (m *Model) handleViewMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Move task up (possibly to previous day)
 		selectedItem := m.getSelectedListItem()
 		if selectedItem != nil && selectedItem.ItemType == "task" && selectedItem.Task != nil && !selectedItem.Task.IsCalendar {
-			m.moveTaskUp(selectedItem.Date, selectedItem.Task.ID)
-			m.saveData()
+			date, taskID := selectedItem.Date, selectedItem.Task.ID
+			changed := m.recordChange("move", func() {
+				m.moveTaskUp(date, taskID)
+			})
+			m.persistTasks(changed)
 			m.rebuildListItemsPreservingSelection()
 		}
 		
@@ -424,11 +608,55 @@ func (m *Model) handleViewMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Move task down (possibly to next day)
 		selectedItem := m.getSelectedListItem()
 		if selectedItem != nil && selectedItem.ItemType == "task" && selectedItem.Task != nil && !selectedItem.Task.IsCalendar {
-			m.moveTaskDown(selectedItem.Date, selectedItem.Task.ID)
-			m.saveData()
+			date, taskID := selectedItem.Date, selectedItem.Task.ID
+			changed := m.recordChange("move", func() {
+				m.moveTaskDown(date, taskID)
+			})
+			m.persistTasks(changed)
 			m.rebuildListItemsPreservingSelection()
 		}
 		
+	case "R":
+		// Set or change the recurrence of a task
+		selectedItem := m.getSelectedListItem()
+		if selectedItem != nil && selectedItem.ItemType == "task" && selectedItem.Task != nil && !selectedItem.Task.IsCalendar {
+			m.startEditingRecurrence(selectedItem.Task)
+		}
+		
+	case "P":
+		// Create a task to prepare for a calendar event
+		selectedItem := m.getSelectedListItem()
+		if selectedItem != nil && selectedItem.ItemType == "task" && selectedItem.Task != nil && selectedItem.Task.IsCalendar {
+			m.startPreparing(selectedItem.Task)
+		}
+		
+	case "t":
+		// Show the selected day as a timeline
+		date := startOfToday()
+		if selectedItem := m.getSelectedListItem(); selectedItem != nil {
+			date = selectedItem.Date
+		}
+		m.openTimeline(date)
+		
+	case "c":
+		// Collapse or expand the subtasks of a task
+		selectedItem := m.getSelectedListItem()
+		if selectedItem != nil && selectedItem.ItemType == "task" && selectedItem.Task != nil && !selectedItem.Task.IsCalendar {
+			if taskID := m.toggleCollapsed(selectedItem.Task.ID); taskID != "" {
+				m.saveTask(taskID)
+				m.rebuildListItems()
+				m.setListCursorToTask(taskID)
+			}
+		}
+		
+	case "u":
+		// Undo the last task change
+		m.undo()
+		
+	case "ctrl+r":
+		// Redo the last undone task change
+		m.redo()
+		
 	case "h":
 		// Jump to history
 		m.mode = ModeHistory
@@ -449,13 +677,13 @@ func (m *Model) handleViewMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		
 	case "n":
 		// Next day
-		m.currentDate = m.currentDate.Add(24 * time.Hour)
+		m.currentDate = storage.AddDays(m.currentDate, 1)
 		m.updateTasksForCurrentDate()
 		m.rebuildListItems()
 		
 	case "p":
 		// Previous day
-		m.currentDate = m.currentDate.Add(-24 * time.Hour)
+		m.currentDate = storage.AddDays(m.currentDate, -1)
 		m.updateTasksForCurrentDate()
 		m.rebuildListItems()
 		
@@ -482,17 +710,22 @@ func (m *Model) handleEditMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if m.editTaskForDate == nil {
 				// Creating new task - use smart insertion to preserve hierarchy
 				task := m.storage.CreateTask(text, m.editDate)
-				m.insertTaskAtPosition(task, m.editDate)
+				changed := m.recordChange("add", func() {
+					m.insertTaskAtPosition(task, m.editDate)
+				})
+				m.persistTasks(changed)
 			} else {
 				// Editing existing task
-				for i := range m.appData.Tasks {
-					if m.appData.Tasks[i].ID == m.editTaskForDate.ID {
-						m.appData.Tasks[i].Text = text
-						break
+				m.recordChange("edit", func() {
+					for i := range m.appData.Tasks {
+						if m.appData.Tasks[i].ID == m.editTaskForDate.ID {
+							m.appData.Tasks[i].Text = text
+							break
+						}
 					}
-				}
+				})
+				m.saveTask(m.editTaskForDate.ID)
 			}
-			m.saveData()
 			m.updateTasksForCurrentDate()
 			m.rebuildListItems()
 		}
@@ -522,6 +755,10 @@ func (m *Model) handleSearchMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			
 			// Find the task in the list and set cursor to it
 			// The list should always start from today, not change
+			if expanded := m.revealTask(result.Task.ID); len(expanded) > 0 {
+				m.persistTasks(expanded)
+				m.rebuildListItems()
+			}
 			m.setListCursorToTask(result.Task.ID)
 			
 			m.mode = ModeView
@@ -579,8 +816,11 @@ func (m *Model) handleDeleteConfirmMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "y", "Y":
 		// Confirm deletion
 		if m.deleteTaskID != "" {
-			m.deleteTaskById(m.deleteTaskID)
-			m.saveData()
+			taskID := m.deleteTaskID
+			changed := m.recordChange("delete", func() {
+				m.deleteTaskById(taskID)
+			})
+			m.persistTasks(changed)
 			m.rebuildListItemsPreservingSelection()
 		}
 		m.deleteTaskID = ""
@@ -595,6 +835,59 @@ func (m *Model) handleDeleteConfirmMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleRecurrenceMode handles input while editing a task's recurrence rule
+func (m *Model) handleRecurrenceMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = ModeView
+		m.textInput.Blur()
+		m.textInput.SetValue("")
+		m.recurrenceTaskID = ""
+		
+	case "enter":
+		rule, err := storage.ParseRecurrence(m.textInput.Value())
+		if err != nil {
+			// Stay in the input so the rule can be corrected
+			m.notice = err.Error()
+			return m, nil
+		}
+		
+		taskID := m.recurrenceTaskID
+		m.recordChange("recurrence change", func() {
+			for i := range m.appData.Tasks {
+				if m.appData.Tasks[i].ID == taskID {
+					if rule != nil {
+						rule.AnchorTo(m.appData.Tasks[i].Date)
+					}
+					m.appData.Tasks[i].Recurrence = rule
+					break
+				}
+			}
+		})
+		m.saveTask(taskID)
+		m.updateTasksForCurrentDate()
+		m.rebuildListItemsPreservingSelection()
+		
+		m.mode = ModeView
+		m.textInput.Blur()
+		m.textInput.SetValue("")
+		m.recurrenceTaskID = ""
+	}
+	
+	return m, nil
+}
+
+// startEditingRecurrence starts editing the recurrence rule of a task
+func (m *Model) startEditingRecurrence(task *storage.Task) {
+	m.mode = ModeRecurrence
+	m.recurrenceTaskID = task.ID
+	m.textInput.SetValue("")
+	if task.Recurrence != nil {
+		m.textInput.SetValue(task.Recurrence.String())
+	}
+	m.textInput.Focus()
+}
+
 // startEditingNewTaskForDate starts editing a new task for a specific date
 func (m *Model) startEditingNewTaskForDate(date time.Time) {
 	m.mode = ModeEdit
@@ -604,62 +897,36 @@ func (m *Model) startEditingNewTaskForDate(date time.Time) {
 	m.textInput.Focus()
 }
 
-// insertTaskAtPosition inserts a new task at the appropriate position preserving hierarchy
+// insertTaskAtPosition inserts a new task as the next sibling of the selected
+// task (after its subtasks), or as a top-level task at the end of the day
 func (m *Model) insertTaskAtPosition(newTask *storage.Task, targetDate time.Time) {
 	// Get the currently selected item to determine insertion context
 	selectedItem := m.getSelectedListItem()
 	
-	// Get all tasks for the target date, sorted by priority
-	dayTasks := m.getTasksForDate(targetDate)
-	
-	if selectedItem == nil || len(dayTasks) == 0 {
-		// No selection or no existing tasks - just add with default priority
-		newTask.Priority = 1
-		m.appData.Tasks = append(m.appData.Tasks, *newTask)
-		return
-	}
-	
-	if selectedItem.ItemType == "add_button" {
-		// Adding at the end of the day - set priority lower than the lowest existing task
-		minPriority := 0
-		for _, task := range dayTasks {
-			if !task.IsCalendar && task.Priority < minPriority {
-				minPriority = task.Priority
+	if selectedItem != nil && selectedItem.ItemType == "task" && selectedItem.Task != nil && !selectedItem.Task.IsCalendar {
+		index := m.taskIndex(selectedItem.Task.ID)
+		if index >= 0 && storage.SameDay(m.appData.Tasks[index].Date, targetDate) {
+			selectedTask := m.appData.Tasks[index]
+			if parent := m.parentOf(selectedTask); parent != nil {
+				newTask.ParentID = parent.ID
 			}
-		}
-		newTask.Priority = minPriority - 1
-		m.appData.Tasks = append(m.appData.Tasks, *newTask)
-		return
-	}
-	
-	if selectedItem.ItemType == "task" && selectedItem.Task != nil {
-		// Insert after the selected task and its entire subtask block
-		selectedTask := selectedItem.Task
-		
-		// Find the end of the selected task's subtask block
-		// Subtasks have higher level numbers and lower priority numbers (appear immediately after)
-		endPriority := selectedTask.Priority - 1
-		
-		// Look for any subtasks (children) of the selected task
-		for _, task := range dayTasks {
-			if !task.IsCalendar && 
-			   task.Priority < selectedTask.Priority && 
-			   task.Level > selectedTask.Level {
-				// This is a subtask - find the lowest priority among all subtasks
-				if task.Priority < endPriority {
-					endPriority = task.Priority
+			
+			var order []string
+			for _, sibling := range m.siblingsOf(selectedTask) {
+				order = append(order, sibling.ID)
+				if sibling.ID == selectedTask.ID {
+					order = append(order, newTask.ID)
 				}
 			}
+			
+			m.appData.Tasks = append(m.appData.Tasks, *newTask)
+			m.setSiblingOrder(order)
+			return
 		}
-		
-		// Insert the new task after the entire block (selected task + its subtasks)
-		newTask.Priority = endPriority - 1
-		m.appData.Tasks = append(m.appData.Tasks, *newTask)
-		return
 	}
 	
-	// Fallback: add at end
-	newTask.Priority = 1
+	// Add at the end of the day's top-level tasks
+	newTask.Priority = endPriority(m.childrenOf("", targetDate))
 	m.appData.Tasks = append(m.appData.Tasks, *newTask)
 }
 
@@ -694,126 +961,101 @@ func (m *Model) refreshQuote() {
 	}
 }
 
-// deleteTask deletes a task
+// deleteTask deletes a task and its subtasks
 func (m *Model) deleteTask(index int) {
 	if index < len(m.tasks) {
-		taskID := m.tasks[index].ID
-		for i := range m.appData.Tasks {
-			if m.appData.Tasks[i].ID == taskID {
-				m.appData.Tasks = append(m.appData.Tasks[:i], m.appData.Tasks[i+1:]...)
-				break
-			}
-		}
-		m.updateTasksForCurrentDate()
+		m.deleteTaskById(m.tasks[index].ID)
 	}
 }
 
-// moveTaskUp moves a task up within its day or to the previous day
+// moveTaskUp moves a task and its subtasks above the previous sibling, or a
+// first top-level task to the previous day
 func (m *Model) moveTaskUp(date time.Time, taskID string) {
-	tasks := m.getTasksForDate(date)
-	localIndex := -1
-	
-	// Find the task's current position
-	for i, task := range tasks {
-		if task.ID == taskID {
-			localIndex = i
-			break
-		}
-	}
-	
-	if localIndex == -1 || tasks[localIndex].IsCalendar {
+	index := m.taskIndex(taskID)
+	if index == -1 || m.appData.Tasks[index].IsCalendar {
 		return
 	}
+	task := m.appData.Tasks[index]
 	
-	if localIndex > 0 {
+	if indexOfTask(m.siblingsOf(task), taskID) > 0 {
 		// Move within the same day
-		m.moveTaskWithinDay(taskID, date, localIndex, localIndex-1)
-	} else {
+		m.moveTaskWithinDay(taskID, -1)
+	} else if m.parentOf(task) == nil {
 		// Move to previous day (only if not moving to past)
-		prevDate := date.Add(-24 * time.Hour)
+		prevDate := storage.AddDays(date, -1)
 		if !prevDate.Before(m.currentDate) {
 			m.moveTaskToDay(taskID, date, prevDate, -1) // -1 means to the end
 		}
 	}
 }
 
-// moveTaskDown moves a task down within its day or to the next day
+// moveTaskDown moves a task and its subtasks below the next sibling, or a
+// last top-level task to the next day
 func (m *Model) moveTaskDown(date time.Time, taskID string) {
-	tasks := m.getTasksForDate(date)
-	localIndex := -1
-	
-	// Find the task's current position
-	for i, task := range tasks {
-		if task.ID == taskID {
-			localIndex = i
-			break
-		}
-	}
-	
-	if localIndex == -1 || tasks[localIndex].IsCalendar {
+	index := m.taskIndex(taskID)
+	if index == -1 || m.appData.Tasks[index].IsCalendar {
 		return
 	}
+	task := m.appData.Tasks[index]
 	
-	if localIndex < len(tasks)-1 {
+	siblings := m.siblingsOf(task)
+	if position := indexOfTask(siblings, taskID); position >= 0 && position < len(siblings)-1 {
 		// Move within the same day
-		m.moveTaskWithinDay(taskID, date, localIndex, localIndex+1)
-	} else {
+		m.moveTaskWithinDay(taskID, 1)
+	} else if m.parentOf(task) == nil {
 		// Move to next day
-		nextDate := date.Add(24 * time.Hour)
+		nextDate := storage.AddDays(date, 1)
 		m.moveTaskToDay(taskID, date, nextDate, 0) // 0 means to the beginning
 	}
 }
 
-// moveTaskWithinDay moves a task to a different position within the same day
-func (m *Model) moveTaskWithinDay(taskID string, date time.Time, fromIndex, toIndex int) {
-	for i := range m.appData.Tasks {
-		if m.appData.Tasks[i].ID == taskID {
-			// Adjust priority based on direction
-			if toIndex < fromIndex {
-				m.appData.Tasks[i].Priority++
-			} else {
-				m.appData.Tasks[i].Priority--
-			}
-			break
-		}
+// moveTaskWithinDay swaps a task with the sibling offset positions away; its
+// subtasks follow because they hang off the task
+func (m *Model) moveTaskWithinDay(taskID string, offset int) {
+	index := m.taskIndex(taskID)
+	if index == -1 {
+		return
+	}
+	
+	siblings := m.siblingsOf(m.appData.Tasks[index])
+	position := indexOfTask(siblings, taskID)
+	target := position + offset
+	if position == -1 || target < 0 || target >= len(siblings) {
+		return
 	}
+	siblings[position], siblings[target] = siblings[target], siblings[position]
+	
+	order := make([]string, len(siblings))
+	for i, sibling := range siblings {
+		order[i] = sibling.ID
+	}
+	m.setSiblingOrder(order)
 	m.updateTasksForCurrentDate()
 }
 
-// moveTaskToDay moves a task from one day to another
+// moveTaskToDay moves a task with all of its subtasks from one day to another,
+// where it becomes a top-level task
 func (m *Model) moveTaskToDay(taskID string, fromDate, toDate time.Time, position int) {
-	for i := range m.appData.Tasks {
-		if m.appData.Tasks[i].ID == taskID {
-			// Change the task's date
+	index := m.taskIndex(taskID)
+	if index == -1 {
+		return
+	}
+	
+	// Set priority based on position among the target day's top-level tasks
+	roots := m.childrenOf("", toDate)
+	priority := startPriority(roots)
+	if position == -1 {
+		priority = endPriority(roots)
+	}
+	
+	for _, id := range m.subtreeIDs(taskID) {
+		if i := m.taskIndex(id); i >= 0 {
 			m.appData.Tasks[i].Date = toDate
-			
-			// Set priority based on position
-			if position == -1 {
-				// Move to end of day - find lowest priority for that day
-				minPriority := 0
-				for _, task := range m.appData.Tasks {
-					if task.Date.Truncate(24*time.Hour).Equal(toDate.Truncate(24*time.Hour)) && !task.IsCalendar {
-						if task.Priority < minPriority {
-							minPriority = task.Priority
-						}
-					}
-				}
-				m.appData.Tasks[i].Priority = minPriority - 1
-			} else {
-				// Move to beginning of day - find highest priority for that day
-				maxPriority := 0
-				for _, task := range m.appData.Tasks {
-					if task.Date.Truncate(24*time.Hour).Equal(toDate.Truncate(24*time.Hour)) && !task.IsCalendar {
-						if task.Priority > maxPriority {
-							maxPriority = task.Priority
-						}
-					}
-				}
-				m.appData.Tasks[i].Priority = maxPriority + 1
-			}
-			break
 		}
 	}
+	m.appData.Tasks[index].ParentID = ""
+	m.appData.Tasks[index].Priority = priority
+	
 	m.updateTasksForCurrentDate()
 }
 
@@ -822,29 +1064,19 @@ func (m *Model) moveTaskToDay(taskID string, fromDate, toDate time.Time, positio
 func (m *Model) updateTasksForCurrentDate() {
 	m.tasks = []storage.Task{}
 	
-	// Add calendar events for the current date
-	if calendarTasks, err := m.calendarManager.FetchEvents(m.currentDate); err == nil {
-		m.calendarTasks = calendarTasks
-		m.tasks = append(m.tasks, calendarTasks...)
-	}
+	// Add calendar events for the current date from the last fetched feeds
+	m.calendarTasks = m.calendarManager.EventsOn(m.currentDate)
+	m.tasks = append(m.tasks, m.calendarTasks...)
 	
 	// Add regular tasks for the current date
 	for _, task := range m.appData.Tasks {
-		if task.Date.Truncate(24*time.Hour).Equal(m.currentDate) {
+		if storage.SameDay(task.Date, m.currentDate) {
 			m.tasks = append(m.tasks, task)
 		}
 	}
+	m.tasks = append(m.tasks, m.virtualOccurrences(m.currentDate)...)
 	
-	// Sort tasks: calendar events first (by time), then regular tasks
-	sort.Slice(m.tasks, func(i, j int) bool {
-		if m.tasks[i].IsCalendar != m.tasks[j].IsCalendar {
-			return m.tasks[i].IsCalendar // Calendar events first
-		}
-		if m.tasks[i].IsCalendar && m.tasks[j].IsCalendar {
-			return m.tasks[i].StartTime.Before(m.tasks[j].StartTime)
-		}
-		return m.tasks[i].Priority > m.tasks[j].Priority
-	})
+	m.tasks = orderDayTasks(m.tasks)
 }
 
 // updateSearchResults updates the search results based on current query
@@ -858,12 +1090,97 @@ func (m *Model) updateSearchResults() {
 	m.searchCursor = 0
 }
 
-// saveData saves the current application data
-func (m *Model) saveData() {
-	if err := m.storage.SaveData(m.appData); err != nil {
+// saveTask persists a single task after it was created or changed
+func (m *Model) saveTask(taskID string) {
+	for _, task := range m.appData.Tasks {
+		if task.ID == taskID {
+			if err := m.storage.UpsertTask(task); err != nil {
+				m.lastError = err.Error()
+				m.storage.LogError(err)
+				return
+			}
+			m.markTaskSynced(task)
+			return
+		}
+	}
+}
+
+// persistTasks writes the given tasks, deleting those that no longer exist
+func (m *Model) persistTasks(taskIDs []string) {
+	for _, id := range taskIDs {
+		if m.taskIndex(id) >= 0 {
+			m.saveTask(id)
+		} else {
+			m.removeTask(id)
+		}
+	}
+}
+
+// removeTask persists the deletion of a single task
+func (m *Model) removeTask(taskID string) {
+	if err := m.storage.DeleteTask(taskID); err != nil {
 		m.lastError = err.Error()
 		m.storage.LogError(err)
+		return
 	}
+	for i := range m.syncedTasks {
+		if m.syncedTasks[i].ID == taskID {
+			m.syncedTasks = append(m.syncedTasks[:i], m.syncedTasks[i+1:]...)
+			break
+		}
+	}
+}
+
+// markAllSynced records the in-memory tasks as matching storage
+func (m *Model) markAllSynced() {
+	m.syncedTasks = append([]storage.Task(nil), m.appData.Tasks...)
+}
+
+// markTaskSynced records a single task as matching storage
+func (m *Model) markTaskSynced(task storage.Task) {
+	for i := range m.syncedTasks {
+		if m.syncedTasks[i].ID == task.ID {
+			m.syncedTasks[i] = task
+			return
+		}
+	}
+	m.syncedTasks = append(m.syncedTasks, task)
+}
+
+// reloadExternalChanges reloads data written by another instance and merges it
+// with the in-memory state by task ID
+func (m *Model) reloadExternalChanges() {
+	changed, err := m.storage.ExternalChange()
+	if err != nil {
+		m.storage.LogError(err)
+		return
+	}
+	if !changed {
+		return
+	}
+	
+	remote, err := m.storage.LoadData()
+	if err != nil {
+		m.lastError = err.Error()
+		m.storage.LogError(err)
+		return
+	}
+	
+	remoteTasks := remote.Tasks
+	merged, needsSave := storage.MergeTasks(m.syncedTasks, m.appData.Tasks, remoteTasks)
+	remote.Tasks = merged
+	m.appData = remote
+	m.syncedTasks = append([]storage.Task(nil), remoteTasks...)
+	
+	if needsSave {
+		// Write back local changes the other instance did not have
+		m.persistTasks(storage.ChangedTaskIDs(remoteTasks, merged))
+	}
+	
+	m.notice = "Reloaded changes made by another instance"
+	m.updateTasksForCurrentDate()
+	m.rebuildListItemsPreservingSelection()
+	m.updateListHeight()
 }
 
 // View renders the application UI
@@ -929,6 +1246,17 @@ func (m *Model) View() string {
 		content = m.fitContentToHeight(content, availableHeight)
 		b.WriteString(content)
 		
+		// Add spacing to push footer to bottom
+		contentLines := strings.Count(content, "\n") + 1
+		remainingLines := availableHeight - contentLines
+		if remainingLines > 0 {
+			b.WriteString(strings.Repeat("\n", remainingLines))
+		}
+	case ModeRecurrence:
+		content := m.renderRecurrenceView()
+		content = m.fitContentToHeight(content, availableHeight)
+		b.WriteString(content)
+		
 		// Add spacing to push footer to bottom
 		contentLines := strings.Count(content, "\n") + 1
 		remainingLines := availableHeight - contentLines
@@ -940,6 +1268,39 @@ func (m *Model) View() string {
 		content = m.fitContentToHeight(content, availableHeight)
 		b.WriteString(content)
 		
+		// Add spacing to push footer to bottom
+		contentLines := strings.Count(content, "\n") + 1
+		remainingLines := availableHeight - contentLines
+		if remainingLines > 0 {
+			b.WriteString(strings.Repeat("\n", remainingLines))
+		}
+	case ModeEventDetail:
+		content := m.renderEventDetailView()
+		content = m.fitContentToHeight(content, availableHeight)
+		b.WriteString(content)
+		
+		// Add spacing to push footer to bottom
+		contentLines := strings.Count(content, "\n") + 1
+		remainingLines := availableHeight - contentLines
+		if remainingLines > 0 {
+			b.WriteString(strings.Repeat("\n", remainingLines))
+		}
+	case ModePrepare:
+		content := m.renderPrepareView()
+		content = m.fitContentToHeight(content, availableHeight)
+		b.WriteString(content)
+		
+		// Add spacing to push footer to bottom
+		contentLines := strings.Count(content, "\n") + 1
+		remainingLines := availableHeight - contentLines
+		if remainingLines > 0 {
+			b.WriteString(strings.Repeat("\n", remainingLines))
+		}
+	case ModeTimeline:
+		content := m.renderTimelineView()
+		content = m.fitContentToHeight(content, availableHeight)
+		b.WriteString(content)
+		
 		// Add spacing to push footer to bottom
 		contentLines := strings.Count(content, "\n") + 1
 		remainingLines := availableHeight - contentLines
@@ -981,30 +1342,65 @@ func (m *Model) fitContentToHeight(content string, maxHeight int) string {
 // getTasksForDate gets all tasks for a specific date
 func (m *Model) getTasksForDate(date time.Time) []storage.Task {
 	var tasks []storage.Task
-	targetDate := date.Truncate(24 * time.Hour)
+	targetDate := storage.StartOfDay(date)
 	
 	for _, task := range m.appData.Tasks {
-		if task.Date.Truncate(24*time.Hour).Equal(targetDate) {
+		if storage.SameDay(task.Date, targetDate) {
 			tasks = append(tasks, task)
 		}
 	}
+	tasks = append(tasks, m.calendarEvents[targetDate.Unix()]...)
+	tasks = append(tasks, m.virtualOccurrences(targetDate)...)
 	
-	// Sort tasks: calendar events first (by time), then regular tasks
-	sort.Slice(tasks, func(i, j int) bool {
-		if tasks[i].IsCalendar != tasks[j].IsCalendar {
-			return tasks[i].IsCalendar // Calendar events first
-		}
-		if tasks[i].IsCalendar && tasks[j].IsCalendar {
-			return tasks[i].StartTime.Before(tasks[j].StartTime)
+	return orderDayTasks(tasks)
+}
+
+// orderDayTasks sorts a day's tasks for display: calendar events first (all-day
+// banners, then by time), then regular tasks as a tree with subtasks below
+// their parents
+func orderDayTasks(tasks []storage.Task) []storage.Task {
+	var events, regular []storage.Task
+	for _, task := range tasks {
+		if task.IsCalendar {
+			events = append(events, task)
+		} else {
+			regular = append(regular, task)
 		}
-		return tasks[i].Priority > tasks[j].Priority
+	}
+	
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventSortTime(events[i]).Before(eventSortTime(events[j]))
 	})
 	
-	return tasks
+	return append(events, storage.FlattenTree(regular)...)
 }
 
 
 
+// virtualOccurrences projects open recurring tasks onto a later date. The
+// returned tasks are read-only previews and are never persisted.
+func (m *Model) virtualOccurrences(date time.Time) []storage.Task {
+	var occurrences []storage.Task
+	targetDate := storage.StartOfDay(date)
+	
+	for _, task := range m.appData.Tasks {
+		if task.Recurrence == nil || task.Done || !storage.StartOfDay(task.Date).Before(targetDate) {
+			continue
+		}
+		if !task.Recurrence.OccursOn(task.Date, targetDate) {
+			continue
+		}
+		
+		occurrence := task
+		occurrence.ID = task.ID + "@" + targetDate.Format("2006-01-02")
+		occurrence.Date = targetDate
+		occurrence.Virtual = true
+		occurrences = append(occurrences, occurrence)
+	}
+	
+	return occurrences
+}
+
 // renderEditView renders the edit mode view
 func (m *Model) renderEditView() string {
 	var b strings.Builder
@@ -1021,6 +1417,27 @@ func (m *Model) renderEditView() string {
 	return b.String()
 }
 
+// renderRecurrenceView renders the recurrence edit view
+func (m *Model) renderRecurrenceView() string {
+	var b strings.Builder
+	
+	var taskText string
+	for _, task := range m.appData.Tasks {
+		if task.ID == m.recurrenceTaskID {
+			taskText = task.Text
+			break
+		}
+	}
+	
+	b.WriteString(fmt.Sprintf("Repeat \"%s\":\n\n", taskText))
+	b.WriteString(m.textInput.View())
+	b.WriteString("\n\nExamples: daily • weekdays • weekly on mon,thu • every 2 weeks • monthly on 15 • every 3 days after done")
+	b.WriteString("\nLeave empty or type \"none\" to stop repeating")
+	b.WriteString("\n\nPress Enter to save, Esc to cancel")
+	
+	return b.String()
+}
+
 // renderSearchView renders the search mode view
 func (m *Model) renderSearchView() string {
 	var b strings.Builder
@@ -1128,11 +1545,19 @@ func (m *Model) renderDeleteConfirmView() string {
 		}
 	}
 	
+	// Subtasks are deleted along with their parent
+	question := "Are you sure you want to delete this task?"
+	if subtasks := len(m.subtreeIDs(m.deleteTaskID)) - 1; subtasks == 1 {
+		question = "Are you sure you want to delete this task and its subtask?"
+	} else if subtasks > 1 {
+		question = fmt.Sprintf("Are you sure you want to delete this task and its %d subtasks?", subtasks)
+	}
+	
 	b.WriteString("Delete Task\n\n")
 	if taskText != "" {
-		b.WriteString(fmt.Sprintf("Are you sure you want to delete this task?\n\n\"%s\"\n\n", taskText))
+		b.WriteString(fmt.Sprintf("%s\n\n\"%s\"\n\n", question, taskText))
 	} else {
-		b.WriteString("Are you sure you want to delete this task?\n\n")
+		b.WriteString(question + "\n\n")
 	}
 	b.WriteString("Press 'y' to confirm, 'n' or Esc to cancel")
 	
@@ -1144,25 +1569,34 @@ func (m *Model) renderFooter() string {
 	var b strings.Builder
 	
 	// Help text first - make it adaptive to terminal width
-	help := "↑/↓: navigate • Shift+↑/↓: move tasks • Enter: edit • Space: toggle • d: delete • h: history • /: search • r: quote • ?: help • q: quit"
+	help := "↑/↓: navigate • Shift+↑/↓: move tasks • Enter: edit • Space: toggle • d: delete • u: undo • h: history • /: search • r: quote • ?: help • q: quit"
 	
 	// If terminal is narrow, use shorter help text
 	if m.width < 130 {
-		help = "↑/↓: nav • Shift+↑/↓: move • Enter: edit • Space: toggle • d: del • h: hist • /: search • r: quote • ?: help • q: quit"
+		help = "↑/↓: nav • Shift+↑/↓: move • Enter: edit • Space: toggle • d: del • u: undo • h: hist • /: search • r: quote • ?: help • q: quit"
 	}
 	if m.width < 110 {
 		help = "↑/↓: nav • Enter: edit • Space: toggle • d: del • h: hist • /: search • r: quote • ?: help • q: quit"
 	}
 	if m.width < 90 {
-		help = "↑/↓/Enter/Space/d/h/r/?/q - Press ? for help"
+		help = "↑/↓/Enter/Space/d/c/R/u/h/r/?/q - Press ? for help"
 	}
 	
+	if m.notice != "" {
+		help = "Notice: " + m.notice
+	}
 	if m.lastError != "" {
 		help = "Error: " + m.lastError
 	}
 	
 	b.WriteString(m.styles.Footer.Width(m.width).Render(help))
 	
+	// Sync state of the calendar feeds
+	if status := m.calendarStatus(); status != "" {
+		b.WriteString("\n")
+		b.WriteString(m.styles.Secondary.MaxWidth(m.width).Render(status))
+	}
+	
 	// Quote below help interface (if available)
 	if m.currentQuote != nil {
 		b.WriteString("\n\n") // Visual spacing between help and quote
@@ -1280,7 +1714,10 @@ func (m *Model) rebuildListItems() {
 	var items []list.Item
 	
 	// Always start from the actual current date (today), not m.currentDate
-	today := time.Now().Truncate(24 * time.Hour)
+	today := startOfToday()
+	
+	// Calendar events of today and the next 30 days, expanded once
+	m.loadCalendarEvents(today, storage.AddDays(today, 31))
 	
 	// Add current day (today)
 	items = append(items, ListItem{
@@ -1289,14 +1726,7 @@ func (m *Model) rebuildListItems() {
 	})
 	
 	// Add today's tasks (use m.currentDate for task filtering to maintain compatibility)
-	todayTasks := m.getTasksForDate(today)
-	for _, task := range todayTasks {
-		items = append(items, ListItem{
-			ItemType: "task",
-			Date:     today,
-			Task:     &task,
-		})
-	}
+	items = append(items, m.taskListItems(today)...)
 	
 	// Add today's "add task" button
 	items = append(items, ListItem{
@@ -1306,8 +1736,7 @@ func (m *Model) rebuildListItems() {
 	
 	// Add next 30 days
 	for i := 1; i <= 30; i++ {
-		futureDate := today.Add(time.Duration(i) * 24 * time.Hour)
-		futureTasks := m.getTasksForDate(futureDate)
+		futureDate := storage.AddDays(today, i)
 		
 		// Add day header
 		items = append(items, ListItem{
@@ -1316,13 +1745,7 @@ func (m *Model) rebuildListItems() {
 		})
 		
 		// Add tasks for this day
-		for _, task := range futureTasks {
-			items = append(items, ListItem{
-				ItemType: "task",
-				Date:     futureDate,
-				Task:     &task,
-			})
-		}
+		items = append(items, m.taskListItems(futureDate)...)
 		
 		// Add "add task" button for this day
 		items = append(items, ListItem{
@@ -1334,6 +1757,48 @@ func (m *Model) rebuildListItems() {
 	m.list.SetItems(items)
 }
 
+// taskListItems returns the list items for the tasks of a day. Subtasks of
+// collapsed tasks are left out and summarised on their collapsed ancestor.
+// Events with an open preparation task are marked as prepared.
+func (m *Model) taskListItems(date time.Time) []list.Item {
+	var items []list.Item
+	var prepared map[string]bool
+	
+	tasks := m.getTasksForDate(date)
+	for i := 0; i < len(tasks); i++ {
+		task := tasks[i]
+		item := ListItem{
+			ItemType: "task",
+			Date:     date,
+			Task:     &task,
+		}
+		
+		if task.IsCalendar {
+			if prepared == nil {
+				prepared = m.preparedEvents()
+			}
+			item.Prepared = prepared[task.ID]
+		}
+		
+		if task.Collapsed && !task.IsCalendar {
+			// The subtree continues while tasks are nested deeper
+			end := i + 1
+			for end < len(tasks) && tasks[end].Level > task.Level {
+				item.HiddenTotal++
+				if tasks[end].Done {
+					item.HiddenDone++
+				}
+				end++
+			}
+			i = end - 1
+		}
+		
+		items = append(items, item)
+	}
+	
+	return items
+}
+
 // getSelectedListItem returns the currently selected list item
 func (m *Model) getSelectedListItem() *ListItem {
 	selectedIndex := m.list.Index()
@@ -1361,6 +1826,9 @@ func (m *Model) toggleTaskById(taskID string) {
 	for i := range m.appData.Tasks {
 		if m.appData.Tasks[i].ID == taskID {
 			m.appData.Tasks[i].Done = !m.appData.Tasks[i].Done
+			if m.appData.Tasks[i].Done {
+				m.scheduleNextOccurrence(i)
+			}
 			// Get a new quote when task status changes
 			m.refreshQuote()
 			break
@@ -1369,28 +1837,103 @@ func (m *Model) toggleTaskById(taskID string) {
 	m.updateTasksForCurrentDate()
 }
 
-// deleteTaskById deletes a task by ID
+// scheduleNextOccurrence creates the next occurrence of a recurring task that
+// was just completed. The rule moves to the new task, so the completed one
+// stays behind as a plain done task.
+func (m *Model) scheduleNextOccurrence(index int) {
+	task := m.appData.Tasks[index]
+	if task.Recurrence == nil {
+		return
+	}
+	
+	today := startOfToday()
+	from := task.Date
+	if task.Recurrence.AfterCompletion {
+		from = today
+	}
+	
+	// Catching up on an overdue task should not create more overdue tasks
+	nextDate := task.Recurrence.Next(from)
+	for nextDate.Before(today) {
+		nextDate = task.Recurrence.Next(nextDate)
+	}
+	
+	// The next occurrence is on another day than the parent, so it starts at the top level
+	next := m.storage.CreateTask(task.Text, nextDate)
+	next.Priority = endPriority(m.childrenOf("", nextDate))
+	next.Recurrence = task.Recurrence
+	next.ScheduledAt = task.ScheduledAt
+	next.DurationMinutes = task.DurationMinutes
+	
+	m.appData.Tasks[index].Recurrence = nil
+	m.appData.Tasks = append(m.appData.Tasks, *next)
+}
+
+// deleteTaskById deletes a task and all of its subtasks by ID
 func (m *Model) deleteTaskById(taskID string) {
-	for i := range m.appData.Tasks {
-		if m.appData.Tasks[i].ID == taskID {
-			m.appData.Tasks = append(m.appData.Tasks[:i], m.appData.Tasks[i+1:]...)
-			break
+	deleted := make(map[string]bool)
+	for _, id := range m.subtreeIDs(taskID) {
+		deleted[id] = true
+	}
+	
+	remaining := make([]storage.Task, 0, len(m.appData.Tasks))
+	for _, task := range m.appData.Tasks {
+		if !deleted[task.ID] {
+			remaining = append(remaining, task)
 		}
 	}
+	m.appData.Tasks = remaining
 	m.updateTasksForCurrentDate()
 }
 
-// adjustTaskLevel adjusts a task's hierarchy level by ID
+// adjustTaskLevel indents (delta > 0) or outdents (delta < 0) a task together
+// with its subtasks. Indenting makes the task the last child of the sibling
+// above it; outdenting places it right after its former parent.
 func (m *Model) adjustTaskLevel(taskID string, delta int) {
-	for i := range m.appData.Tasks {
-		if m.appData.Tasks[i].ID == taskID {
-			newLevel := m.appData.Tasks[i].Level + delta
-			if newLevel >= 0 {
-				m.appData.Tasks[i].Level = newLevel
-			}
+	index := m.taskIndex(taskID)
+	if index == -1 || m.appData.Tasks[index].IsCalendar {
+		return
+	}
+	task := m.appData.Tasks[index]
+	
+	switch {
+	case delta > 0:
+		siblings := m.siblingsOf(task)
+		position := indexOfTask(siblings, taskID)
+		if position <= 0 {
+			// Nothing above to nest under
+			break
+		}
+		newParent := siblings[position-1]
+		m.appData.Tasks[index].Priority = endPriority(m.childrenOf(newParent.ID, task.Date))
+		m.appData.Tasks[index].ParentID = newParent.ID
+		
+		// Keep the indented task visible
+		if parentIndex := m.taskIndex(newParent.ID); parentIndex >= 0 {
+			m.appData.Tasks[parentIndex].Collapsed = false
+		}
+		
+	case delta < 0:
+		parent := m.parentOf(task)
+		if parent == nil {
 			break
 		}
+		grandparentID := ""
+		if grandparent := m.parentOf(*parent); grandparent != nil {
+			grandparentID = grandparent.ID
+		}
+		
+		var order []string
+		for _, sibling := range m.siblingsOf(*parent) {
+			order = append(order, sibling.ID)
+			if sibling.ID == parent.ID {
+				order = append(order, taskID)
+			}
+		}
+		m.appData.Tasks[index].ParentID = grandparentID
+		m.setSiblingOrder(order)
 	}
+	
 	m.updateTasksForCurrentDate()
 }
 
@@ -1426,8 +1969,15 @@ func (m *Model) rebuildListItemsPreservingSelection() {
 	// Rebuild the list
 	m.rebuildListItems()
 	
-	// Try to restore selection
+	// Try to restore selection. Events spanning several days share their ID,
+	// so the item on the same day is preferred.
 	if selectedTaskID != "" {
+		for i, item := range m.list.Items() {
+			if listItem, ok := item.(ListItem); ok && listItem.Task != nil && listItem.Task.ID == selectedTaskID && listItem.Date.Equal(selectedDate) {
+				m.list.Select(i)
+				return
+			}
+		}
 		m.setListCursorToTask(selectedTaskID)
 	} else if selectedItemType == "add_button" {
 		// Find the add button for the same date
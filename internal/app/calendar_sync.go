@@ -0,0 +1,201 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"personal-disorganizer/internal/calendar"
+	"personal-disorganizer/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultCalendarRefresh is used when the config does not set a refresh interval
+const defaultCalendarRefresh = 5 * time.Minute
+
+// calendarRefreshMsg triggers a background refresh of all calendar feeds
+type calendarRefreshMsg time.Time
+
+// calendarFeedMsg delivers the result of fetching one calendar feed
+type calendarFeedMsg calendar.FeedUpdate
+
+// localCalendarsChangedMsg lists the local calendar sources whose files changed on disk
+type localCalendarsChangedMsg []string
+
+// taskSyncMsg delivers the outcome of syncing tasks with CalDAV servers
+type taskSyncMsg struct {
+	base    []storage.Task // The tasks the sync started from
+	results []calendar.TaskSyncResult
+}
+
+// calendarRefreshInterval returns how often calendar feeds are refetched
+func (m *Model) calendarRefreshInterval() time.Duration {
+	if seconds := m.storage.GetConfig().RefreshInterval; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultCalendarRefresh
+}
+
+// scheduleCalendarRefresh schedules the next calendarRefreshMsg
+func (m *Model) scheduleCalendarRefresh() tea.Cmd {
+	if len(m.calendarManager.URLs()) == 0 {
+		return nil
+	}
+	return tea.Tick(m.calendarRefreshInterval(), func(t time.Time) tea.Msg {
+		return calendarRefreshMsg(t)
+	})
+}
+
+// refreshCalendars fetches every feed that is not already being fetched and
+// syncs tasks with CalDAV servers
+func (m *Model) refreshCalendars() tea.Cmd {
+	return tea.Batch(m.fetchCalendars(m.calendarManager.URLs()), m.syncTasks())
+}
+
+// checkLocalCalendars looks for local calendar files that changed on disk.
+// Directories can hold thousands of files, so they are checked in the
+// background, one check at a time.
+func (m *Model) checkLocalCalendars() tea.Cmd {
+	if len(m.calendarManager.URLs()) == 0 || m.checkingLocal {
+		return nil
+	}
+	m.checkingLocal = true
+
+	manager := m.calendarManager
+	return func() tea.Msg {
+		return localCalendarsChangedMsg(manager.ChangedLocalSources())
+	}
+}
+
+// refreshChangedCalendars rereads the local calendars found to have changed
+func (m *Model) refreshChangedCalendars(sources localCalendarsChangedMsg) tea.Cmd {
+	m.checkingLocal = false
+	return m.fetchCalendars(sources)
+}
+
+// fetchCalendars fetches the given feeds unless they are already being
+// fetched, each in its own command so one slow feed does not delay the others
+func (m *Model) fetchCalendars(sources []string) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, feedURL := range sources {
+		if !m.calendarManager.MarkSyncing(feedURL) {
+			continue
+		}
+		manager := m.calendarManager
+		cmds = append(cmds, func() tea.Msg {
+			return calendarFeedMsg(manager.Fetch(feedURL))
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// handleCalendarFeed stores a fetched feed and shows its events
+func (m *Model) handleCalendarFeed(update calendar.FeedUpdate) {
+	m.calendarManager.Apply(update)
+	m.updateTasksForCurrentDate()
+	m.rebuildListItemsPreservingSelection()
+	m.updateListHeight()
+}
+
+// syncTasks syncs the tasks with the CalDAV calendars configured for it, one
+// after the other in the background, unless a sync is already running
+func (m *Model) syncTasks() tea.Cmd {
+	sources := m.calendarManager.TaskSources()
+	if len(sources) == 0 || m.taskSyncing {
+		return nil
+	}
+	m.taskSyncing = true
+
+	manager := m.calendarManager
+	base := append([]storage.Task(nil), m.appData.Tasks...)
+	return func() tea.Msg {
+		msg := taskSyncMsg{base: base}
+		tasks := base
+		for _, source := range sources {
+			result := manager.SyncTasks(source, tasks)
+			if result.Tasks != nil {
+				tasks = result.Tasks
+			}
+			msg.results = append(msg.results, result)
+		}
+		return msg
+	}
+}
+
+// handleTaskSync merges the changes read from CalDAV servers with the edits
+// made since the sync started, like changes made by another instance
+func (m *Model) handleTaskSync(msg taskSyncMsg) {
+	m.taskSyncing = false
+
+	synced := msg.base
+	changed := 0
+	var conflicts []string
+	for _, result := range msg.results {
+		if result.Err != nil {
+			m.storage.LogError(fmt.Errorf("task sync with %s failed: %w", result.URL, result.Err))
+		}
+		if result.Tasks != nil {
+			synced = result.Tasks
+		}
+		changed += result.Changed
+		conflicts = append(conflicts, result.Conflicts...)
+	}
+	if len(conflicts) > 0 {
+		m.notice = fmt.Sprintf("Task sync conflict, kept your version of: %s", strings.Join(conflicts, ", "))
+	}
+	if changed == 0 {
+		return
+	}
+
+	merged, _ := storage.MergeTasks(msg.base, m.appData.Tasks, synced)
+	changedIDs := storage.ChangedTaskIDs(m.appData.Tasks, merged)
+	m.appData.Tasks = merged
+	m.persistTasks(changedIDs)
+	m.updateTasksForCurrentDate()
+	m.rebuildListItemsPreservingSelection()
+	m.updateListHeight()
+}
+
+// loadCalendarEvents caches the calendar events of the days from from up to,
+// but not including, to, so each day of the list finds its events
+func (m *Model) loadCalendarEvents(from, to time.Time) {
+	m.calendarEvents = make(map[int64][]storage.Task)
+	for _, event := range m.calendarManager.EventsBetween(from, to) {
+		day := storage.StartOfDay(event.Date).Unix()
+		m.calendarEvents[day] = append(m.calendarEvents[day], event)
+	}
+}
+
+// calendarStatus summarises the sync state of each feed for the footer
+func (m *Model) calendarStatus() string {
+	statuses := m.calendarManager.Statuses()
+	if len(statuses) == 0 {
+		return ""
+	}
+
+	var parts []string
+	for _, status := range statuses {
+		label := status.Name
+		switch {
+		case status.Syncing:
+			label += " ⟳"
+		case status.Stale():
+			label += " ✗ stale since " + syncTimeLabel(status.LastSync)
+		case status.Err != nil:
+			label += " ✗"
+		case !status.LastSync.IsZero():
+			label += " ✓ " + syncTimeLabel(status.LastSync)
+		}
+		parts = append(parts, label)
+	}
+	return "Calendars: " + strings.Join(parts, " • ")
+}
+
+// syncTimeLabel formats a sync time, with the date if it was not today
+func syncTimeLabel(t time.Time) string {
+	if storage.SameDay(t, time.Now()) {
+		return t.Format("15:04")
+	}
+	return t.Format("Jan 2 15:04")
+}
@@ -0,0 +1,213 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/calendar"
+	"personal-disorganizer/internal/storage"
+	"personal-disorganizer/internal/testutil"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runCmd runs a command and its batched commands, passing every resulting
+// message to the model and running the commands it returns
+func runCmd(m *Model, cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, c := range msg {
+			runCmd(m, c)
+		}
+	case nil:
+	default:
+		_, next := m.Update(msg)
+		runCmd(m, next)
+	}
+}
+
+func TestCalendarRefresh(t *testing.T) {
+	today := startOfToday()
+	event := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:review@example.com\r\n" +
+		"DTSTART:" + today.Add(15*time.Hour).UTC().Format("20060102T150405Z") + "\r\n" +
+		"SUMMARY:Review\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken.ics" {
+			http.Error(w, "gone", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(event))
+	}))
+	defer server.Close()
+
+	m := newTestModel(t)
+	m.calendarManager = calendar.NewManager([]string{server.URL + "/work.ics", server.URL + "/broken.ics"})
+
+	cmd := m.refreshCalendars()
+	if status := m.calendarStatus(); status != "Calendars: work ⟳ • broken ⟳" {
+		t.Errorf("Expected both feeds to be syncing, got %q", status)
+	}
+	// Feeds already being fetched are not fetched twice
+	if again := m.refreshCalendars(); again != nil {
+		if msg := again(); msg != nil {
+			t.Errorf("Expected no second fetch, got %T", msg)
+		}
+	}
+
+	runCmd(m, cmd)
+	if len(m.tasks) != 1 || m.tasks[0].Text != "Review" {
+		t.Fatalf("Expected the fetched event on today, got %+v", m.tasks)
+	}
+	status := m.calendarStatus()
+	if !strings.HasPrefix(status, "Calendars: work ✓ ") || !strings.HasSuffix(status, " • broken ✗") {
+		t.Errorf("Unexpected status %q", status)
+	}
+}
+
+func TestCalendarStatus_Stale(t *testing.T) {
+	m := newTestModel(t)
+	feedURL := "https://example.com/work.ics"
+	m.calendarManager = calendar.NewManager([]string{feedURL})
+
+	synced := startOfToday().Add(9*time.Hour + 30*time.Minute)
+	m.calendarManager.Apply(calendar.FeedUpdate{URL: feedURL, Time: synced})
+	if status := m.calendarStatus(); status != "Calendars: work ✓ 09:30" {
+		t.Errorf("Unexpected status %q", status)
+	}
+
+	m.calendarManager.Apply(calendar.FeedUpdate{URL: feedURL, Time: synced.Add(time.Hour), Err: errors.New("offline")})
+	if status := m.calendarStatus(); status != "Calendars: work ✗ stale since 09:30" {
+		t.Errorf("Unexpected status %q", status)
+	}
+
+	m.calendarManager = calendar.NewManager([]string{feedURL})
+	m.calendarManager.Apply(calendar.FeedUpdate{URL: feedURL, Err: errors.New("offline")})
+	if status := m.calendarStatus(); status != "Calendars: work ✗" {
+		t.Errorf("Expected a failed feed without cache not to be stale, got %q", status)
+	}
+}
+
+func TestCalendarRefresh_LocalChanges(t *testing.T) {
+	today := startOfToday()
+	dir := testutil.TempDir(t)
+	writeEvent := func(name, summary string) {
+		event := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:" + name + "\r\n" +
+			"DTSTART:" + today.Add(10*time.Hour).UTC().Format("20060102T150405Z") + "\r\n" +
+			"SUMMARY:" + summary + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+		if err := os.WriteFile(filepath.Join(dir, name+".ics"), []byte(event), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeEvent("planning", "Planning")
+
+	m := newTestModel(t)
+	m.calendarManager = calendar.NewManager([]string{dir})
+	runCmd(m, m.refreshCalendars())
+	if len(m.tasks) != 1 {
+		t.Fatalf("Expected the local event, got %+v", m.tasks)
+	}
+
+	// Files are checked in the background, one check at a time
+	cmd := m.checkLocalCalendars()
+	if cmd == nil {
+		t.Fatal("Expected a background check of the local calendars")
+	}
+	if m.checkLocalCalendars() != nil {
+		t.Error("Expected no second check while one is running")
+	}
+
+	// Unchanged files are not reread
+	msg := cmd()
+	if changed, ok := msg.(localCalendarsChangedMsg); !ok || len(changed) != 0 {
+		t.Errorf("Expected no changed calendars, got %v", msg)
+	}
+	m.Update(msg)
+
+	writeEvent("review", "Review")
+	runCmd(m, m.checkLocalCalendars())
+	if len(m.tasks) != 2 {
+		t.Errorf("Expected the new local event, got %+v", m.tasks)
+	}
+}
+
+func TestRebuildListItems_CalendarEvents(t *testing.T) {
+	today := startOfToday()
+	dir := testutil.TempDir(t)
+	stamp := func(day, hour int) string {
+		return storage.AddDays(today, day).Add(time.Duration(hour) * time.Hour).UTC().Format("20060102T150405Z")
+	}
+	event := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:review\r\nDTSTART:" + stamp(3, 10) + "\r\nSUMMARY:Review\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:offsite\r\nDTSTART:" + stamp(29, 9) + "\r\nDTEND:" + stamp(32, 17) + "\r\nSUMMARY:Offsite\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	if err := os.WriteFile(filepath.Join(dir, "work.ics"), []byte(event), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestModel(t)
+	m.calendarManager = calendar.NewManager([]string{dir})
+	runCmd(m, m.refreshCalendars())
+
+	// Events show up on every listed day they cover, not only the current one
+	events := make(map[int][]string)
+	for _, item := range m.list.Items() {
+		listItem := item.(ListItem)
+		if listItem.ItemType == "task" && listItem.Task.IsCalendar {
+			day := storage.DaysBetween(today, listItem.Date)
+			events[day] = append(events[day], listItem.Task.Text)
+		}
+	}
+	expected := map[int][]string{3: {"Review"}, 29: {"Offsite"}, 30: {"Offsite"}}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected %v, got %v", expected, events)
+	}
+}
+
+func TestHandleTaskSync(t *testing.T) {
+	today := startOfToday()
+	m := newTestModel(t,
+		storage.Task{ID: "a", Text: "Letter", Date: today},
+		storage.Task{ID: "b", Text: "Stamps", Date: today},
+		storage.Task{ID: "c", Text: "Envelope", Date: today},
+	)
+	base := append([]storage.Task(nil), m.appData.Tasks...)
+
+	// While the sync runs, one task is edited here
+	findTask(m, "b").Text = "20 stamps"
+
+	synced := []storage.Task{base[0], base[1], {ID: "d", Text: "Call the bank", Date: today}}
+	synced[0].Text = "Long letter"
+	m.handleTaskSync(taskSyncMsg{base: base, results: []calendar.TaskSyncResult{{
+		Tasks:     synced,
+		Changed:   3,
+		Conflicts: []string{"Stamps"},
+	}}})
+
+	var texts []string
+	for _, task := range m.appData.Tasks {
+		texts = append(texts, task.Text)
+	}
+	expected := []string{"Long letter", "20 stamps", "Call the bank"}
+	if !reflect.DeepEqual(texts, expected) {
+		t.Errorf("Expected %v, got %v", expected, texts)
+	}
+	if !strings.Contains(m.notice, "Stamps") {
+		t.Errorf("Expected the conflict to be reported, got %q", m.notice)
+	}
+
+	// The merged tasks are saved
+	data, err := m.storage.LoadData()
+	if err != nil || len(data.Tasks) != 3 {
+		t.Errorf("Expected the synced tasks to be saved, got %+v (%v)", data, err)
+	}
+}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openURLMsg reports the outcome of opening a link in the browser
+type openURLMsg struct {
+	url string
+	err error
+}
+
+// openBrowser opens a link with the system's default handler. Tests replace
+// it to avoid starting a browser.
+var openBrowser = func(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Run()
+}
+
+// openURL opens a link in the background
+func openURL(url string) tea.Cmd {
+	return func() tea.Msg {
+		return openURLMsg{url: url, err: openBrowser(url)}
+	}
+}
+
+// showEventDetail opens the detail view of a calendar event
+func (m *Model) showEventDetail(task *storage.Task) {
+	m.detailTask = task
+	m.mode = ModeEventDetail
+}
+
+// handleEventDetailMode handles input in the event detail view. The number
+// keys open the event's meeting links, o the first of them, and P creates a
+// preparation task.
+func (m *Model) handleEventDetailMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var links []string
+	if m.detailTask != nil && m.detailTask.Details != nil {
+		links = m.detailTask.Details.MeetingURLs
+	}
+
+	switch key := msg.String(); key {
+	case "esc", "q", "enter":
+		m.mode = ModeView
+		m.detailTask = nil
+
+	case "P":
+		if m.detailTask != nil {
+			m.startPreparing(m.detailTask)
+			m.detailTask = nil
+		}
+
+	case "o", "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		index := 0
+		if key != "o" {
+			index = int(key[0] - '1')
+		}
+		if index >= len(links) {
+			m.notice = "This event has no such meeting link"
+			return m, nil
+		}
+		return m, openURL(links[index])
+	}
+
+	return m, nil
+}
+
+// renderEventDetailView renders the detail view of a calendar event
+func (m *Model) renderEventDetailView() string {
+	if m.detailTask == nil {
+		return ""
+	}
+	task := *m.detailTask
+	details := storage.EventDetails{}
+	if task.Details != nil {
+		details = *task.Details
+	}
+
+	var b strings.Builder
+	b.WriteString("📅 " + task.Text + "\n")
+	if task.CalendarName != "" {
+		b.WriteString("[" + task.CalendarName + "]\n")
+	}
+	b.WriteString("\n")
+
+	// Labels are padded so the values line up
+	field := func(label, value string) {
+		if value != "" {
+			b.WriteString(fmt.Sprintf("%-11s%s\n", label, value))
+		}
+	}
+	field("When:", eventWhen(task))
+	field("Where:", details.Location)
+	field("Organizer:", details.Organizer)
+	for i, attendee := range details.Attendees {
+		if i == 0 {
+			field("Attendees:", attendee)
+		} else {
+			field("", attendee)
+		}
+	}
+	for i, link := range details.MeetingURLs {
+		label := ""
+		if i == 0 {
+			label = "Join:"
+		}
+		b.WriteString(fmt.Sprintf("%-11s%d. %s\n", label, i+1, link))
+	}
+
+	// Line breaks of the description are kept, long lines are wrapped
+	if description := strings.TrimSpace(details.Description); description != "" {
+		b.WriteString("\n")
+		for _, line := range strings.Split(description, "\n") {
+			b.WriteString(m.wrapText(line, m.width-2))
+			b.WriteString("\n")
+		}
+	}
+
+	switch len(details.MeetingURLs) {
+	case 0:
+		b.WriteString("\nPress P to prepare, Esc to go back")
+	case 1:
+		b.WriteString("\nPress o to join, P to prepare, Esc to go back")
+	default:
+		b.WriteString("\nPress 1-" + fmt.Sprint(min(len(details.MeetingURLs), 9)) + " to open a link, P to prepare, Esc to go back")
+	}
+
+	return b.String()
+}
+
+// eventWhen describes when a calendar event takes place, such as
+// "Mon, Jan 15 10:00 – 11:30 (1h 30m)" or "Mon, Jan 15 – Wed, Jan 17 (3 days)"
+func eventWhen(task storage.Task) string {
+	const day = "Mon, Jan 2"
+	start := task.StartTime.Local()
+	end := task.EndTime.Local()
+
+	if task.AllDay {
+		_, total := eventDay(task)
+		if total < 2 {
+			return start.Format(day) + " (all day)"
+		}
+		last := storage.LastDay(task.StartTime, task.EndTime)
+		return fmt.Sprintf("%s – %s (%d days)", start.Format(day), last.Format(day), total)
+	}
+
+	if !end.After(start) {
+		return start.Format(day + " 15:04")
+	}
+	endFormat := "15:04"
+	if !storage.SameDay(start, end) {
+		endFormat = day + " 15:04"
+	}
+	return fmt.Sprintf("%s – %s (%s)", start.Format(day+" 15:04"), end.Format(endFormat), formatDuration(end.Sub(start)))
+}
+
+// formatDuration formats a duration in days, hours and minutes, such as
+// "1h 30m" or "2d 4h"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	if len(parts) == 0 {
+		return "0m"
+	}
+	return strings.Join(parts, " ")
+}
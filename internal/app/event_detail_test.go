@@ -0,0 +1,106 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+	"personal-disorganizer/internal/testutil"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestEventWhen(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		task     storage.Task
+		expected string
+	}{
+		{name: "timed", task: storage.Task{StartTime: at(15, 10, 0), EndTime: at(15, 11, 30)}, expected: "Mon, Jan 15 10:00 – 11:30 (1h 30m)"},
+		{name: "without end", task: storage.Task{StartTime: at(15, 10, 0)}, expected: "Mon, Jan 15 10:00"},
+		{name: "overnight", task: storage.Task{StartTime: at(15, 22, 0), EndTime: at(16, 2, 0)}, expected: "Mon, Jan 15 22:00 – Tue, Jan 16 02:00 (4h)"},
+		{name: "all day", task: storage.Task{AllDay: true, StartTime: at(15, 0, 0), EndTime: at(16, 0, 0)}, expected: "Mon, Jan 15 (all day)"},
+		{name: "several days", task: storage.Task{AllDay: true, StartTime: at(15, 0, 0), EndTime: at(18, 0, 0)}, expected: "Mon, Jan 15 – Wed, Jan 17 (3 days)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if when := eventWhen(tt.task); when != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, when)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                          "0m",
+		45 * time.Minute:           "45m",
+		2 * time.Hour:              "2h",
+		26*time.Hour + time.Minute: "1d 2h 1m",
+	}
+	for duration, expected := range tests {
+		if formatted := formatDuration(duration); formatted != expected {
+			t.Errorf("formatDuration(%v) = %q, expected %q", duration, formatted, expected)
+		}
+	}
+}
+
+func TestEventDetailView(t *testing.T) {
+	today := startOfToday()
+	m := newTestModel(t)
+	m.width = 80
+	m.calendarEvents = map[int64][]storage.Task{today.Unix(): {{
+		ID: "cal_review", Text: "Review", Date: today, IsCalendar: true, CalendarName: "Work",
+		StartTime: today.Add(10 * time.Hour), EndTime: today.Add(11 * time.Hour),
+		Details: &storage.EventDetails{
+			Location:    "Room 4",
+			Description: "Agenda\nJoin at https://zoom.us/j/1",
+			Organizer:   "Jane Doe <jane@example.com>",
+			Attendees:   []string{"Bob (accepted)", "Carol"},
+			MeetingURLs: []string{"https://zoom.us/j/1"},
+		},
+	}}}
+	m.list.SetItems(m.taskListItems(today))
+	m.list.Select(0)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ModeEventDetail {
+		t.Fatalf("Expected Enter on an event to open its details, got mode %v", m.mode)
+	}
+	view := m.renderEventDetailView()
+	for _, expected := range []string{"Review", "[Work]", "(1h)", "Where:     Room 4", "Jane Doe", "           Carol", "1. https://zoom.us/j/1", "Agenda\nJoin at"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected the view to contain %q, got:\n%s", expected, view)
+		}
+	}
+
+	var opened []string
+	original := openBrowser
+	openBrowser = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+	defer func() { openBrowser = original }()
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	runCmd(m, cmd)
+	if len(opened) != 1 || opened[0] != "https://zoom.us/j/1" {
+		t.Errorf("Expected the meeting link to be opened, got %v", opened)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	if m.notice == "" {
+		t.Error("Expected a notice for a missing link")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.mode != ModeView || m.detailTask != nil {
+		t.Errorf("Expected Esc to close the details, got mode %v", m.mode)
+	}
+}
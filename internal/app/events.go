@@ -0,0 +1,72 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// eventDay returns which day of a calendar event the task's date is, counting
+// from 1, and how many local days the event covers
+func eventDay(task storage.Task) (day, total int) {
+	total = storage.DaysBetween(task.StartTime, storage.LastDay(task.StartTime, task.EndTime)) + 1
+	day = storage.DaysBetween(task.StartTime, task.Date) + 1
+	return day, total
+}
+
+// eventDayMarker labels the days of events spanning several days, such as
+// "day 2/3"
+func eventDayMarker(task storage.Task) string {
+	day, total := eventDay(task)
+	if total < 2 || day < 1 || day > total {
+		return ""
+	}
+	return fmt.Sprintf("day %d/%d", day, total)
+}
+
+// isBannerEvent reports whether a calendar event takes up its whole day:
+// all-day events and the middle days of longer timed events. Banners are shown
+// above the day's timed events.
+func isBannerEvent(task storage.Task) bool {
+	if !task.IsCalendar {
+		return false
+	}
+	if task.AllDay {
+		return true
+	}
+	day, total := eventDay(task)
+	return day > 1 && day < total
+}
+
+// eventTimeLabel returns the time shown before a timed event: its start, or
+// its end on the last day of an event that began on an earlier day
+func eventTimeLabel(task storage.Task) string {
+	if day, total := eventDay(task); day > 1 && day == total {
+		return "until " + task.EndTime.Local().Format("15:04")
+	}
+	return task.StartTime.Local().Format("15:04")
+}
+
+// eventSortTime orders a day's calendar events: banners first, then timed
+// events by start
+func eventSortTime(task storage.Task) time.Time {
+	if isBannerEvent(task) {
+		return time.Time{}
+	}
+	return task.StartTime
+}
+
+// eventStyles returns the styles of a calendar event's text and banner, in the
+// color of its calendar if one is configured
+func (d ItemDelegate) eventStyles(task storage.Task) (text, banner lipgloss.Style) {
+	text, banner = d.styles.Calendar, d.styles.AllDayEvent
+	if task.CalendarColor != "" {
+		color := lipgloss.Color(task.CalendarColor)
+		text = text.Foreground(color)
+		banner = banner.Background(color)
+	}
+	return text, banner
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+	"personal-disorganizer/internal/testutil"
+)
+
+func TestEventDayLabels(t *testing.T) {
+	berlin := testutil.SetLocalZone(t, "Europe/Berlin")
+	day := func(d, hour int) time.Time {
+		return time.Date(2024, 1, d, hour, 0, 0, 0, berlin)
+	}
+
+	tests := []struct {
+		name           string
+		task           storage.Task
+		expectedMarker string
+		expectedBanner bool
+		expectedTime   string
+	}{
+		{
+			name:         "meeting",
+			task:         storage.Task{IsCalendar: true, Date: day(15, 0), StartTime: day(15, 9), EndTime: day(15, 10)},
+			expectedTime: "09:00",
+		},
+		{
+			name:           "all-day event",
+			task:           storage.Task{IsCalendar: true, AllDay: true, Date: day(15, 0), StartTime: day(15, 0), EndTime: day(16, 0)},
+			expectedBanner: true,
+		},
+		{
+			name:           "second day of a conference",
+			task:           storage.Task{IsCalendar: true, AllDay: true, Date: day(16, 0), StartTime: day(15, 0), EndTime: day(18, 0)},
+			expectedMarker: "day 2/3",
+			expectedBanner: true,
+		},
+		{
+			name:           "first day of an overnight trip",
+			task:           storage.Task{IsCalendar: true, Date: day(15, 0), StartTime: day(15, 18), EndTime: day(17, 12)},
+			expectedMarker: "day 1/3",
+			expectedTime:   "18:00",
+		},
+		{
+			name:           "middle day of an overnight trip",
+			task:           storage.Task{IsCalendar: true, Date: day(16, 0), StartTime: day(15, 18), EndTime: day(17, 12)},
+			expectedMarker: "day 2/3",
+			expectedBanner: true,
+		},
+		{
+			name:           "last day of an overnight trip",
+			task:           storage.Task{IsCalendar: true, Date: day(17, 0), StartTime: day(15, 18), EndTime: day(17, 12)},
+			expectedMarker: "day 3/3",
+			expectedTime:   "until 12:00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if marker := eventDayMarker(tt.task); marker != tt.expectedMarker {
+				t.Errorf("Expected marker %q, got %q", tt.expectedMarker, marker)
+			}
+			if banner := isBannerEvent(tt.task); banner != tt.expectedBanner {
+				t.Errorf("Expected banner %v, got %v", tt.expectedBanner, banner)
+			}
+			if !tt.expectedBanner {
+				if label := eventTimeLabel(tt.task); label != tt.expectedTime {
+					t.Errorf("Expected time %q, got %q", tt.expectedTime, label)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderDayTasks_AllDayEventsFirst(t *testing.T) {
+	today := startOfToday()
+	at := func(hour int) time.Time {
+		return today.Add(time.Duration(hour) * time.Hour)
+	}
+
+	tasks := []storage.Task{
+		{ID: "task", Text: "Task", Date: today},
+		{ID: "meeting", IsCalendar: true, Date: today, StartTime: at(9), EndTime: at(10)},
+		{ID: "early", IsCalendar: true, Date: today, StartTime: at(7)},
+		{ID: "holiday", IsCalendar: true, AllDay: true, Date: today, StartTime: today, EndTime: storage.AddDays(today, 1)},
+	}
+
+	var order []string
+	for _, task := range orderDayTasks(tasks) {
+		order = append(order, task.ID)
+	}
+	if expected := []string{"holiday", "early", "meeting", "task"}; !reflect.DeepEqual(order, expected) {
+		t.Errorf("Expected %v, got %v", expected, order)
+	}
+}
+
+func TestRenderTask_CalendarBadge(t *testing.T) {
+	m := newTestModel(t)
+	today := startOfToday()
+
+	tests := []struct {
+		name     string
+		task     storage.Task
+		expected string
+	}{
+		{
+			name:     "timed event",
+			task:     storage.Task{IsCalendar: true, Text: "Standup", Date: today, StartTime: today.Add(9 * time.Hour), CalendarName: "Team", CalendarColor: "#50fa7b"},
+			expected: "📅 09:00 [Team] Standup",
+		},
+		{
+			name:     "all-day event",
+			task:     storage.Task{IsCalendar: true, AllDay: true, Text: "Offsite", Date: today, StartTime: today, EndTime: storage.AddDays(today, 1), CalendarName: "Team"},
+			expected: "📅 [Team] Offsite",
+		},
+		{
+			name:     "calendar without a name",
+			task:     storage.Task{IsCalendar: true, Text: "Dentist", Date: today, StartTime: today.Add(14 * time.Hour)},
+			expected: "📅 14:00 Dentist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			m.delegate.renderTask(&b, ListItem{ItemType: "task", Date: today, Task: &tt.task}, false)
+			if got := b.String(); !strings.Contains(got, tt.expected) {
+				t.Errorf("Expected %q in %q", tt.expected, got)
+			}
+		})
+	}
+}
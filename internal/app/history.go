@@ -0,0 +1,150 @@
+package app
+
+import (
+	"reflect"
+
+	"personal-disorganizer/internal/storage"
+)
+
+// maxHistory is the number of task mutations that can be undone
+const maxHistory = 100
+
+// taskState is the state of a single task before or after a command.
+// A nil task means the task did not exist.
+type taskState struct {
+	id   string
+	task *storage.Task
+}
+
+// taskCommand is an undoable change to one or more tasks
+type taskCommand struct {
+	description string
+	before      []taskState
+	after       []taskState
+}
+
+// history holds the undo and redo stacks. It only refers to tasks by ID, so it
+// stays valid across saves and reloads of the underlying data.
+type history struct {
+	undo []taskCommand
+	redo []taskCommand
+}
+
+// push records a new command; this discards anything that could be redone
+func (h *history) push(cmd taskCommand) {
+	h.undo = append(h.undo, cmd)
+	if len(h.undo) > maxHistory {
+		h.undo = h.undo[len(h.undo)-maxHistory:]
+	}
+	h.redo = nil
+}
+
+// popUndo removes the most recent command from the undo stack and moves it to the redo stack
+func (h *history) popUndo() (taskCommand, bool) {
+	if len(h.undo) == 0 {
+		return taskCommand{}, false
+	}
+	cmd := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, cmd)
+	return cmd, true
+}
+
+// popRedo removes the most recently undone command from the redo stack and moves it back to the undo stack
+func (h *history) popRedo() (taskCommand, bool) {
+	if len(h.redo) == 0 {
+		return taskCommand{}, false
+	}
+	cmd := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, cmd)
+	return cmd, true
+}
+
+// recordChange runs mutate and records every task it created, changed or
+// deleted as an undoable command. It returns the IDs of those tasks; nothing
+// is recorded if no task changed.
+func (m *Model) recordChange(description string, mutate func()) []string {
+	original := append([]storage.Task(nil), m.appData.Tasks...)
+	before := make(map[string]storage.Task, len(original))
+	for _, task := range original {
+		before[task.ID] = task
+	}
+
+	mutate()
+
+	cmd := taskCommand{description: description}
+	var changed []string
+	remaining := make(map[string]bool, len(m.appData.Tasks))
+	for _, task := range m.appData.Tasks {
+		remaining[task.ID] = true
+
+		old, existed := before[task.ID]
+		switch {
+		case !existed:
+			cmd.before = append(cmd.before, taskState{id: task.ID})
+		case !reflect.DeepEqual(old, task):
+			cmd.before = append(cmd.before, taskState{id: task.ID, task: &old})
+		default:
+			continue
+		}
+		cmd.after = append(cmd.after, taskState{id: task.ID, task: &task})
+		changed = append(changed, task.ID)
+	}
+
+	for _, task := range original {
+		if !remaining[task.ID] {
+			cmd.before = append(cmd.before, taskState{id: task.ID, task: &task})
+			cmd.after = append(cmd.after, taskState{id: task.ID})
+			changed = append(changed, task.ID)
+		}
+	}
+
+	if len(changed) > 0 {
+		m.history.push(cmd)
+	}
+	return changed
+}
+
+// undo reverts the most recent task mutation
+func (m *Model) undo() {
+	cmd, ok := m.history.popUndo()
+	if !ok {
+		m.notice = "Nothing to undo"
+		return
+	}
+	m.applyTaskStates(cmd.before)
+	m.notice = "Undid " + cmd.description
+}
+
+// redo re-applies the most recently undone task mutation
+func (m *Model) redo() {
+	cmd, ok := m.history.popRedo()
+	if !ok {
+		m.notice = "Nothing to redo"
+		return
+	}
+	m.applyTaskStates(cmd.after)
+	m.notice = "Redid " + cmd.description
+}
+
+// applyTaskStates puts tasks back into the recorded states and persists them
+func (m *Model) applyTaskStates(states []taskState) {
+	var taskIDs []string
+	for _, state := range states {
+		index := m.taskIndex(state.id)
+		switch {
+		case state.task == nil && index >= 0:
+			m.appData.Tasks = append(m.appData.Tasks[:index], m.appData.Tasks[index+1:]...)
+		case state.task != nil && index >= 0:
+			m.appData.Tasks[index] = *state.task
+		case state.task != nil:
+			m.appData.Tasks = append(m.appData.Tasks, *state.task)
+		}
+		taskIDs = append(taskIDs, state.id)
+	}
+	m.persistTasks(taskIDs)
+
+	m.updateTasksForCurrentDate()
+	m.rebuildListItemsPreservingSelection()
+}
@@ -0,0 +1,275 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+	"personal-disorganizer/internal/testutil"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newTestModel creates a model on top of a JSON storage in a temporary directory
+func newTestModel(t *testing.T, tasks ...storage.Task) *Model {
+	t.Helper()
+
+	dir := testutil.TempDir(t)
+	s, err := storage.NewStorageWithPaths(storage.Paths{ConfigDir: dir, DataDir: dir})
+	if err != nil {
+		t.Fatalf("NewStorageWithPaths() error = %v", err)
+	}
+	if err := s.SaveData(&storage.AppData{Tasks: tasks}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+
+	m, err := NewModel(s)
+	if err != nil {
+		t.Fatalf("NewModel() error = %v", err)
+	}
+	return m
+}
+
+// findTask returns the in-memory task with the given ID
+func findTask(m *Model, taskID string) *storage.Task {
+	for i := range m.appData.Tasks {
+		if m.appData.Tasks[i].ID == taskID {
+			return &m.appData.Tasks[i]
+		}
+	}
+	return nil
+}
+
+func TestHistory_UndoRedo(t *testing.T) {
+	today := startOfToday()
+
+	tests := []struct {
+		name   string
+		mutate func(m *Model)
+		check  func(t *testing.T, m *Model)
+	}{
+		{
+			name:   "toggle",
+			mutate: func(m *Model) { m.toggleTaskById("a") },
+			check: func(t *testing.T, m *Model) {
+				if !findTask(m, "a").Done {
+					t.Error("Expected task to be done")
+				}
+			},
+		},
+		{
+			name:   "delete",
+			mutate: func(m *Model) { m.deleteTaskById("a") },
+			check: func(t *testing.T, m *Model) {
+				if findTask(m, "a") != nil {
+					t.Error("Expected task to be deleted")
+				}
+			},
+		},
+		{
+			name:   "indent",
+			mutate: func(m *Model) { m.adjustTaskLevel("a", 1) },
+			check: func(t *testing.T, m *Model) {
+				if findTask(m, "a").ParentID != "above" {
+					t.Error("Expected task to be indented")
+				}
+			},
+		},
+		{
+			name:   "move to another day",
+			mutate: func(m *Model) { m.moveTaskToDay("a", today, storage.AddDays(today, 1), 0) },
+			check: func(t *testing.T, m *Model) {
+				if !findTask(m, "a").Date.Equal(storage.AddDays(today, 1)) {
+					t.Error("Expected task to move to tomorrow")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := storage.Task{ID: "a", Text: "A", Date: today, Priority: 1}
+			m := newTestModel(t, storage.Task{ID: "above", Text: "Above", Date: today, Priority: 2}, original)
+
+			m.recordChange(tt.name, func() { tt.mutate(m) })
+			tt.check(t, m)
+
+			m.undo()
+			task := findTask(m, "a")
+			if task == nil || task.Done != original.Done || task.ParentID != original.ParentID || !task.Date.Equal(original.Date) {
+				t.Fatalf("Expected undo to restore %+v, got %+v", original, task)
+			}
+
+			m.redo()
+			tt.check(t, m)
+		})
+	}
+}
+
+func TestHistory_SurvivesSaveAndReload(t *testing.T) {
+	today := startOfToday()
+	m := newTestModel(t, storage.Task{ID: "a", Text: "A", Date: today})
+
+	m.persistTasks(m.recordChange("delete", func() { m.deleteTaskById("a") }))
+
+	// A reload replaces the task data but keeps the history
+	data, err := m.storage.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	m.appData = data
+
+	m.undo()
+	if findTask(m, "a") == nil {
+		t.Fatal("Expected undo to restore the deleted task")
+	}
+
+	// The restored task is persisted
+	data, err = m.storage.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(data.Tasks) != 1 || data.Tasks[0].ID != "a" {
+		t.Errorf("Expected restored task on disk, got %+v", data.Tasks)
+	}
+}
+
+func TestHistory_NoOpIsNotRecorded(t *testing.T) {
+	m := newTestModel(t, storage.Task{ID: "a", Text: "A"})
+
+	// Outdenting a top-level task changes nothing
+	m.recordChange("outdent", func() { m.adjustTaskLevel("a", -1) })
+
+	if len(m.history.undo) != 0 {
+		t.Errorf("Expected no history entry, got %d", len(m.history.undo))
+	}
+}
+
+func TestHistory_NewChangeClearsRedo(t *testing.T) {
+	m := newTestModel(t, storage.Task{ID: "a", Text: "A"})
+
+	m.recordChange("toggle", func() { m.toggleTaskById("a") })
+	m.undo()
+	m.recordChange("edit", func() { findTask(m, "a").Text = "Edited" })
+
+	m.redo()
+	if m.notice != "Nothing to redo" {
+		t.Errorf("Expected redo stack to be cleared, notice %q", m.notice)
+	}
+	if findTask(m, "a").Done {
+		t.Error("Expected the undone toggle to stay undone")
+	}
+}
+
+func TestHistory_UndoCompletingRecurringTask(t *testing.T) {
+	today := startOfToday()
+	m := newTestModel(t, storage.Task{ID: "a", Text: "Standup", Date: today, Recurrence: &storage.Recurrence{Freq: storage.FreqDaily}})
+
+	m.recordChange("toggle", func() { m.toggleTaskById("a") })
+	if len(m.appData.Tasks) != 2 {
+		t.Fatalf("Expected the next occurrence to be created, got %d tasks", len(m.appData.Tasks))
+	}
+
+	// Undo also removes the generated occurrence
+	m.undo()
+	if len(m.appData.Tasks) != 1 || m.appData.Tasks[0].Recurrence == nil || m.appData.Tasks[0].Done {
+		t.Errorf("Expected only the original open recurring task, got %+v", m.appData.Tasks)
+	}
+}
+
+// recordingBackend counts the writes made through a storage backend
+type recordingBackend struct {
+	storage.Backend
+	fullSaves int
+	upserts   []string
+	deletes   []string
+	flushes   int
+}
+
+func (b *recordingBackend) SaveData(data *storage.AppData) error {
+	b.fullSaves++
+	return b.Backend.SaveData(data)
+}
+
+func (b *recordingBackend) UpsertTask(task storage.Task) error {
+	b.upserts = append(b.upserts, task.ID)
+	return b.Backend.UpsertTask(task)
+}
+
+func (b *recordingBackend) DeleteTask(taskID string) error {
+	b.deletes = append(b.deletes, taskID)
+	return b.Backend.DeleteTask(taskID)
+}
+
+func (b *recordingBackend) FlushExport() {
+	b.flushes++
+	b.Backend.FlushExport()
+}
+
+func TestKeys_WriteOnlyChangedTasks(t *testing.T) {
+	today := startOfToday()
+	m := newTestModel(t,
+		storage.Task{ID: "a", Text: "A", Date: today, Priority: 3},
+		storage.Task{ID: "b", Text: "B", Date: today, Priority: 2},
+		storage.Task{ID: "c", Text: "C", Date: today, Priority: 1},
+	)
+	backend := &recordingBackend{Backend: m.storage}
+	m.storage = backend
+
+	// Select task B
+	for i, item := range m.list.Items() {
+		if listItem := item.(ListItem); listItem.Task != nil && listItem.Task.ID == "b" {
+			m.list.Select(i)
+		}
+	}
+
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune(" ")},
+		{Type: tea.KeyTab},
+		{Type: tea.KeyShiftTab},
+		{Type: tea.KeyRunes, Runes: []rune("d")},
+		{Type: tea.KeyRunes, Runes: []rune("y")},
+	} {
+		m.Update(key)
+	}
+
+	if backend.fullSaves != 0 {
+		t.Errorf("Expected no full saves, got %d", backend.fullSaves)
+	}
+	for _, id := range backend.upserts {
+		if id == "c" {
+			t.Errorf("Expected only changed tasks to be written, got %v", backend.upserts)
+			break
+		}
+	}
+	if len(backend.deletes) != 1 || backend.deletes[0] != "b" {
+		t.Errorf("Expected B to be deleted on its own, got %v", backend.deletes)
+	}
+
+	data, err := m.storage.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(data.Tasks) != 2 {
+		t.Errorf("Expected the deletion to be persisted, got %+v", data.Tasks)
+	}
+}
+
+func TestDataCheck_ExportsWhenIdle(t *testing.T) {
+	m := newTestModel(t, storage.Task{ID: "a", Text: "A", Date: startOfToday()})
+	backend := &recordingBackend{Backend: m.storage}
+	m.storage = backend
+
+	// No export while keys are being pressed
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" ")})
+	m.Update(dataCheckMsg(time.Now()))
+	if backend.flushes != 0 {
+		t.Errorf("Expected no export right after a key press, got %d", backend.flushes)
+	}
+
+	m.lastKeyAt = time.Now().Add(-dataCheckInterval)
+	m.Update(dataCheckMsg(time.Now()))
+	if backend.flushes != 1 {
+		t.Errorf("Expected an export once input is idle, got %d", backend.flushes)
+	}
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"personal-disorganizer/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// prepareTaskPrefix starts the text of tasks created to prepare for an event
+const prepareTaskPrefix = "Prepare: "
+
+// startPreparing asks how long before a calendar event its preparation task
+// is due. If the event already has an open one, that task is selected instead.
+func (m *Model) startPreparing(event *storage.Task) {
+	if taskID := m.openPrepTask(event.ID); taskID != "" {
+		m.mode = ModeView
+		m.detailTask = nil
+		m.setListCursorToTask(taskID)
+		m.notice = "This event already has an open preparation task"
+		return
+	}
+
+	m.mode = ModePrepare
+	m.prepareEvent = event
+	m.textInput.SetValue("0")
+	m.textInput.CursorEnd()
+	m.textInput.Focus()
+}
+
+// handlePrepareMode handles input while choosing the lead time of a
+// preparation task
+func (m *Model) handlePrepareMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = ModeView
+		m.textInput.Blur()
+		m.textInput.SetValue("")
+		m.prepareEvent = nil
+
+	case "enter":
+		days, err := parseLeadTime(m.textInput.Value())
+		if err != nil {
+			// Stay in the input so the lead time can be corrected
+			m.notice = err.Error()
+			return m, nil
+		}
+
+		taskID := m.createPrepTask(*m.prepareEvent, days)
+		m.updateTasksForCurrentDate()
+		m.rebuildListItems()
+		m.setListCursorToTask(taskID)
+
+		m.mode = ModeView
+		m.textInput.Blur()
+		m.textInput.SetValue("")
+		m.prepareEvent = nil
+	}
+
+	return m, nil
+}
+
+// createPrepTask adds a task to prepare for a calendar event, due the given
+// number of days before the event's first day but not before today. It
+// returns the new task's ID.
+func (m *Model) createPrepTask(event storage.Task, leadDays int) string {
+	date := storage.AddDays(storage.StartOfDay(event.StartTime), -leadDays)
+	if today := startOfToday(); date.Before(today) {
+		date = today
+	}
+
+	task := m.storage.CreateTask(prepareTaskPrefix+event.Text, date)
+	task.EventID = event.ID
+	changed := m.recordChange("prepare", func() {
+		task.Priority = endPriority(m.childrenOf("", date))
+		m.appData.Tasks = append(m.appData.Tasks, *task)
+	})
+	m.persistTasks(changed)
+	return task.ID
+}
+
+// openPrepTask returns the ID of an unfinished preparation task of a
+// calendar event, or "" if there is none
+func (m *Model) openPrepTask(eventID string) string {
+	for _, task := range m.appData.Tasks {
+		if task.EventID == eventID && !task.Done {
+			return task.ID
+		}
+	}
+	return ""
+}
+
+// preparedEvents returns the IDs of calendar events that have an unfinished
+// preparation task
+func (m *Model) preparedEvents() map[string]bool {
+	prepared := make(map[string]bool)
+	for _, task := range m.appData.Tasks {
+		if task.EventID != "" && !task.Done {
+			prepared[task.EventID] = true
+		}
+	}
+	return prepared
+}
+
+// parseLeadTime parses how long before an event to prepare for it, such as
+// "0" (on the day), "2", "3d", "1w" or "2 weeks", in days
+func parseLeadTime(text string) (int, error) {
+	value := strings.ToLower(strings.Join(strings.Fields(text), ""))
+	if value == "" {
+		return 0, nil
+	}
+
+	unit := 1
+	for _, suffix := range []struct {
+		name string
+		days int
+	}{{"weeks", 7}, {"week", 7}, {"w", 7}, {"days", 1}, {"day", 1}, {"d", 1}} {
+		if strings.HasSuffix(value, suffix.name) {
+			value = strings.TrimSuffix(value, suffix.name)
+			unit = suffix.days
+			break
+		}
+	}
+
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("invalid lead time %q, expected a number of days or weeks such as 2 or 1w", strings.TrimSpace(text))
+	}
+	return count * unit, nil
+}
+
+// renderPrepareView renders the lead time prompt of a preparation task
+func (m *Model) renderPrepareView() string {
+	if m.prepareEvent == nil {
+		return ""
+	}
+	event := *m.prepareEvent
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Prepare for \"%s\" (%s)\n", event.Text, eventWhen(event)))
+	b.WriteString("How many days before should the task be due?\n\n")
+	b.WriteString(m.textInput.View())
+	b.WriteString("\n\nExamples: 0 (on the day) • 2 • 3d • 1w")
+	b.WriteString("\nThe task is added as \"" + prepareTaskPrefix + event.Text + "\"")
+	b.WriteString("\n\nPress Enter to create, Esc to cancel")
+
+	return b.String()
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/calendar"
+	"personal-disorganizer/internal/storage"
+	"personal-disorganizer/internal/testutil"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseLeadTime(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    int
+		expectError bool
+	}{
+		{input: "", expected: 0},
+		{input: "0", expected: 0},
+		{input: "2", expected: 2},
+		{input: "3d", expected: 3},
+		{input: "1 day", expected: 1},
+		{input: "1w", expected: 7},
+		{input: "2 Weeks", expected: 14},
+		{input: "-1", expectError: true},
+		{input: "soon", expectError: true},
+		{input: "w", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			days, err := parseLeadTime(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q, got %d days", tt.input, days)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if days != tt.expected {
+				t.Errorf("Expected %d days, got %d", tt.expected, days)
+			}
+		})
+	}
+}
+
+// selectEvent selects the list item of a calendar event on a day
+func selectEvent(t *testing.T, m *Model, text string, date time.Time) ListItem {
+	t.Helper()
+	for i, item := range m.list.Items() {
+		listItem := item.(ListItem)
+		if listItem.ItemType == "task" && listItem.Task.IsCalendar && listItem.Task.Text == text && listItem.Date.Equal(date) {
+			m.list.Select(i)
+			return listItem
+		}
+	}
+	t.Fatalf("Event %q not listed on %s", text, date.Format("2006-01-02"))
+	return ListItem{}
+}
+
+func TestPrepareTask(t *testing.T) {
+	today := startOfToday()
+	eventDay := storage.AddDays(today, 5)
+	dir := testutil.TempDir(t)
+	start := eventDay.Add(10 * time.Hour).UTC().Format("20060102T150405Z")
+	event := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:review\r\nDTSTART:" + start + "\r\nSUMMARY:Review\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	if err := os.WriteFile(filepath.Join(dir, "work.ics"), []byte(event), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestModel(t)
+	m.calendarManager = calendar.NewManager([]string{dir})
+	runCmd(m, m.refreshCalendars())
+
+	item := selectEvent(t, m, "Review", eventDay)
+	if item.Prepared {
+		t.Fatal("Expected the event not to be prepared yet")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("P")})
+	if m.mode != ModePrepare {
+		t.Fatalf("Expected P on an event to ask for the lead time, got mode %v", m.mode)
+	}
+	m.textInput.SetValue("2d")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ModeView {
+		t.Fatalf("Expected Enter to create the task, got mode %v", m.mode)
+	}
+
+	var prep *storage.Task
+	for i := range m.appData.Tasks {
+		if m.appData.Tasks[i].EventID == item.Task.ID {
+			prep = &m.appData.Tasks[i]
+		}
+	}
+	if prep == nil {
+		t.Fatalf("Expected a task linked to the event, got %+v", m.appData.Tasks)
+	}
+	if prep.Text != "Prepare: Review" {
+		t.Errorf("Expected text %q, got %q", "Prepare: Review", prep.Text)
+	}
+	if !prep.Date.Equal(storage.AddDays(eventDay, -2)) {
+		t.Errorf("Expected the task 2 days before the event, got %s", prep.Date.Format("2006-01-02"))
+	}
+	if selected := m.getSelectedListItem(); selected == nil || selected.Task == nil || selected.Task.ID != prep.ID {
+		t.Errorf("Expected the new task to be selected, got %+v", selected)
+	}
+
+	// The link is persisted
+	data, err := m.storage.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if saved := data.Tasks[len(data.Tasks)-1]; saved.EventID != item.Task.ID {
+		t.Errorf("Expected the saved task to link to %s, got %q", item.Task.ID, saved.EventID)
+	}
+
+	// The event is marked while the task is open
+	if item = selectEvent(t, m, "Review", eventDay); !item.Prepared {
+		t.Error("Expected the event to be marked as prepared")
+	}
+
+	// A second P selects the open task instead of adding another
+	prepID := prep.ID
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("P")})
+	if m.mode != ModeView || len(m.appData.Tasks) != 1 {
+		t.Errorf("Expected no second preparation task, got mode %v and %d tasks", m.mode, len(m.appData.Tasks))
+	}
+	if selected := m.getSelectedListItem(); selected == nil || selected.Task == nil || selected.Task.ID != prepID {
+		t.Errorf("Expected the open preparation task to be selected, got %+v", selected)
+	}
+
+	// Done tasks no longer mark the event
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" ")})
+	if item = selectEvent(t, m, "Review", eventDay); item.Prepared {
+		t.Error("Expected the event not to be marked once the task is done")
+	}
+}
+
+func TestCreatePrepTask_NotBeforeToday(t *testing.T) {
+	today := startOfToday()
+	m := newTestModel(t)
+	event := storage.Task{ID: "cal_soon", Text: "Soon", IsCalendar: true, StartTime: storage.AddDays(today, 1).Add(9 * time.Hour)}
+
+	taskID := m.createPrepTask(event, 7)
+	if task := findTask(m, taskID); task == nil || !task.Date.Equal(today) {
+		t.Errorf("Expected a lead time reaching into the past to put the task on today, got %+v", task)
+	}
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCompletingRecurringTaskSchedulesNext(t *testing.T) {
+	today := startOfToday()
+
+	tests := []struct {
+		name     string
+		task     storage.Task
+		expected time.Time
+	}{
+		{
+			name:     "daily",
+			task:     storage.Task{ID: "a", Text: "Water plants", Date: today, Recurrence: &storage.Recurrence{Freq: storage.FreqDaily}},
+			expected: storage.AddDays(today, 1),
+		},
+		{
+			name:     "overdue daily catches up to today",
+			task:     storage.Task{ID: "a", Text: "Water plants", Date: today.Add(-72 * time.Hour), Recurrence: &storage.Recurrence{Freq: storage.FreqDaily}},
+			expected: today,
+		},
+		{
+			name:     "every 3 days after completion",
+			task:     storage.Task{ID: "a", Text: "Clean", Date: today.Add(-48 * time.Hour), Recurrence: &storage.Recurrence{Freq: storage.FreqDaily, Interval: 3, AfterCompletion: true}},
+			expected: today.Add(72 * time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t, tt.task)
+
+			m.toggleTaskById("a")
+
+			completed := findTask(m, "a")
+			if !completed.Done || completed.Recurrence != nil {
+				t.Errorf("Expected completed task without recurrence, got %+v", completed)
+			}
+
+			var next *storage.Task
+			for i := range m.appData.Tasks {
+				if m.appData.Tasks[i].ID != "a" {
+					next = &m.appData.Tasks[i]
+				}
+			}
+			if next == nil {
+				t.Fatal("Expected the next occurrence to be created")
+			}
+			if next.Done || next.Text != tt.task.Text || next.Recurrence == nil {
+				t.Errorf("Expected open recurring copy, got %+v", next)
+			}
+			if !next.Date.Equal(tt.expected) {
+				t.Errorf("Expected next occurrence on %s, got %s", tt.expected.Format("2006-01-02"), next.Date.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestVirtualOccurrences(t *testing.T) {
+	today := startOfToday()
+	m := newTestModel(t,
+		storage.Task{ID: "series", Text: "Every other day", Date: today, Recurrence: &storage.Recurrence{Freq: storage.FreqDaily, Interval: 2}},
+		storage.Task{ID: "done", Text: "Finished", Date: today, Done: true, Recurrence: &storage.Recurrence{Freq: storage.FreqDaily}},
+	)
+
+	for offset := 0; offset <= 4; offset++ {
+		date := storage.AddDays(today, offset)
+		tasks := m.getTasksForDate(date)
+
+		var virtual []storage.Task
+		for _, task := range tasks {
+			if task.Virtual {
+				virtual = append(virtual, task)
+			}
+		}
+
+		// The real task covers today; projections follow every second day
+		expected := 0
+		if offset > 0 && offset%2 == 0 {
+			expected = 1
+		}
+		if len(virtual) != expected {
+			t.Fatalf("Day +%d: expected %d virtual occurrences, got %d", offset, expected, len(virtual))
+		}
+		if expected == 1 && (virtual[0].ID == "series" || !virtual[0].Date.Equal(date)) {
+			t.Errorf("Day +%d: expected a distinct occurrence on that day, got %+v", offset, virtual[0])
+		}
+	}
+
+	// Projections are never persisted
+	if err := m.storage.SaveData(m.appData); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	data, err := m.storage.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(data.Tasks) != 2 {
+		t.Errorf("Expected only the 2 real tasks on disk, got %d", len(data.Tasks))
+	}
+}
+
+func TestRecurrenceMode_AnchorsMonthlyRule(t *testing.T) {
+	today := startOfToday()
+	m := newTestModel(t, storage.Task{ID: "a", Text: "Pay rent", Date: today})
+
+	m.startEditingRecurrence(findTask(m, "a"))
+	m.textInput.SetValue("monthly")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if rule := findTask(m, "a").Recurrence; rule == nil || rule.MonthDay != today.Day() {
+		t.Errorf("Expected the rule to keep day %d of the month, got %+v", today.Day(), rule)
+	}
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// midnightMsg is sent when a new day starts
+type midnightMsg time.Time
+
+// startOfToday returns the start of the current local day
+func startOfToday() time.Time {
+	return storage.StartOfDay(time.Now())
+}
+
+// scheduleMidnight schedules a midnightMsg for the start of the next day
+func (m *Model) scheduleMidnight() tea.Cmd {
+	nextDay := storage.AddDays(time.Now(), 1)
+	return tea.Tick(time.Until(nextDay), func(t time.Time) tea.Msg {
+		return midnightMsg(t)
+	})
+}
+
+// handleMidnight moves the view to the new day and rolls over unfinished tasks if enabled
+func (m *Model) handleMidnight() {
+	today := startOfToday()
+	if m.currentDate.Before(today) {
+		m.currentDate = today
+	}
+
+	if m.storage.GetConfig().Rollover {
+		m.rolloverOpenTasks(today)
+	}
+
+	m.updateTasksForCurrentDate()
+	m.rebuildListItemsPreservingSelection()
+}
+
+// rolloverOpenTasks carries unfinished tasks from past days forward to today.
+// Each open task moves together with its subtask block, so done subtasks stay
+// with their parent. Carried tasks go to the top of today in their original
+// order and remember when they first became overdue.
+func (m *Model) rolloverOpenTasks(today time.Time) {
+	// Group past regular tasks by day
+	pastDays := make(map[time.Time][]storage.Task)
+	for _, task := range m.appData.Tasks {
+		day := storage.StartOfDay(task.Date)
+		if task.IsCalendar || !day.Before(today) {
+			continue
+		}
+		pastDays[day] = append(pastDays[day], task)
+	}
+
+	var days []time.Time
+	for day := range pastDays {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	// Collect the blocks to carry, oldest day first
+	var carried []storage.Task
+	for _, day := range days {
+		carried = append(carried, openBlocks(pastDays[day])...)
+	}
+	if len(carried) == 0 {
+		return
+	}
+
+	// Place carried blocks above today's existing top-level tasks
+	rootCount := 0
+	for _, task := range carried {
+		if task.ParentID == "" {
+			rootCount++
+		}
+	}
+	topPriority := startPriority(m.childrenOf("", today))
+
+	updates := make(map[string]storage.Task, len(carried))
+	var carriedIDs []string
+	openCount := 0
+	rootIndex := 0
+	for _, task := range carried {
+		if !task.Done {
+			if task.OverdueSince.IsZero() {
+				task.OverdueSince = storage.StartOfDay(task.Date)
+			}
+			task.DeferCount++
+			openCount++
+		}
+		if task.ParentID == "" {
+			task.Priority = topPriority + rootCount - 1 - rootIndex
+			rootIndex++
+		}
+		task.Date = today
+		updates[task.ID] = task
+		carriedIDs = append(carriedIDs, task.ID)
+	}
+
+	for i := range m.appData.Tasks {
+		if task, ok := updates[m.appData.Tasks[i].ID]; ok {
+			m.appData.Tasks[i] = task
+		}
+	}
+	m.persistTasks(carriedIDs)
+
+	if openCount == 1 {
+		m.notice = "Carried 1 unfinished task over to today"
+	} else {
+		m.notice = fmt.Sprintf("Carried %d unfinished tasks over to today", openCount)
+	}
+}
+
+// openBlocks returns the subtrees of one day that contain unfinished work, in
+// display order. A subtree is rooted at an open task whose ancestors are all
+// done and includes every task below it. Roots become top-level tasks because
+// their parents stay behind.
+func openBlocks(dayTasks []storage.Task) []storage.Task {
+	ordered := storage.FlattenTree(dayTasks)
+
+	var blocks []storage.Task
+	for i := 0; i < len(ordered); i++ {
+		root := ordered[i]
+		if root.Done {
+			continue
+		}
+
+		// The subtree continues while tasks are nested deeper than the root
+		end := i + 1
+		for end < len(ordered) && ordered[end].Level > root.Level {
+			end++
+		}
+
+		root.ParentID = ""
+		blocks = append(blocks, root)
+		blocks = append(blocks, ordered[i+1:end]...)
+		i = end - 1
+	}
+
+	return blocks
+}
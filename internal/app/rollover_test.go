@@ -0,0 +1,112 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+)
+
+func TestOpenBlocks(t *testing.T) {
+	tasks := []storage.Task{
+		{ID: "done-parent", Priority: 3, Done: true},
+		{ID: "open-child", ParentID: "done-parent", Priority: 2},
+		{ID: "done-grandchild", ParentID: "open-child", Priority: 1, Done: true},
+		{ID: "done-sibling", ParentID: "done-parent", Priority: 1, Done: true},
+		{ID: "open-parent", Priority: 2},
+		{ID: "done-child", ParentID: "open-parent", Priority: 1, Done: true},
+		{ID: "done-alone", Priority: 1, Done: true},
+	}
+
+	blocks := openBlocks(tasks)
+
+	expected := []struct {
+		id       string
+		parentID string
+	}{
+		{"open-child", ""},
+		{"done-grandchild", "open-child"},
+		{"open-parent", ""},
+		{"done-child", "open-parent"},
+	}
+	if len(blocks) != len(expected) {
+		t.Fatalf("Expected %d carried tasks, got %+v", len(expected), blocks)
+	}
+	for i, want := range expected {
+		if blocks[i].ID != want.id || blocks[i].ParentID != want.parentID {
+			t.Errorf("Position %d: expected %s below %q, got %s below %q", i, want.id, want.parentID, blocks[i].ID, blocks[i].ParentID)
+		}
+	}
+}
+
+func TestRolloverOpenTasks(t *testing.T) {
+	today := startOfToday()
+	yesterday := storage.AddDays(today, -1)
+	lastWeek := storage.AddDays(today, -7)
+
+	m := newTestModel(t,
+		storage.Task{ID: "old", Text: "Old", Date: lastWeek, Priority: 1},
+		storage.Task{ID: "deferred", Text: "Deferred", Date: yesterday, Priority: 2, OverdueSince: lastWeek, DeferCount: 3},
+		storage.Task{ID: "done", Text: "Done", Date: yesterday, Priority: 1, Done: true},
+		storage.Task{ID: "today", Text: "Today", Date: today, Priority: 5},
+	)
+
+	m.rolloverOpenTasks(today)
+
+	old := findTask(m, "old")
+	if !old.Date.Equal(today) || !old.OverdueSince.Equal(lastWeek) || old.DeferCount != 1 {
+		t.Errorf("Expected old task carried to today, got %+v", old)
+	}
+
+	deferred := findTask(m, "deferred")
+	if !deferred.OverdueSince.Equal(lastWeek) || deferred.DeferCount != 4 {
+		t.Errorf("Expected original overdue date and incremented count, got %+v", deferred)
+	}
+
+	if done := findTask(m, "done"); !done.Date.Equal(yesterday) {
+		t.Errorf("Expected done task to stay on its day, got %+v", done)
+	}
+
+	// Carried tasks come first, oldest day first, above today's own tasks
+	var order []string
+	for _, task := range m.getTasksForDate(today) {
+		order = append(order, task.ID)
+	}
+	if len(order) != 3 || order[0] != "old" || order[1] != "deferred" || order[2] != "today" {
+		t.Errorf("Expected [old deferred today], got %v", order)
+	}
+
+	// The carried state is persisted
+	data, err := m.storage.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	for _, task := range data.Tasks {
+		if task.ID == "old" && (task.DeferCount != 1 || !task.OverdueSince.Equal(lastWeek)) {
+			t.Errorf("Expected rollover state on disk, got %+v", task)
+		}
+	}
+}
+
+func TestOverdueLabel(t *testing.T) {
+	since := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		task     storage.Task
+		expected string
+	}{
+		{name: "never carried", task: storage.Task{}, expected: ""},
+		{name: "carried once", task: storage.Task{OverdueSince: since, DeferCount: 1}, expected: "overdue since Mar 5"},
+		{name: "carried repeatedly", task: storage.Task{OverdueSince: since, DeferCount: 3}, expected: "overdue since Mar 5 (deferred 3×)"},
+		{name: "done", task: storage.Task{OverdueSince: since, DeferCount: 3, Done: true}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overdueLabel(tt.task); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,530 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// slotLength is the time covered by one row of the timeline
+const slotLength = 30 * time.Minute
+
+// slotsPerDay is the number of timeline rows of a whole day
+const slotsPerDay = int(24 * time.Hour / slotLength)
+
+// Hours shown on the timeline even when nothing is planned outside them
+const (
+	timelineFirstHour = 8
+	timelineLastHour  = 18
+)
+
+// minFreeGap is the shortest free time labelled on the timeline
+const minFreeGap = 15 * time.Minute
+
+// maxUnscheduledRows limits the unscheduled tasks listed below the timeline
+const maxUnscheduledRows = 5
+
+// timelineState is the state of the timeline view of a day
+type timelineState struct {
+	date       time.Time
+	slot       int            // Selected row, counted in slots from midnight
+	taskFocus  bool           // Whether the unscheduled task list is selected
+	taskIndex  int            // Selected unscheduled task
+	carryingID string         // Scheduled task being moved to another slot
+	events     []storage.Task // Events of a day outside the main list's 30 days
+}
+
+// timeBlock is a calendar event or a scheduled task on the timeline
+type timeBlock struct {
+	task       storage.Task
+	start, end time.Time
+}
+
+// timeSpan is a stretch of time, such as a free gap between blocks
+type timeSpan struct {
+	start, end time.Time
+}
+
+// slotTime returns the start of a timeline slot of a day
+func slotTime(date time.Time, slot int) time.Time {
+	year, month, day := date.In(time.Local).Date()
+	return time.Date(year, month, day, 0, slot*int(slotLength/time.Minute), 0, 0, time.Local)
+}
+
+// slotOf returns the slot of a day containing a time, clamped to the day.
+// Like slotTime it goes by the local wall clock, as days with a DST change
+// are not 24 hours long.
+func slotOf(date, t time.Time) int {
+	if t.Before(date) {
+		return 0
+	}
+	if !t.Before(storage.AddDays(date, 1)) {
+		return slotsPerDay - 1
+	}
+	local := t.In(time.Local)
+	return (local.Hour()*60 + local.Minute()) / int(slotLength/time.Minute)
+}
+
+// openTimeline shows the timeline of a day, starting at the current time
+// on today and at the start of the working day otherwise
+func (m *Model) openTimeline(date time.Time) {
+	m.timeline = timelineState{date: storage.StartOfDay(date), slot: timelineFirstHour * int(time.Hour/slotLength)}
+	if now := time.Now(); storage.SameDay(now, date) {
+		m.timeline.slot = slotOf(m.timeline.date, now)
+	}
+	m.loadTimelineEvents()
+	m.mode = ModeTimeline
+}
+
+// closeTimeline returns to the task list
+func (m *Model) closeTimeline() {
+	m.mode = ModeView
+	m.timeline = timelineState{}
+	m.updateTasksForCurrentDate()
+	m.rebuildListItemsPreservingSelection()
+}
+
+// loadTimelineEvents expands the events of the timeline's day when it is not
+// one of the days the main list already holds events for
+func (m *Model) loadTimelineEvents() {
+	m.timeline.events = nil
+	if days := storage.DaysBetween(startOfToday(), m.timeline.date); days < 0 || days > 30 {
+		m.timeline.events = m.calendarManager.EventsOn(m.timeline.date)
+	}
+}
+
+// timelineEvents returns the calendar events of the timeline's day
+func (m *Model) timelineEvents() []storage.Task {
+	if days := storage.DaysBetween(startOfToday(), m.timeline.date); days < 0 || days > 30 {
+		return m.timeline.events
+	}
+	return m.calendarEvents[m.timeline.date.Unix()]
+}
+
+// timelineBlocks returns the timed events and scheduled tasks of the
+// timeline's day sorted by start, and the events that take the whole day.
+// A task being moved is placed at the selected slot.
+func (m *Model) timelineBlocks() (blocks []timeBlock, allDay []storage.Task) {
+	dayStart := m.timeline.date
+	dayEnd := storage.AddDays(dayStart, 1)
+
+	for _, event := range m.timelineEvents() {
+		if isBannerEvent(event) {
+			allDay = append(allDay, event)
+			continue
+		}
+		start, end := event.StartTime, event.EndTime
+		if start.Before(dayStart) {
+			start = dayStart
+		}
+		if end.After(dayEnd) {
+			end = dayEnd
+		}
+		if end.Before(start) {
+			end = start
+		}
+		blocks = append(blocks, timeBlock{task: event, start: start, end: end})
+	}
+
+	for _, task := range m.appData.Tasks {
+		if task.IsCalendar || !storage.SameDay(task.Date, dayStart) {
+			continue
+		}
+		if task.ID == m.timeline.carryingID {
+			start := slotTime(dayStart, m.timeline.slot)
+			blocks = append(blocks, timeBlock{task: task, start: start, end: start.Add(task.Duration())})
+		} else if start, end, ok := task.Schedule(); ok {
+			blocks = append(blocks, timeBlock{task: task, start: start, end: end})
+		}
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool {
+		return blocks[i].start.Before(blocks[j].start)
+	})
+	return blocks, allDay
+}
+
+// unscheduledTasks returns the open tasks of the timeline's day without a
+// scheduled start, in list order
+func (m *Model) unscheduledTasks() []storage.Task {
+	var tasks []storage.Task
+	for _, task := range m.getTasksForDate(m.timeline.date) {
+		if task.IsCalendar || task.Virtual || task.Done || !task.ScheduledAt.IsZero() || task.ID == m.timeline.carryingID {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// freeSpans returns the gaps between blocks from one time to another
+func freeSpans(blocks []timeBlock, from, to time.Time) []timeSpan {
+	var spans []timeSpan
+	cursor := from
+	for _, block := range blocks {
+		if block.start.After(cursor) && cursor.Before(to) {
+			end := block.start
+			if end.After(to) {
+				end = to
+			}
+			spans = append(spans, timeSpan{start: cursor, end: end})
+		}
+		if block.end.After(cursor) {
+			cursor = block.end
+		}
+	}
+	if cursor.Before(to) {
+		spans = append(spans, timeSpan{start: cursor, end: to})
+	}
+	return spans
+}
+
+// visibleSlots returns the range of slots shown: the working day, extended to
+// whatever is planned outside it and to the selected slot
+func (m *Model) visibleSlots(blocks []timeBlock) (first, last int) {
+	perHour := int(time.Hour / slotLength)
+	first, last = timelineFirstHour*perHour, timelineLastHour*perHour
+	for _, block := range blocks {
+		first = min(first, slotOf(m.timeline.date, block.start))
+		if block.end.After(block.start) {
+			last = max(last, slotOf(m.timeline.date, block.end.Add(-time.Nanosecond))+1)
+		}
+	}
+	first = min(first, m.timeline.slot)
+	last = max(last, m.timeline.slot+1)
+	return first, last
+}
+
+// taskBlockAt returns the scheduled task covering a slot, preferring one
+// that starts in it
+func (m *Model) taskBlockAt(blocks []timeBlock, slot int) *timeBlock {
+	slotStart := slotTime(m.timeline.date, slot)
+	slotEnd := slotStart.Add(slotLength)
+
+	var covering *timeBlock
+	for i := range blocks {
+		block := &blocks[i]
+		if block.task.IsCalendar || !block.start.Before(slotEnd) || !block.end.After(slotStart) {
+			continue
+		}
+		if !block.start.Before(slotStart) {
+			return block
+		}
+		if covering == nil {
+			covering = block
+		}
+	}
+	return covering
+}
+
+// handleTimelineMode handles input in the timeline. Tasks are scheduled by
+// selecting a slot and then a task below the timeline, or moved by picking
+// up their block with Enter and dropping it at another slot.
+func (m *Model) handleTimelineMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	blocks, _ := m.timelineBlocks()
+	unscheduled := m.unscheduledTasks()
+
+	switch msg.String() {
+	case "esc":
+		if m.timeline.carryingID != "" {
+			m.timeline.carryingID = ""
+			break
+		}
+		m.closeTimeline()
+
+	case "t":
+		m.closeTimeline()
+
+	case "tab":
+		if m.timeline.carryingID == "" && len(unscheduled) > 0 {
+			m.timeline.taskFocus = !m.timeline.taskFocus
+		}
+
+	case "up", "k":
+		if m.timeline.taskFocus {
+			m.timeline.taskIndex = max(m.timeline.taskIndex-1, 0)
+		} else {
+			m.timeline.slot = max(m.timeline.slot-1, 0)
+		}
+
+	case "down", "j":
+		if m.timeline.taskFocus {
+			m.timeline.taskIndex = min(m.timeline.taskIndex+1, len(unscheduled)-1)
+		} else {
+			m.timeline.slot = min(m.timeline.slot+1, slotsPerDay-1)
+		}
+
+	case "n", "p":
+		days := 1
+		if msg.String() == "p" {
+			days = -1
+		}
+		m.timeline.date = storage.AddDays(m.timeline.date, days)
+		m.timeline.carryingID = ""
+		m.timeline.taskFocus = false
+		m.timeline.taskIndex = 0
+		m.loadTimelineEvents()
+
+	case "f":
+		m.selectNextFreeSlot(blocks)
+
+	case "enter":
+		switch {
+		case m.timeline.taskFocus:
+			if m.timeline.taskIndex < len(unscheduled) {
+				task := unscheduled[m.timeline.taskIndex]
+				m.scheduleTask(task.ID, slotTime(m.timeline.date, m.timeline.slot), task.Duration())
+			}
+			m.timeline.taskFocus = false
+			m.timeline.taskIndex = 0
+		case m.timeline.carryingID != "":
+			taskID := m.timeline.carryingID
+			m.timeline.carryingID = ""
+			if index := m.taskIndex(taskID); index >= 0 {
+				m.scheduleTask(taskID, slotTime(m.timeline.date, m.timeline.slot), m.appData.Tasks[index].Duration())
+			}
+		default:
+			if block := m.taskBlockAt(blocks, m.timeline.slot); block != nil {
+				m.timeline.carryingID = block.task.ID
+				m.timeline.slot = slotOf(m.timeline.date, block.start)
+			} else if len(unscheduled) > 0 {
+				m.timeline.taskFocus = true
+			} else {
+				m.notice = "No unscheduled tasks on this day"
+			}
+		}
+
+	case "+", "=", "-":
+		block := m.taskBlockAt(blocks, m.timeline.slot)
+		if block == nil || m.timeline.taskFocus {
+			break
+		}
+		duration := block.task.Duration() + slotLength
+		if msg.String() == "-" {
+			duration = max(block.task.Duration()-slotLength, slotLength)
+		}
+		if m.timeline.carryingID == block.task.ID {
+			// Only the duration changes until the task is dropped
+			m.setTaskDuration(block.task.ID, duration)
+		} else {
+			m.scheduleTask(block.task.ID, block.start, duration)
+		}
+
+	case "x", "backspace", "delete":
+		if block := m.taskBlockAt(blocks, m.timeline.slot); block != nil && !m.timeline.taskFocus {
+			taskID := block.task.ID
+			m.timeline.carryingID = ""
+			m.recordChange("unschedule", func() {
+				if index := m.taskIndex(taskID); index >= 0 {
+					m.appData.Tasks[index].ClearSchedule()
+				}
+			})
+			m.saveTask(taskID)
+		}
+
+	case "u":
+		m.timeline.carryingID = ""
+		m.undo()
+
+	case "ctrl+r":
+		m.timeline.carryingID = ""
+		m.redo()
+	}
+
+	if n := len(m.unscheduledTasks()); m.timeline.taskIndex >= n {
+		m.timeline.taskIndex = max(n-1, 0)
+		if n == 0 {
+			m.timeline.taskFocus = false
+		}
+	}
+	return m, nil
+}
+
+// selectNextFreeSlot moves the selection to the next slot after the selected
+// one that nothing is planned in
+func (m *Model) selectNextFreeSlot(blocks []timeBlock) {
+	for slot := m.timeline.slot + 1; slot < slotsPerDay; slot++ {
+		slotStart := slotTime(m.timeline.date, slot)
+		slotEnd := slotStart.Add(slotLength)
+		busy := false
+		for _, block := range blocks {
+			if block.task.ID != m.timeline.carryingID && block.start.Before(slotEnd) && block.end.After(slotStart) {
+				busy = true
+				break
+			}
+		}
+		if !busy {
+			m.timeline.slot = slot
+			return
+		}
+	}
+	m.notice = "No free time left on this day"
+}
+
+// scheduleTask plans a task at a time of its day and reports a clash with
+// other events or tasks in the footer
+func (m *Model) scheduleTask(taskID string, start time.Time, duration time.Duration) {
+	m.recordChange("schedule", func() {
+		if index := m.taskIndex(taskID); index >= 0 {
+			m.appData.Tasks[index].SetSchedule(start, duration)
+		}
+	})
+	m.saveTask(taskID)
+
+	blocks, _ := m.timelineBlocks()
+	end := start.Add(duration)
+	for _, block := range blocks {
+		if block.task.ID != taskID && block.start.Before(end) && block.end.After(start) {
+			m.notice = "Overlaps with " + block.task.Text
+			return
+		}
+	}
+}
+
+// setTaskDuration changes the planned length of a task
+func (m *Model) setTaskDuration(taskID string, duration time.Duration) {
+	m.recordChange("schedule", func() {
+		if index := m.taskIndex(taskID); index >= 0 {
+			m.appData.Tasks[index].DurationMinutes = int(duration / time.Minute)
+		}
+	})
+	m.saveTask(taskID)
+}
+
+// renderTimelineView renders a day as hours from top to bottom with its
+// events and scheduled tasks as blocks, the free time between them, and the
+// tasks still to be scheduled below
+func (m *Model) renderTimelineView() string {
+	blocks, allDay := m.timelineBlocks()
+	first, last := m.visibleSlots(blocks)
+	free := freeSpans(blocks, slotTime(m.timeline.date, first), slotTime(m.timeline.date, last))
+
+	var header []string
+	title := "Timeline - " + m.timeline.date.Format("Monday, January 2")
+	if storage.SameDay(m.timeline.date, time.Now()) {
+		title = "Timeline - Today - " + m.timeline.date.Format("Monday, January 2")
+	}
+	header = append(header, m.styles.TodayHeader.Render(title), "")
+	for _, event := range allDay {
+		line := "📅 " + event.Text
+		if event.CalendarName != "" {
+			line = "📅 [" + event.CalendarName + "] " + event.Text
+		}
+		_, banner := m.delegate.eventStyles(event)
+		header = append(header, "  "+banner.Render(line+" (all day)"))
+	}
+	if len(allDay) > 0 {
+		header = append(header, "")
+	}
+
+	var rows []string
+	for slot := first; slot < last; slot++ {
+		rows = append(rows, m.renderTimelineRow(slot, slot == first, blocks, free))
+	}
+
+	footer := []string{""}
+	unscheduled := m.unscheduledTasks()
+	if len(unscheduled) == 0 {
+		footer = append(footer, m.styles.Secondary.Render("All open tasks of this day are scheduled"))
+	} else {
+		footer = append(footer, "Unscheduled:")
+		offset := max(0, min(m.timeline.taskIndex-maxUnscheduledRows/2, len(unscheduled)-maxUnscheduledRows))
+		for i := offset; i < len(unscheduled) && i < offset+maxUnscheduledRows; i++ {
+			prefix := "  "
+			if m.timeline.taskFocus && i == m.timeline.taskIndex {
+				prefix = "> "
+			}
+			footer = append(footer, prefix+"☐ "+m.styles.TaskActive.Render(unscheduled[i].Text))
+		}
+		if hidden := len(unscheduled) - maxUnscheduledRows; hidden > 0 {
+			footer = append(footer, m.styles.Secondary.Render(fmt.Sprintf("  (%d tasks in total)", len(unscheduled))))
+		}
+	}
+
+	footer = append(footer, "")
+	switch {
+	case m.timeline.carryingID != "":
+		footer = append(footer, "↑/↓: move • +/-: duration • Enter: place • Esc: cancel")
+	case m.timeline.taskFocus:
+		footer = append(footer, "↑/↓: choose task • Enter: schedule at selected time • Tab: back to timeline")
+	default:
+		footer = append(footer, "↑/↓: time • Enter: schedule/move • Tab: tasks • +/-: duration • x: unschedule • f: next free • n/p: day • Esc: back")
+	}
+
+	// Only part of the day fits on small screens, kept around the selection
+	room := max(m.list.Height()-len(header)-len(footer), 3)
+	if len(rows) > room {
+		top := min(max(m.timeline.slot-first-room/2, 0), len(rows)-room)
+		rows = rows[top : top+room]
+	}
+
+	lines := append(header, rows...)
+	return strings.Join(append(lines, footer...), "\n")
+}
+
+// renderTimelineRow renders one slot: its time, a bar that is solid while
+// something is planned, the blocks starting in it and the free time that
+// starts in it. The first row also names blocks that began earlier.
+func (m *Model) renderTimelineRow(slot int, firstRow bool, blocks []timeBlock, free []timeSpan) string {
+	slotStart := slotTime(m.timeline.date, slot)
+	slotEnd := slotStart.Add(slotLength)
+
+	busy := false
+	var labels []string
+	for _, block := range blocks {
+		if block.start.Before(slotEnd) && block.end.After(slotStart) {
+			busy = true
+		}
+		startsHere := !block.start.Before(slotStart) && block.start.Before(slotEnd)
+		if startsHere || (firstRow && block.start.Before(slotStart) && block.end.After(slotStart)) {
+			labels = append(labels, m.timelineBlockLabel(block))
+		}
+	}
+	for _, span := range free {
+		if !span.start.Before(slotStart) && span.start.Before(slotEnd) && span.end.Sub(span.start) >= minFreeGap {
+			labels = append(labels, m.styles.Secondary.Render("free "+formatDuration(span.end.Sub(span.start))))
+		}
+	}
+
+	prefix := "  "
+	if !m.timeline.taskFocus && slot == m.timeline.slot {
+		prefix = "> "
+	}
+	bar := m.styles.Secondary.Render("┊")
+	if busy {
+		bar = m.styles.Calendar.Render("│")
+	}
+	return fmt.Sprintf("%s%s %s %s", prefix, slotStart.Format("15:04"), bar, strings.Join(labels, " • "))
+}
+
+// timelineBlockLabel describes a block by its time and text
+func (m *Model) timelineBlockLabel(block timeBlock) string {
+	when := block.start.Local().Format("15:04")
+	if block.end.After(block.start) {
+		when += "–" + block.end.Local().Format("15:04")
+	}
+
+	task := block.task
+	if task.IsCalendar {
+		textStyle, _ := m.delegate.eventStyles(task)
+		label := "📅 " + when + " "
+		if task.CalendarName != "" {
+			label += "[" + task.CalendarName + "] "
+		}
+		return textStyle.Render(label + task.Text)
+	}
+
+	label := "☐ " + when + " " + m.styles.TaskActive.Render(task.Text)
+	if task.Done {
+		label = "☑ " + when + " " + m.styles.TaskCompleted.Render(task.Text)
+	}
+	if task.ID == m.timeline.carryingID {
+		label += m.styles.Secondary.Render(" (moving)")
+	}
+	return label
+}
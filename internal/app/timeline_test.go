@@ -0,0 +1,277 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/calendar"
+	"personal-disorganizer/internal/storage"
+	"personal-disorganizer/internal/testutil"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFreeSpans(t *testing.T) {
+	day := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	block := func(from, to time.Time) timeBlock {
+		return timeBlock{start: from, end: to}
+	}
+
+	tests := []struct {
+		name     string
+		blocks   []timeBlock
+		expected []timeSpan
+	}{
+		{
+			name:     "nothing planned",
+			expected: []timeSpan{{at(8, 0), at(18, 0)}},
+		},
+		{
+			name:     "gaps around a block",
+			blocks:   []timeBlock{block(at(9, 0), at(9, 15))},
+			expected: []timeSpan{{at(8, 0), at(9, 0)}, {at(9, 15), at(18, 0)}},
+		},
+		{
+			name:     "overlapping and nested blocks",
+			blocks:   []timeBlock{block(at(9, 0), at(11, 0)), block(at(9, 30), at(10, 0)), block(at(10, 30), at(12, 0))},
+			expected: []timeSpan{{at(8, 0), at(9, 0)}, {at(12, 0), at(18, 0)}},
+		},
+		{
+			name:     "blocks outside the range",
+			blocks:   []timeBlock{block(at(6, 0), at(8, 30)), block(at(17, 0), at(20, 0))},
+			expected: []timeSpan{{at(8, 30), at(17, 0)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if spans := freeSpans(tt.blocks, at(8, 0), at(18, 0)); !reflect.DeepEqual(spans, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, spans)
+			}
+		})
+	}
+}
+
+func TestSlotOf_DST(t *testing.T) {
+	berlin := testutil.SetLocalZone(t, "Europe/Berlin")
+
+	tests := []struct {
+		name     string
+		day      time.Time
+		at       time.Time
+		expected int
+	}{
+		{
+			name:     "before the spring change",
+			day:      time.Date(2026, 3, 29, 0, 0, 0, 0, berlin),
+			at:       time.Date(2026, 3, 29, 1, 30, 0, 0, berlin),
+			expected: 3,
+		},
+		{
+			name:     "after the spring change",
+			day:      time.Date(2026, 3, 29, 0, 0, 0, 0, berlin),
+			at:       time.Date(2026, 3, 29, 10, 0, 0, 0, berlin),
+			expected: 20,
+		},
+		{
+			name:     "after the autumn change",
+			day:      time.Date(2026, 10, 25, 0, 0, 0, 0, berlin),
+			at:       time.Date(2026, 10, 25, 10, 0, 0, 0, berlin),
+			expected: 20,
+		},
+		{
+			name:     "end of the day",
+			day:      time.Date(2026, 10, 25, 0, 0, 0, 0, berlin),
+			at:       time.Date(2026, 10, 26, 0, 0, 0, 0, berlin),
+			expected: slotsPerDay - 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot := slotOf(tt.day, tt.at)
+			if slot != tt.expected {
+				t.Errorf("Expected slot %d, got %d", tt.expected, slot)
+			}
+			if tt.expected < slotsPerDay-1 && !slotTime(tt.day, slot).Equal(tt.at) {
+				t.Errorf("Expected slot %d to start at %v, got %v", slot, tt.at, slotTime(tt.day, slot))
+			}
+		})
+	}
+}
+
+func TestTimeline_MoveOnDSTDay(t *testing.T) {
+	berlin := testutil.SetLocalZone(t, "Europe/Berlin")
+	day := time.Date(2026, 3, 29, 0, 0, 0, 0, berlin)
+	at := time.Date(2026, 3, 29, 10, 0, 0, 0, berlin)
+	m := newTestModel(t, storage.Task{ID: "a", Text: "Write", Date: day, ScheduledAt: at})
+
+	// Picking a block up and dropping it in place leaves it where it was
+	m.openTimeline(day)
+	m.timeline.slot = 20
+	pressKey(m, "enter")
+	if m.timeline.carryingID != "a" || m.timeline.slot != 20 {
+		t.Fatalf("Expected the block picked up at slot 20, got %q at %d", m.timeline.carryingID, m.timeline.slot)
+	}
+	pressKey(m, "enter")
+	if start, _, _ := findTask(m, "a").Schedule(); !start.Equal(at) {
+		t.Errorf("Expected the task to stay at 10:00, got %v", start)
+	}
+}
+
+// pressKey sends a key press to the model
+func pressKey(m *Model, keys string) {
+	switch keys {
+	case "enter":
+		m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	case "esc":
+		m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	case "down":
+		m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	default:
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(keys)})
+	}
+}
+
+func TestTimeline_ScheduleTasks(t *testing.T) {
+	today := startOfToday()
+	day := storage.AddDays(today, 1)
+	at := func(hour, minute int) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.Local)
+	}
+
+	dir := testutil.TempDir(t)
+	stamp := func(hour, minute int) string {
+		return at(hour, minute).UTC().Format("20060102T150405Z")
+	}
+	event := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:standup\r\nDTSTART:" + stamp(9, 0) + "\r\nDTEND:" + stamp(9, 15) + "\r\nSUMMARY:Standup\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:review\r\nDTSTART:" + stamp(11, 0) + "\r\nDTEND:" + stamp(12, 30) + "\r\nSUMMARY:Review\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	if err := os.WriteFile(filepath.Join(dir, "work.ics"), []byte(event), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestModel(t,
+		storage.Task{ID: "report", Text: "Write report", Date: day, Priority: 2},
+		storage.Task{ID: "email", Text: "Email Bob", Date: day, Priority: 1},
+	)
+	m.calendarManager = calendar.NewManager([]string{dir})
+	runCmd(m, m.refreshCalendars())
+	m.Update(tea.WindowSizeMsg{Width: 100, Height: 60})
+
+	m.openTimeline(day)
+	if m.mode != ModeTimeline || m.timeline.slot != 16 {
+		t.Fatalf("Expected the timeline to open at 08:00, got mode %v at slot %d", m.mode, m.timeline.slot)
+	}
+	view := m.renderTimelineView()
+	for _, expected := range []string{"09:00–09:15 Standup", "free 1h 45m", "11:00–12:30 Review", "free 5h 30m", "Unscheduled:", "Write report"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected the timeline to contain %q, got:\n%s", expected, view)
+		}
+	}
+
+	// Select 09:30 with the next free slot, then a task for it
+	pressKey(m, "f")
+	pressKey(m, "f")
+	if m.timeline.slot != 19 {
+		t.Fatalf("Expected the second free slot to be 09:30, got slot %d", m.timeline.slot)
+	}
+	pressKey(m, "enter")
+	if !m.timeline.taskFocus {
+		t.Fatal("Expected Enter on a free slot to select a task to schedule")
+	}
+	pressKey(m, "enter")
+	report := findTask(m, "report")
+	if start, end, ok := report.Schedule(); !ok || !start.Equal(at(9, 30)) || !end.Equal(at(10, 0)) {
+		t.Fatalf("Expected the report at 09:30-10:00, got %v - %v (%v)", start, end, ok)
+	}
+	if view := m.renderTimelineView(); !strings.Contains(view, "09:30–10:00 Write report") || !strings.Contains(view, "free 15m") {
+		t.Errorf("Expected the report as a block after 15 free minutes, got:\n%s", view)
+	}
+
+	// Lengthen it, then move it onto the review
+	pressKey(m, "+")
+	if report := findTask(m, "report"); report.Duration() != time.Hour {
+		t.Errorf("Expected + to lengthen the task to 1h, got %v", report.Duration())
+	}
+	pressKey(m, "enter")
+	if m.timeline.carryingID != "report" {
+		t.Fatalf("Expected Enter on a task block to pick it up, got %q", m.timeline.carryingID)
+	}
+	for i := 0; i < 3; i++ {
+		pressKey(m, "down")
+	}
+	pressKey(m, "enter")
+	if start, end, ok := findTask(m, "report").Schedule(); !ok || !start.Equal(at(11, 0)) || !end.Equal(at(12, 0)) {
+		t.Errorf("Expected the report moved to 11:00-12:00, got %v - %v (%v)", start, end, ok)
+	}
+	if m.notice != "Overlaps with Review" {
+		t.Errorf("Expected a notice about the overlap, got %q", m.notice)
+	}
+
+	// Moving can be cancelled
+	pressKey(m, "enter")
+	pressKey(m, "down")
+	pressKey(m, "esc")
+	if start, _, _ := findTask(m, "report").Schedule(); !start.Equal(at(11, 0)) || m.mode != ModeTimeline {
+		t.Errorf("Expected Esc to cancel the move, got start %v in mode %v", start, m.mode)
+	}
+
+	// The schedule is persisted and shown in the task list
+	data, err := m.storage.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	for _, task := range data.Tasks {
+		if task.ID == "report" && (task.ScheduledAt.IsZero() || task.DurationMinutes != 60) {
+			t.Errorf("Expected the schedule to be saved, got %+v", task)
+		}
+	}
+	pressKey(m, "esc")
+	if m.mode != ModeView {
+		t.Fatalf("Expected Esc to close the timeline, got mode %v", m.mode)
+	}
+	if list := m.list.View(); !strings.Contains(list, "Write report 11:00–12:00") {
+		t.Errorf("Expected the list to show the scheduled time, got:\n%s", list)
+	}
+
+	// Unscheduling, and undo
+	m.openTimeline(day)
+	m.timeline.slot = 23
+	pressKey(m, "x")
+	if _, _, ok := findTask(m, "report").Schedule(); ok {
+		t.Error("Expected x to unschedule the task")
+	}
+	pressKey(m, "u")
+	if _, _, ok := findTask(m, "report").Schedule(); !ok {
+		t.Error("Expected undo to restore the schedule")
+	}
+}
+
+func TestTimeline_RecurringTaskKeepsTime(t *testing.T) {
+	today := startOfToday()
+	start := time.Date(today.Year(), today.Month(), today.Day(), 7, 0, 0, 0, time.Local)
+	m := newTestModel(t, storage.Task{
+		ID: "run", Text: "Run", Date: today, ScheduledAt: start, DurationMinutes: 45,
+		Recurrence: &storage.Recurrence{Freq: storage.FreqDaily},
+	})
+
+	m.toggleTaskById("run")
+	for _, task := range m.appData.Tasks {
+		if task.ID == "run" {
+			continue
+		}
+		from, end, ok := task.Schedule()
+		if !ok || from.Format("15:04") != "07:00" || end.Sub(from) != 45*time.Minute {
+			t.Errorf("Expected the next occurrence at 07:00 for 45m, got %v - %v (%v)", from, end, ok)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"sort"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+)
+
+// taskIndex returns the index of a task in the application data, or -1
+func (m *Model) taskIndex(taskID string) int {
+	for i := range m.appData.Tasks {
+		if m.appData.Tasks[i].ID == taskID {
+			return i
+		}
+	}
+	return -1
+}
+
+// parentOf returns a copy of a task's parent, or nil for top-level tasks.
+// A parent on another day does not count, matching storage.FlattenTree.
+func (m *Model) parentOf(task storage.Task) *storage.Task {
+	if task.ParentID == "" || task.ParentID == task.ID {
+		return nil
+	}
+
+	index := m.taskIndex(task.ParentID)
+	if index == -1 {
+		return nil
+	}
+	parent := m.appData.Tasks[index]
+	if !storage.SameDay(parent.Date, task.Date) {
+		return nil
+	}
+	return &parent
+}
+
+// childrenOf returns the regular tasks of a day directly below parentID
+// ("" for top-level tasks), in display order
+func (m *Model) childrenOf(parentID string, date time.Time) []storage.Task {
+	day := storage.StartOfDay(date)
+
+	var dayTasks []storage.Task
+	onDay := make(map[string]bool)
+	for _, task := range m.appData.Tasks {
+		if storage.StartOfDay(task.Date).Equal(day) {
+			onDay[task.ID] = true
+			if !task.IsCalendar {
+				dayTasks = append(dayTasks, task)
+			}
+		}
+	}
+
+	var children []storage.Task
+	for _, task := range dayTasks {
+		effectiveParent := task.ParentID
+		if effectiveParent == task.ID || !onDay[effectiveParent] {
+			effectiveParent = ""
+		}
+		if effectiveParent == parentID {
+			children = append(children, task)
+		}
+	}
+
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].Priority > children[j].Priority
+	})
+	return children
+}
+
+// siblingsOf returns the tasks sharing a task's parent and day, including the
+// task itself, in display order
+func (m *Model) siblingsOf(task storage.Task) []storage.Task {
+	parentID := ""
+	if parent := m.parentOf(task); parent != nil {
+		parentID = parent.ID
+	}
+	return m.childrenOf(parentID, task.Date)
+}
+
+// setSiblingOrder renumbers sibling priorities so they appear in the given order
+func (m *Model) setSiblingOrder(taskIDs []string) {
+	for i, id := range taskIDs {
+		if index := m.taskIndex(id); index >= 0 {
+			m.appData.Tasks[index].Priority = len(taskIDs) - i
+		}
+	}
+}
+
+// subtreeIDs returns the ID of a task followed by the IDs of all its subtasks
+func (m *Model) subtreeIDs(taskID string) []string {
+	return storage.SubtreeIDs(m.appData.Tasks, taskID)
+}
+
+// indexOfTask returns the position of a task in a list, or -1
+func indexOfTask(tasks []storage.Task, taskID string) int {
+	for i, task := range tasks {
+		if task.ID == taskID {
+			return i
+		}
+	}
+	return -1
+}
+
+// startPriority returns a priority that orders a task before the given siblings
+func startPriority(siblings []storage.Task) int {
+	priority := 0
+	for _, task := range siblings {
+		if task.Priority >= priority {
+			priority = task.Priority + 1
+		}
+	}
+	return priority
+}
+
+// endPriority returns a priority that orders a task after the given siblings
+func endPriority(siblings []storage.Task) int {
+	priority := 0
+	for _, task := range siblings {
+		if task.Priority <= priority {
+			priority = task.Priority - 1
+		}
+	}
+	return priority
+}
+
+// hasChildren reports whether a task has subtasks on its day
+func (m *Model) hasChildren(task storage.Task) bool {
+	return len(m.childrenOf(task.ID, task.Date)) > 0
+}
+
+// toggleCollapsed collapses or expands the subtasks of a task. On a task
+// without subtasks it collapses the task's parent instead. It returns the ID of
+// the task that changed, or "" if nothing did.
+func (m *Model) toggleCollapsed(taskID string) string {
+	index := m.taskIndex(taskID)
+	if index == -1 {
+		return ""
+	}
+	task := m.appData.Tasks[index]
+
+	if !m.hasChildren(task) {
+		parent := m.parentOf(task)
+		if parent == nil {
+			return ""
+		}
+		index = m.taskIndex(parent.ID)
+		m.appData.Tasks[index].Collapsed = true
+		return parent.ID
+	}
+
+	m.appData.Tasks[index].Collapsed = !task.Collapsed
+	return taskID
+}
+
+// revealTask expands every collapsed ancestor of a task so that it shows up in
+// the list. It returns the IDs of the tasks that were expanded.
+func (m *Model) revealTask(taskID string) []string {
+	var expanded []string
+
+	index := m.taskIndex(taskID)
+	if index == -1 {
+		return nil
+	}
+	seen := map[string]bool{taskID: true}
+	for parent := m.parentOf(m.appData.Tasks[index]); parent != nil && !seen[parent.ID]; parent = m.parentOf(*parent) {
+		seen[parent.ID] = true
+		if parent.Collapsed {
+			m.appData.Tasks[m.taskIndex(parent.ID)].Collapsed = false
+			expanded = append(expanded, parent.ID)
+		}
+	}
+
+	return expanded
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+)
+
+// dayOrder returns the IDs and levels of a day's regular tasks in display order
+func dayOrder(m *Model, date time.Time) ([]string, []int) {
+	var ids []string
+	var levels []int
+	for _, task := range m.getTasksForDate(date) {
+		if task.IsCalendar {
+			continue
+		}
+		ids = append(ids, task.ID)
+		levels = append(levels, task.Level)
+	}
+	return ids, levels
+}
+
+func TestTaskTree_Operations(t *testing.T) {
+	today := startOfToday()
+	tomorrow := storage.AddDays(today, 1)
+
+	tests := []struct {
+		name           string
+		mutate         func(m *Model)
+		date           time.Time
+		expectedOrder  []string
+		expectedLevels []int
+	}{
+		{
+			name:           "unchanged",
+			mutate:         func(m *Model) {},
+			date:           today,
+			expectedOrder:  []string{"a", "a1", "a2", "b", "c"},
+			expectedLevels: []int{0, 1, 1, 0, 0},
+		},
+		{
+			name:           "indent under previous sibling",
+			mutate:         func(m *Model) { m.adjustTaskLevel("b", 1) },
+			date:           today,
+			expectedOrder:  []string{"a", "a1", "a2", "b", "c"},
+			expectedLevels: []int{0, 1, 1, 1, 0},
+		},
+		{
+			name:           "outdent after former parent",
+			mutate:         func(m *Model) { m.adjustTaskLevel("a1", -1) },
+			date:           today,
+			expectedOrder:  []string{"a", "a2", "a1", "b", "c"},
+			expectedLevels: []int{0, 1, 0, 0, 0},
+		},
+		{
+			name:           "move down takes subtasks along",
+			mutate:         func(m *Model) { m.moveTaskDown(today, "a") },
+			date:           today,
+			expectedOrder:  []string{"b", "a", "a1", "a2", "c"},
+			expectedLevels: []int{0, 0, 1, 1, 0},
+		},
+		{
+			name:           "subtask moves among its siblings",
+			mutate:         func(m *Model) { m.moveTaskDown(today, "a1") },
+			date:           today,
+			expectedOrder:  []string{"a", "a2", "a1", "b", "c"},
+			expectedLevels: []int{0, 1, 1, 0, 0},
+		},
+		{
+			name:           "last subtask stays under its parent",
+			mutate:         func(m *Model) { m.moveTaskDown(today, "a2") },
+			date:           today,
+			expectedOrder:  []string{"a", "a1", "a2", "b", "c"},
+			expectedLevels: []int{0, 1, 1, 0, 0},
+		},
+		{
+			name:           "subtree moves to another day",
+			mutate:         func(m *Model) { m.moveTaskToDay("a", today, tomorrow, 0) },
+			date:           tomorrow,
+			expectedOrder:  []string{"a", "a1", "a2", "t"},
+			expectedLevels: []int{0, 1, 1, 0},
+		},
+		{
+			name:           "delete removes subtasks",
+			mutate:         func(m *Model) { m.deleteTaskById("a") },
+			date:           today,
+			expectedOrder:  []string{"b", "c"},
+			expectedLevels: []int{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t,
+				storage.Task{ID: "c", Text: "C", Date: today, Priority: 1},
+				storage.Task{ID: "a2", Text: "A2", Date: today, ParentID: "a", Priority: 1},
+				storage.Task{ID: "a", Text: "A", Date: today, Priority: 3},
+				storage.Task{ID: "b", Text: "B", Date: today, Priority: 2},
+				storage.Task{ID: "a1", Text: "A1", Date: today, ParentID: "a", Priority: 2},
+				storage.Task{ID: "t", Text: "T", Date: tomorrow, Priority: 1},
+			)
+
+			tt.mutate(m)
+
+			order, levels := dayOrder(m, tt.date)
+			if !reflect.DeepEqual(order, tt.expectedOrder) {
+				t.Errorf("Expected order %v, got %v", tt.expectedOrder, order)
+			}
+			if !reflect.DeepEqual(levels, tt.expectedLevels) {
+				t.Errorf("Expected levels %v, got %v", tt.expectedLevels, levels)
+			}
+		})
+	}
+}
+
+func TestTaskTree_Collapse(t *testing.T) {
+	today := startOfToday()
+	m := newTestModel(t,
+		storage.Task{ID: "a", Text: "A", Date: today, Priority: 2},
+		storage.Task{ID: "a1", Text: "A1", Date: today, ParentID: "a", Priority: 2, Done: true},
+		storage.Task{ID: "a1x", Text: "A1x", Date: today, ParentID: "a1", Priority: 1},
+		storage.Task{ID: "a2", Text: "A2", Date: today, ParentID: "a", Priority: 1},
+		storage.Task{ID: "b", Text: "B", Date: today, Priority: 1},
+	)
+
+	visible := func() []string {
+		var ids []string
+		for _, item := range m.taskListItems(today) {
+			ids = append(ids, item.(ListItem).Task.ID)
+		}
+		return ids
+	}
+
+	// Collapsing from a subtask folds its parent
+	if changed := m.toggleCollapsed("a1x"); changed != "a1" {
+		t.Fatalf("Expected a1 to collapse, got %q", changed)
+	}
+	if ids := visible(); !reflect.DeepEqual(ids, []string{"a", "a1", "a2", "b"}) {
+		t.Errorf("Expected a1x to be hidden, got %v", ids)
+	}
+
+	if changed := m.toggleCollapsed("a"); changed != "a" {
+		t.Fatalf("Expected a to collapse, got %q", changed)
+	}
+	items := m.taskListItems(today)
+	if ids := visible(); !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Errorf("Expected only top-level tasks, got %v", ids)
+	}
+	if item := items[0].(ListItem); item.HiddenDone != 1 || item.HiddenTotal != 3 {
+		t.Errorf("Expected progress 1/3, got %d/%d", item.HiddenDone, item.HiddenTotal)
+	}
+
+	// Moving a collapsed task takes its hidden subtasks along
+	m.moveTaskDown(today, "a")
+	if ids := visible(); !reflect.DeepEqual(ids, []string{"b", "a"}) {
+		t.Errorf("Expected collapsed task to move below b, got %v", ids)
+	}
+	if order, _ := dayOrder(m, today); !reflect.DeepEqual(order, []string{"b", "a", "a1", "a1x", "a2"}) {
+		t.Errorf("Expected subtasks to follow their parent, got %v", order)
+	}
+
+	// Revealing a hidden task expands all of its ancestors
+	if expanded := m.revealTask("a1x"); !reflect.DeepEqual(expanded, []string{"a1", "a"}) {
+		t.Errorf("Expected a1 and a to expand, got %v", expanded)
+	}
+	if ids := visible(); len(ids) != 5 {
+		t.Errorf("Expected all tasks to be visible, got %v", ids)
+	}
+
+	// Indenting under a collapsed task expands it
+	findTask(m, "b").Collapsed = true
+	m.adjustTaskLevel("a", 1)
+	if findTask(m, "a").ParentID != "b" || findTask(m, "b").Collapsed {
+		t.Error("Expected b to expand when a task is indented under it")
+	}
+
+	// Collapsed state is persisted
+	findTask(m, "a").Collapsed = false
+	m.toggleCollapsed("a")
+	m.saveTask("a")
+	data, err := m.storage.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	for _, task := range data.Tasks {
+		if task.ID == "a" && !task.Collapsed {
+			t.Error("Expected collapsed state to be saved")
+		}
+	}
+}
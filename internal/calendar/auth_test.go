@@ -0,0 +1,107 @@
+package calendar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+	"personal-disorganizer/internal/testutil"
+)
+
+func TestManager_SourceOptions(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	t.Setenv("PD_TEST_TEAM_TOKEN", "token-1")
+
+	var authorization, client atomic.Value
+	var rejectToken atomic.Value
+	rejectToken.Store("")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization.Store(r.Header.Get("Authorization"))
+		client.Store(r.Header.Get("X-Client"))
+		if r.Header.Get("Authorization") == "Bearer "+rejectToken.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(singleEvent("DTSTART:20240115T100000Z", "SUMMARY:Sync")))
+	}))
+	defer server.Close()
+
+	disabled := false
+	team := server.URL + "/team.ics"
+	private := server.URL + "/private.ics"
+	manager := NewManagerWithSources([]storage.CalendarSource{
+		{URL: team, Name: "Team", Color: "#50fa7b", Headers: map[string]string{"X-Client": "pd"},
+			Auth: &storage.CalendarAuth{Type: "bearer", SecretEnv: "PD_TEST_TEAM_TOKEN"}},
+		{URL: private, Auth: &storage.CalendarAuth{Type: "basic", Username: "jane", Secret: "hunter2"}},
+		{URL: server.URL + "/old.ics", Enabled: &disabled},
+	})
+
+	if urls := manager.URLs(); len(urls) != 2 {
+		t.Fatalf("Expected the disabled calendar to be skipped, got %v", urls)
+	}
+
+	manager.Apply(manager.Fetch(team))
+	if got := authorization.Load(); got != "Bearer token-1" {
+		t.Errorf("Expected the bearer token, got %q", got)
+	}
+	if got := client.Load(); got != "pd" {
+		t.Errorf("Expected the configured header, got %q", got)
+	}
+	tasks := manager.EventsOn(date)
+	if len(tasks) != 1 || tasks[0].CalendarName != "Team" || tasks[0].CalendarColor != "#50fa7b" {
+		t.Errorf("Expected the event to carry its calendar's name and color, got %+v", tasks)
+	}
+
+	manager.Apply(manager.Fetch(private))
+	if got := authorization.Load(); got != "Basic amFuZTpodW50ZXIy" {
+		t.Errorf("Expected basic auth, got %q", got)
+	}
+
+	// The secret is resolved once, and again after the server rejects it
+	t.Setenv("PD_TEST_TEAM_TOKEN", "token-2")
+	rejectToken.Store("token-1")
+	if update := manager.Fetch(team); update.Err == nil {
+		t.Error("Expected the old token to be rejected")
+	}
+	manager.Apply(manager.Fetch(team))
+	if got := authorization.Load(); got != "Bearer token-2" {
+		t.Errorf("Expected the new token after a rejection, got %q", got)
+	}
+
+	statuses := manager.Statuses()
+	if statuses[0].Name != "Team" || statuses[1].Name != "private" {
+		t.Errorf("Expected configured or derived names, got %q and %q", statuses[0].Name, statuses[1].Name)
+	}
+}
+
+func TestManager_UnsupportedAuth(t *testing.T) {
+	manager := NewManagerWithSources([]storage.CalendarSource{
+		{URL: "https://example.invalid/cal.ics", Auth: &storage.CalendarAuth{Type: "digest", Secret: "x"}},
+	})
+	if update := manager.Fetch("https://example.invalid/cal.ics"); update.Err == nil {
+		t.Error("Expected an unsupported auth type to fail")
+	}
+}
+
+func TestFeedName(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://example.com/calendars/work.ics", "work"},
+		{"webcal://example.com/holidays", "holidays"},
+		{"https://calendar.example.com/", "calendar.example.com"},
+		{"https://calendar.example.com", "calendar.example.com"},
+		{"/home/user/calendars/personal/", "personal"},
+	}
+
+	for _, tt := range tests {
+		if got := feedName(tt.url); got != tt.expected {
+			t.Errorf("feedName(%q) = %q, expected %q", tt.url, got, tt.expected)
+		}
+	}
+}
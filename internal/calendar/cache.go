@@ -0,0 +1,141 @@
+package calendar
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+)
+
+// cacheEntry describes the last successful download of a feed, stored next
+// to its body so unchanged feeds can be revalidated instead of downloaded
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// SetCacheDir enables the on-disk feed cache in dir. Feeds are kept there
+// after every successful download.
+func (m *Manager) SetCacheDir(dir string) {
+	m.cacheDir = dir
+}
+
+// LoadCache fills the feeds from the on-disk cache so their last known events
+// are available before the first fetch completes, or when it fails
+func (m *Manager) LoadCache() {
+	if m.cacheDir == "" {
+		return
+	}
+
+	for _, url := range m.URLs() {
+		// Local sources are read directly, and CalDAV servers are queried
+		if _, ok := localPath(url); ok || m.sources[url].IsCalDAV() {
+			continue
+		}
+		entry, body, err := m.readCache(url)
+		if err != nil {
+			continue
+		}
+		series, err := m.parseFeed(bytes.NewReader(body))
+		if err != nil {
+			if m.logger != nil {
+				m.logger.LogError(fmt.Errorf("calendar cache unreadable for %s: %w", url, err))
+			}
+			continue
+		}
+
+		m.mu.Lock()
+		f := m.feed(url)
+		f.series = series
+		f.status.LastSync = entry.FetchedAt
+		m.mu.Unlock()
+	}
+}
+
+// cachePaths returns the body and metadata files of a feed in the cache
+func (m *Manager) cachePaths(url string) (body, meta string) {
+	sum := sha256.Sum256([]byte(url))
+	base := filepath.Join(m.cacheDir, hex.EncodeToString(sum[:8]))
+	return base + ".ics", base + ".json"
+}
+
+// readCacheEntry returns the metadata of a feed's cached download
+func (m *Manager) readCacheEntry(url string) (cacheEntry, error) {
+	var entry cacheEntry
+	if m.cacheDir == "" {
+		return entry, fmt.Errorf("no cache directory")
+	}
+
+	_, metaPath := m.cachePaths(url)
+	meta, err := os.ReadFile(metaPath)
+	if err != nil {
+		return entry, err
+	}
+	if err := json.Unmarshal(meta, &entry); err != nil {
+		return entry, fmt.Errorf("invalid cache entry: %w", err)
+	}
+	// Guards against hash collisions and hand-copied cache files
+	if entry.URL != url {
+		return entry, fmt.Errorf("cache entry belongs to %s", entry.URL)
+	}
+	return entry, nil
+}
+
+// readCache returns the cached download of a feed
+func (m *Manager) readCache(url string) (cacheEntry, []byte, error) {
+	entry, err := m.readCacheEntry(url)
+	if err != nil {
+		return entry, nil, err
+	}
+	bodyPath, _ := m.cachePaths(url)
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		return entry, nil, err
+	}
+	return entry, body, nil
+}
+
+// writeCache stores a successful download of a feed. The body is written
+// before the metadata so a crash never leaves metadata for a missing body.
+func (m *Manager) writeCache(entry cacheEntry, body []byte) error {
+	if m.cacheDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(m.cacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create calendar cache: %w", err)
+	}
+
+	bodyPath, _ := m.cachePaths(entry.URL)
+	if err := storage.WriteFileAtomic(bodyPath, body, 0644); err != nil {
+		return err
+	}
+	return m.writeCacheEntry(entry)
+}
+
+// writeCacheEntry stores the metadata of a feed's cached download
+func (m *Manager) writeCacheEntry(entry cacheEntry) error {
+	meta, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	_, metaPath := m.cachePaths(entry.URL)
+	return storage.WriteFileAtomic(metaPath, meta, 0644)
+}
+
+// touchCache records that a cached feed was confirmed unchanged
+func (m *Manager) touchCache(url string, fetchedAt time.Time) error {
+	entry, err := m.readCacheEntry(url)
+	if err != nil {
+		return err
+	}
+	entry.FetchedAt = fetchedAt
+	return m.writeCacheEntry(entry)
+}
@@ -0,0 +1,108 @@
+package calendar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/testutil"
+)
+
+func TestManager_CacheRevalidation(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	body := singleEvent("DTSTART:20240115T100000Z", "SUMMARY:Planning")
+	lastModified := "Mon, 15 Jan 2024 08:00:00 GMT"
+
+	var downloads, revalidations atomic.Int32
+	var conditionalHeaders atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditionalHeaders.Store(r.Header.Get("If-None-Match") + "|" + r.Header.Get("If-Modified-Since"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidations.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	url := server.URL + "/work.ics"
+	cacheDir := testutil.TempDir(t)
+
+	manager := NewManager([]string{url})
+	manager.SetCacheDir(cacheDir)
+
+	manager.Apply(manager.Fetch(url))
+	if headers := conditionalHeaders.Load(); headers != "|" {
+		t.Errorf("Expected no conditional headers without a cache, got %q", headers)
+	}
+	firstSync := manager.Statuses()[0].LastSync
+
+	// Unchanged feeds are revalidated instead of downloaded
+	manager.Apply(manager.Fetch(url))
+	if headers := conditionalHeaders.Load(); headers != `"v1"|`+lastModified {
+		t.Errorf("Expected ETag and Last-Modified to be sent, got %q", headers)
+	}
+	if downloads.Load() != 1 || revalidations.Load() != 1 {
+		t.Errorf("Expected 1 download and 1 revalidation, got %d and %d", downloads.Load(), revalidations.Load())
+	}
+	if events := manager.EventsOn(date); len(events) != 1 {
+		t.Errorf("Expected the events to be kept after a 304, got %d", len(events))
+	}
+	status := manager.Statuses()[0]
+	if status.Err != nil || status.LastSync.Before(firstSync) {
+		t.Errorf("Expected the revalidation to count as a sync, got %+v", status)
+	}
+
+	// A new session starts from the cache, while offline
+	server.Close()
+	offline := NewManager([]string{url})
+	offline.SetCacheDir(cacheDir)
+	offline.LoadCache()
+
+	if events := offline.EventsOn(date); len(events) != 1 || events[0].Text != "Planning" {
+		t.Fatalf("Expected the cached event, got %+v", events)
+	}
+	cachedSync := offline.Statuses()[0].LastSync
+	if !cachedSync.Equal(status.LastSync) {
+		t.Errorf("Expected the cached sync time %v, got %v", status.LastSync, cachedSync)
+	}
+
+	offline.Apply(offline.Fetch(url))
+	status = offline.Statuses()[0]
+	if !status.Stale() || !status.LastSync.Equal(cachedSync) {
+		t.Errorf("Expected the feed to be stale since the cached sync, got %+v", status)
+	}
+	if events := offline.EventsOn(date); len(events) != 1 {
+		t.Errorf("Expected cached events while offline, got %d", len(events))
+	}
+}
+
+func TestManager_CacheIgnoresOtherFeeds(t *testing.T) {
+	cacheDir := testutil.TempDir(t)
+	manager := NewManager([]string{"https://example.com/a.ics"})
+	manager.SetCacheDir(cacheDir)
+
+	if err := manager.writeCache(cacheEntry{URL: "https://example.com/a.ics", FetchedAt: time.Now()}, []byte(singleEvent("DTSTART:20240115T100000Z"))); err != nil {
+		t.Fatalf("writeCache() error = %v", err)
+	}
+
+	// Metadata naming another feed is not trusted
+	_, metaPath := manager.cachePaths("https://example.com/a.ics")
+	if err := os.WriteFile(metaPath, []byte(`{"url": "https://example.com/b.ics"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := manager.readCache("https://example.com/a.ics"); err == nil {
+		t.Error("Expected a cache entry for another feed to be rejected")
+	}
+	manager.LoadCache()
+	if status := manager.Statuses()[0]; !status.LastSync.IsZero() {
+		t.Errorf("Expected nothing to be loaded, got %+v", status)
+	}
+}
@@ -0,0 +1,357 @@
+package calendar
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+// errPreconditionFailed is returned when a CalDAV object changed on the
+// server since its ETag was read
+var errPreconditionFailed = errors.New("changed on the server")
+
+// davCollection is a calendar collection on a CalDAV server
+type davCollection struct {
+	URL        string
+	Name       string
+	Components []string // Supported component types; empty if the server does not say
+}
+
+// supports reports whether the collection can hold a component type
+func (c davCollection) supports(component string) bool {
+	if len(c.Components) == 0 {
+		return true
+	}
+	for _, supported := range c.Components {
+		if strings.EqualFold(supported, component) {
+			return true
+		}
+	}
+	return false
+}
+
+// davObject is a calendar object resource returned by a REPORT
+type davObject struct {
+	URL  string
+	ETag string
+	Data string
+}
+
+// davMultistatus is the body of a 207 Multi-Status response (RFC 4918)
+type davMultistatus struct {
+	Responses []davResponse `xml:"DAV: response"`
+}
+
+type davResponse struct {
+	Href     string        `xml:"DAV: href"`
+	Propstat []davPropstat `xml:"DAV: propstat"`
+}
+
+type davPropstat struct {
+	Prop   davProp `xml:"DAV: prop"`
+	Status string  `xml:"DAV: status"`
+}
+
+type davHref struct {
+	Href string `xml:"DAV: href"`
+}
+
+type davProp struct {
+	ResourceType struct {
+		Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+	} `xml:"DAV: resourcetype"`
+	DisplayName          string  `xml:"DAV: displayname"`
+	CurrentUserPrincipal davHref `xml:"DAV: current-user-principal"`
+	CalendarHomeSet      davHref `xml:"urn:ietf:params:xml:ns:caldav calendar-home-set"`
+	ComponentSet         struct {
+		Components []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"urn:ietf:params:xml:ns:caldav comp"`
+	} `xml:"urn:ietf:params:xml:ns:caldav supported-calendar-component-set"`
+	ETag         string `xml:"DAV: getetag"`
+	CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+}
+
+// prop returns the properties the server found, leaving out those listed
+// with an error status such as 404
+func (r davResponse) prop() davProp {
+	for _, propstat := range r.Propstat {
+		if propstat.Status == "" || strings.Contains(propstat.Status, " 200 ") {
+			return propstat.Prop
+		}
+	}
+	return davProp{}
+}
+
+// discoveryRequest asks for what is needed to find a user's calendars
+const discoveryRequest = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <d:resourcetype/>
+    <d:displayname/>
+    <d:current-user-principal/>
+    <c:calendar-home-set/>
+    <c:supported-calendar-component-set/>
+  </d:prop>
+</d:propfind>`
+
+// calendarQueryRequest fetches all objects of one component type
+const calendarQueryRequest = `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <d:getetag/>
+    <c:calendar-data/>
+  </d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="%s"/>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+
+// davRequest sends a request to a CalDAV server with the source's headers
+// and credentials
+func (m *Manager) davRequest(source, method, target string, headers map[string]string, body string) (*http.Response, error) {
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CalDAV URL: %w", err)
+	}
+	switch method {
+	case "PROPFIND", "REPORT":
+		req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	case http.MethodPut:
+		req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if err := m.authorize(req, source); err != nil {
+		return nil, fmt.Errorf("calendar auth failed: %w", err)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("CalDAV request failed: %w", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		// The secret may have changed, so it is looked up again next time
+		m.forgetSecret(source)
+	}
+	return resp, nil
+}
+
+// davQuery sends a PROPFIND or REPORT and returns the responses of its
+// multi-status body
+func (m *Manager) davQuery(source, method, target, depth, body string) ([]davResponse, error) {
+	resp, err := m.davRequest(source, method, target, map[string]string{"Depth": depth}, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("CalDAV %s %s failed: %d", method, target, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CalDAV response: %w", err)
+	}
+	if len(data) > maxFeedSize {
+		return nil, fmt.Errorf("CalDAV response larger than %d bytes", maxFeedSize)
+	}
+
+	var status davMultistatus
+	if err := xml.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("invalid CalDAV response: %w", err)
+	}
+	return status.Responses, nil
+}
+
+// resolveHref turns an href from a response into an absolute URL
+func resolveHref(base, href string) string {
+	baseURL, err := neturl.Parse(base)
+	if err != nil {
+		return href
+	}
+	ref, err := neturl.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return href
+	}
+	return baseURL.ResolveReference(ref).String()
+}
+
+// sameResource compares two URLs of a server, ignoring a trailing slash
+func sameResource(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
+
+// discoverCalendars finds the calendar collections of a CalDAV source. The
+// URL may be a calendar itself, the user's calendar home, their principal or
+// the server root, from which the principal is looked up (RFC 4791, 6764).
+func (m *Manager) discoverCalendars(source string) ([]davCollection, error) {
+	target := source
+	for step := 0; step < 3; step++ {
+		responses, err := m.davQuery(source, "PROPFIND", target, "0", discoveryRequest)
+		if err != nil {
+			return nil, err
+		}
+		if len(responses) == 0 {
+			return nil, fmt.Errorf("CalDAV server returned nothing for %s", target)
+		}
+		prop := responses[0].prop()
+
+		switch {
+		case prop.ResourceType.Calendar != nil:
+			return []davCollection{newDavCollection(target, prop)}, nil
+		case prop.CalendarHomeSet.Href != "":
+			return m.listCalendars(source, resolveHref(target, prop.CalendarHomeSet.Href))
+		case prop.CurrentUserPrincipal.Href != "" && !sameResource(resolveHref(target, prop.CurrentUserPrincipal.Href), target):
+			target = resolveHref(target, prop.CurrentUserPrincipal.Href)
+		default:
+			// Possibly the calendar home itself
+			return m.listCalendars(source, target)
+		}
+	}
+	return nil, fmt.Errorf("no CalDAV calendars found at %s", source)
+}
+
+// listCalendars returns the calendar collections directly inside a
+// collection, usually the user's calendar home
+func (m *Manager) listCalendars(source, home string) ([]davCollection, error) {
+	responses, err := m.davQuery(source, "PROPFIND", home, "1", discoveryRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	var collections []davCollection
+	for _, response := range responses {
+		prop := response.prop()
+		if prop.ResourceType.Calendar != nil {
+			collections = append(collections, newDavCollection(resolveHref(home, response.Href), prop))
+		}
+	}
+	if len(collections) == 0 {
+		return nil, fmt.Errorf("no CalDAV calendars found at %s", home)
+	}
+	return collections, nil
+}
+
+// newDavCollection describes a calendar collection from its properties
+func newDavCollection(target string, prop davProp) davCollection {
+	if !strings.HasSuffix(target, "/") {
+		target += "/"
+	}
+	collection := davCollection{URL: target, Name: strings.TrimSpace(prop.DisplayName)}
+	for _, component := range prop.ComponentSet.Components {
+		collection.Components = append(collection.Components, strings.ToUpper(component.Name))
+	}
+	return collection
+}
+
+// calendarQuery reads all objects of a component type, such as "VEVENT",
+// from a calendar collection
+func (m *Manager) calendarQuery(source, collection, component string) ([]davObject, error) {
+	responses, err := m.davQuery(source, "REPORT", collection, "1", fmt.Sprintf(calendarQueryRequest, component))
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []davObject
+	for _, response := range responses {
+		prop := response.prop()
+		if prop.CalendarData == "" {
+			continue
+		}
+		objects = append(objects, davObject{
+			URL:  resolveHref(collection, response.Href),
+			ETag: prop.ETag,
+			Data: prop.CalendarData,
+		})
+	}
+	return objects, nil
+}
+
+// putObject uploads a calendar object. With an ETag, the upload only
+// succeeds if the object is unchanged on the server; without one, only if
+// it does not exist yet. It returns the new ETag, which servers may omit.
+func (m *Manager) putObject(source, target, etag, data string) (string, error) {
+	headers := map[string]string{"If-None-Match": "*"}
+	if etag != "" {
+		headers = map[string]string{"If-Match": etag}
+	}
+	resp, err := m.davRequest(source, http.MethodPut, target, headers, data)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return resp.Header.Get("ETag"), nil
+	case http.StatusPreconditionFailed:
+		return "", errPreconditionFailed
+	default:
+		return "", fmt.Errorf("CalDAV PUT %s failed: %d", target, resp.StatusCode)
+	}
+}
+
+// deleteObject deletes a calendar object if it is unchanged on the server
+func (m *Manager) deleteObject(source, target, etag string) error {
+	var headers map[string]string
+	if etag != "" {
+		headers = map[string]string{"If-Match": etag}
+	}
+	resp, err := m.davRequest(source, http.MethodDelete, target, headers, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	case http.StatusPreconditionFailed:
+		return errPreconditionFailed
+	default:
+		return fmt.Errorf("CalDAV DELETE %s failed: %d", target, resp.StatusCode)
+	}
+}
+
+// fetchCalDAV reads the events of all calendars of a CalDAV source. Objects
+// of all calendars share one series store, like the files of a directory.
+func (m *Manager) fetchCalDAV(source string) FeedUpdate {
+	update := FeedUpdate{URL: source, Time: time.Now()}
+
+	collections, err := m.discoverCalendars(source)
+	if err != nil {
+		update.Err = err
+		return update
+	}
+
+	var calendars []*component
+	for _, collection := range collections {
+		if !collection.supports("VEVENT") {
+			continue
+		}
+		objects, err := m.calendarQuery(source, collection.URL, "VEVENT")
+		if err != nil {
+			update.Err = err
+			return update
+		}
+		for _, object := range objects {
+			parsed, err := parseComponents(strings.NewReader(object.Data))
+			if err != nil {
+				continue
+			}
+			calendars = append(calendars, parsed...)
+		}
+	}
+
+	update.series = m.buildSeries(calendars, newTimeZones(calendars))
+	return update
+}
@@ -0,0 +1,409 @@
+package calendar
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+	"personal-disorganizer/internal/testutil"
+)
+
+// davServer is a minimal in-process CalDAV server: a principal, a calendar
+// home with an event and a task calendar, and the PROPFIND, REPORT, PUT and
+// DELETE requests the manager sends
+type davServer struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	collections map[string]string // Path to supported component
+	objects     map[string]davTestObject
+	version     int
+	beforeWrite func() // Runs before PUT and DELETE, to change objects during a sync
+}
+
+type davTestObject struct {
+	data string
+	etag string
+}
+
+func newDavServer(t *testing.T) *davServer {
+	t.Helper()
+
+	s := &davServer{
+		collections: map[string]string{
+			"/calendars/jane/work/":  "VEVENT",
+			"/calendars/jane/tasks/": "VTODO",
+		},
+		objects: make(map[string]davTestObject),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// set stores an object as a client would, giving it a new ETag
+func (s *davServer) set(path, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setLocked(path, data)
+}
+
+func (s *davServer) setLocked(path, data string) string {
+	s.version++
+	etag := fmt.Sprintf(`"%d"`, s.version)
+	s.objects[path] = davTestObject{data: data, etag: etag}
+	return etag
+}
+
+// remove deletes an object as a client would
+func (s *davServer) remove(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, path)
+}
+
+// object returns the data of an object, or "" if it does not exist
+func (s *davServer) object(path string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.objects[path].data
+}
+
+// paths returns the sorted paths of the objects in a collection
+func (s *davServer) paths(collection string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var paths []string
+	for path := range s.objects {
+		if strings.HasPrefix(path, collection) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (s *davServer) handle(w http.ResponseWriter, r *http.Request) {
+	if user, password, ok := r.BasicAuth(); !ok || user != "jane" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+
+	if r.Method == http.MethodPut || r.Method == http.MethodDelete {
+		s.mu.Lock()
+		hook := s.beforeWrite
+		s.beforeWrite = nil
+		s.mu.Unlock()
+		if hook != nil {
+			hook()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case "PROPFIND":
+		s.propfind(w, r)
+	case "REPORT":
+		var b strings.Builder
+		for path, object := range s.objects {
+			if strings.HasPrefix(path, r.URL.Path) && strings.Contains(string(body), `name="`+s.collections[r.URL.Path]+`"`) {
+				fmt.Fprintf(&b, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:getetag>%s</d:getetag>`+
+					`<c:calendar-data>%s</c:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`,
+					path, html.EscapeString(object.etag), html.EscapeString(object.data))
+			}
+		}
+		writeMultistatus(w, b.String())
+	case http.MethodPut:
+		existing, exists := s.objects[r.URL.Path]
+		if match := r.Header.Get("If-Match"); match != "" && (!exists || match != existing.etag) ||
+			r.Header.Get("If-None-Match") == "*" && exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.Header().Set("ETag", s.setLocked(r.URL.Path, string(body)))
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		existing, exists := s.objects[r.URL.Path]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && match != existing.etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *davServer) propfind(w http.ResponseWriter, r *http.Request) {
+	collection := func(path string) string {
+		return fmt.Sprintf(`<d:response><d:href>%s</d:href><d:propstat><d:prop>`+
+			`<d:resourcetype><d:collection/><c:calendar/></d:resourcetype><d:displayname>%s</d:displayname>`+
+			`<c:supported-calendar-component-set><c:comp name="%s"/></c:supported-calendar-component-set>`+
+			`</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`,
+			path, strings.Trim(path[len("/calendars/jane/"):], "/"), s.collections[path])
+	}
+
+	switch path := r.URL.Path; {
+	case path == "/":
+		writeMultistatus(w, `<d:response><d:href>/</d:href><d:propstat><d:prop>`+
+			`<d:current-user-principal><d:href>/principals/jane/</d:href></d:current-user-principal>`+
+			`</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>`+
+			`<d:propstat><d:prop><c:calendar-home-set/></d:prop><d:status>HTTP/1.1 404 Not Found</d:status></d:propstat></d:response>`)
+	case path == "/principals/jane/":
+		writeMultistatus(w, `<d:response><d:href>/principals/jane/</d:href><d:propstat><d:prop>`+
+			`<c:calendar-home-set><d:href>/calendars/jane/</d:href></c:calendar-home-set>`+
+			`</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`)
+	case path == "/calendars/jane/" && r.Header.Get("Depth") == "1":
+		writeMultistatus(w, `<d:response><d:href>/calendars/jane/</d:href><d:propstat><d:prop>`+
+			`<d:resourcetype><d:collection/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`+
+			collection("/calendars/jane/work/")+collection("/calendars/jane/tasks/"))
+	case s.collections[path] != "":
+		writeMultistatus(w, collection(path))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func writeMultistatus(w http.ResponseWriter, responses string) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>`+
+		`<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">%s</d:multistatus>`, responses)
+}
+
+// davManager returns a manager for a CalDAV source on the server
+func davManager(s *davServer, path string, syncTasks bool) (*Manager, string) {
+	url := s.URL + path
+	return NewManagerWithSources([]storage.CalendarSource{{
+		URL:       url,
+		Type:      "caldav",
+		SyncTasks: syncTasks,
+		Auth:      &storage.CalendarAuth{Type: "basic", Username: "jane", Secret: "secret"},
+	}}), url
+}
+
+func TestManager_CalDAVEvents(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	server := newDavServer(t)
+	server.set("/calendars/jane/work/standup.ics", "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:standup\r\n"+
+		"DTSTART:20240108T090000Z\r\nRRULE:FREQ=WEEKLY\r\nSUMMARY:Standup\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n")
+	server.set("/calendars/jane/work/standup-moved.ics", "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:standup\r\n"+
+		"RECURRENCE-ID:20240115T090000Z\r\nDTSTART:20240115T110000Z\r\nSUMMARY:Late standup\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n")
+	server.set("/calendars/jane/work/review.ics", singleEvent("DTSTART:20240115T150000Z", "SUMMARY:Review"))
+	server.set("/calendars/jane/tasks/todo.ics", "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:todo\r\nSUMMARY:Not an event\r\nEND:VTODO\r\nEND:VCALENDAR\r\n")
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	// The calendars are found from the server root, or read directly
+	for _, path := range []string{"/", "/calendars/jane/work/"} {
+		t.Run(path, func(t *testing.T) {
+			manager, url := davManager(server, path, false)
+			update := manager.Fetch(url)
+			if update.Err != nil {
+				t.Fatalf("Unexpected error: %v", update.Err)
+			}
+			manager.Apply(update)
+			if summaries := summariesOn(manager, date); !reflect.DeepEqual(summaries, []string{"Late standup", "Review"}) {
+				t.Errorf("Expected the moved standup and the review, got %v", summaries)
+			}
+		})
+	}
+
+	manager := NewManagerWithSources([]storage.CalendarSource{{URL: server.URL + "/", Type: "caldav"}})
+	if update := manager.Fetch(server.URL + "/"); update.Err == nil {
+		t.Error("Expected an error without credentials")
+	}
+}
+
+// todoSummaries returns the sorted summaries of the tasks on the server
+func todoSummaries(s *davServer) []string {
+	var summaries []string
+	for _, path := range s.paths("/calendars/jane/tasks/") {
+		if task, ok := parseTodo(s.object(path)); ok {
+			summaries = append(summaries, task.Text)
+		}
+	}
+	sort.Strings(summaries)
+	return summaries
+}
+
+// taskTexts returns the sorted texts of tasks, with a mark for done ones
+func taskTexts(tasks []storage.Task) []string {
+	var texts []string
+	for _, task := range tasks {
+		if task.Done {
+			texts = append(texts, task.Text+" ✓")
+		} else {
+			texts = append(texts, task.Text)
+		}
+	}
+	sort.Strings(texts)
+	return texts
+}
+
+func TestManager_SyncTasks(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	server := newDavServer(t)
+	cacheDir := testutil.TempDir(t)
+	manager, url := davManager(server, "/", true)
+	manager.SetCacheDir(cacheDir)
+	if sources := manager.TaskSources(); !reflect.DeepEqual(sources, []string{url}) {
+		t.Fatalf("Expected the CalDAV source to sync tasks, got %v", sources)
+	}
+
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	syncWith := func(tasks []storage.Task) TaskSyncResult {
+		t.Helper()
+		result := manager.SyncTasks(url, tasks)
+		if result.Err != nil {
+			t.Fatalf("Unexpected error: %v", result.Err)
+		}
+		return result
+	}
+
+	// First sync: local tasks are uploaded and tasks from the phone come in
+	server.set("/calendars/jane/tasks/phone.ics", "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:phone\r\n"+
+		"SUMMARY:Call the bank\r\nDUE;VALUE=DATE:20240116\r\nEND:VTODO\r\nEND:VCALENDAR\r\n")
+	tasks := []storage.Task{
+		{ID: "letter", Text: "Write letter", Date: day},
+		{ID: "stamps", Text: "Buy stamps", Date: day, ParentID: "letter"},
+	}
+	result := syncWith(tasks)
+	tasks = result.Tasks
+	if result.Changed != 1 || !reflect.DeepEqual(taskTexts(tasks), []string{"Buy stamps", "Call the bank", "Write letter"}) {
+		t.Fatalf("Expected the phone's task to be added, got %d changes and %v", result.Changed, taskTexts(tasks))
+	}
+	if summaries := todoSummaries(server); !reflect.DeepEqual(summaries, []string{"Buy stamps", "Call the bank", "Write letter"}) {
+		t.Fatalf("Expected the local tasks to be uploaded, got %v", summaries)
+	}
+	if uploaded, _ := parseTodo(server.object("/calendars/jane/tasks/stamps.ics")); uploaded.ParentID != "letter" || !uploaded.Date.Equal(day) {
+		t.Errorf("Expected the subtask's parent and date to be uploaded, got %+v", uploaded)
+	}
+
+	// Nothing changed
+	if result := syncWith(tasks); result.Changed != 0 || len(result.Conflicts) != 0 {
+		t.Errorf("Expected nothing to sync, got %+v", result)
+	}
+
+	// Done on the phone, renamed here, and synced by a new session
+	server.set("/calendars/jane/tasks/phone.ics", "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:phone\r\n"+
+		"SUMMARY:Call the bank\r\nDUE;VALUE=DATE:20240116\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\nEND:VCALENDAR\r\n")
+	tasks[0].Text = "Write long letter"
+	manager, _ = davManager(server, "/", true)
+	manager.SetCacheDir(cacheDir)
+	result = syncWith(tasks)
+	tasks = result.Tasks
+	if result.Changed != 1 || !reflect.DeepEqual(taskTexts(tasks), []string{"Buy stamps", "Call the bank ✓", "Write long letter"}) {
+		t.Errorf("Expected the completion to come in, got %d changes and %v", result.Changed, taskTexts(tasks))
+	}
+	if summaries := todoSummaries(server); !reflect.DeepEqual(summaries, []string{"Buy stamps", "Call the bank", "Write long letter"}) {
+		t.Errorf("Expected the rename to be uploaded, got %v", summaries)
+	}
+
+	// Deleted here, and deleted on the phone
+	server.remove("/calendars/jane/tasks/phone.ics")
+	var kept []storage.Task
+	for _, task := range tasks {
+		if task.ID != "letter" {
+			kept = append(kept, task)
+		}
+	}
+	result = syncWith(kept)
+	tasks = result.Tasks
+	if result.Changed != 1 || !reflect.DeepEqual(taskTexts(tasks), []string{"Buy stamps"}) {
+		t.Errorf("Expected only the stamps to be left, got %d changes and %v", result.Changed, taskTexts(tasks))
+	}
+	if summaries := todoSummaries(server); !reflect.DeepEqual(summaries, []string{"Buy stamps"}) {
+		t.Errorf("Expected the letter to be deleted on the server, got %v", summaries)
+	}
+
+	// Changed on both sides: the local version wins
+	server.set("/calendars/jane/tasks/stamps.ics", strings.Replace(server.object("/calendars/jane/tasks/stamps.ics"), "Buy stamps", "Buy 10 stamps", 1))
+	tasks[0].Text = "Buy 20 stamps"
+	result = syncWith(tasks)
+	if !reflect.DeepEqual(result.Conflicts, []string{"Buy 20 stamps"}) || !reflect.DeepEqual(taskTexts(result.Tasks), []string{"Buy 20 stamps"}) {
+		t.Errorf("Expected a conflict won by the local task, got %+v", result)
+	}
+	if summaries := todoSummaries(server); !reflect.DeepEqual(summaries, []string{"Buy 20 stamps"}) {
+		t.Errorf("Expected the local version on the server, got %v", summaries)
+	}
+}
+
+func TestManager_SyncTasks_ChangedDuringSync(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	server := newDavServer(t)
+	manager, url := davManager(server, "/calendars/jane/tasks/", true)
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	tasks := manager.SyncTasks(url, []storage.Task{{ID: "milk", Text: "Milk", Date: day}}).Tasks
+	path := "/calendars/jane/tasks/milk.ics"
+
+	// The phone changes the task between the query and the upload
+	server.mu.Lock()
+	server.beforeWrite = func() {
+		server.set(path, strings.Replace(server.object(path), "Milk", "Oat milk", 1))
+	}
+	server.mu.Unlock()
+	tasks[0].Text = "Whole milk"
+	result := manager.SyncTasks(url, tasks)
+	if result.Err != nil || !reflect.DeepEqual(result.Conflicts, []string{"Whole milk"}) {
+		t.Fatalf("Expected the upload to be refused as a conflict, got %+v", result)
+	}
+	if task, _ := parseTodo(server.object(path)); task.Text != "Oat milk" {
+		t.Errorf("Expected the phone's change to be kept, got %q", task.Text)
+	}
+
+	// The next sync sees both changes and keeps the local one
+	result = manager.SyncTasks(url, result.Tasks)
+	if task, _ := parseTodo(server.object(path)); task.Text != "Whole milk" || len(result.Conflicts) != 1 {
+		t.Errorf("Expected the local change to be uploaded as a conflict, got %q and %+v", task.Text, result)
+	}
+}
+
+func TestTaskTodo_RoundTrip(t *testing.T) {
+	testutil.SetLocalZone(t, "America/New_York")
+	task := storage.Task{
+		ID:        "5f0c9e2a-0000-4000-8000-000000000001",
+		Text:      "Pack; bring chargers, adapters\nand a very long list of other things that will need folding",
+		Done:      true,
+		Date:      storage.StartOfDay(time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local)),
+		ParentID:  "trip",
+		Priority:  3,
+		CreatedAt: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC),
+	}
+
+	data := taskTodo(task)
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Expected lines to be folded, got %q", line)
+		}
+	}
+
+	parsed, ok := parseTodo(data)
+	if !ok {
+		t.Fatalf("Expected the VTODO to parse:\n%s", data)
+	}
+	if taskFingerprint(parsed) != taskFingerprint(task) || !parsed.CreatedAt.Equal(task.CreatedAt) {
+		t.Errorf("Expected %+v, got %+v", task, parsed)
+	}
+
+	if _, ok := parseTodo("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:No UID\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"); ok {
+		t.Error("Expected a VTODO without UID to be rejected")
+	}
+}
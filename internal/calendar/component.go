@@ -0,0 +1,106 @@
+package calendar
+
+import (
+	"io"
+	"strings"
+)
+
+// component is an iCalendar component such as VCALENDAR, VEVENT or VTIMEZONE
+// with its properties and nested components
+type component struct {
+	Name       string
+	Properties []contentLine
+	Components []*component
+	Complete   bool // Whether the matching END line was seen
+}
+
+// Property returns the first property with the given name, or nil
+func (c *component) Property(name string) *contentLine {
+	for i := range c.Properties {
+		if c.Properties[i].Name == name {
+			return &c.Properties[i]
+		}
+	}
+	return nil
+}
+
+// PropertiesNamed returns all properties with the given name
+func (c *component) PropertiesNamed(name string) []contentLine {
+	var values []contentLine
+	for _, property := range c.Properties {
+		if property.Name == name {
+			values = append(values, property)
+		}
+	}
+	return values
+}
+
+// Walk calls fn for the component and all components nested below it
+func (c *component) Walk(fn func(*component)) {
+	fn(c)
+	for _, child := range c.Components {
+		child.Walk(fn)
+	}
+}
+
+// parseComponents reads iCalendar data into a tree of components. Malformed
+// lines are skipped. Components that are never closed are kept but marked
+// incomplete; a VEVENT that starts while another one is open closes the
+// unfinished one, as some broken feeds omit END:VEVENT.
+func parseComponents(reader io.Reader) ([]*component, error) {
+	var roots []*component
+	var stack []*component
+
+	lines := newLineReader(reader)
+	for {
+		line, ok := lines.Next()
+		if !ok {
+			break
+		}
+
+		content, err := parseContentLine(line)
+		if err != nil {
+			continue
+		}
+		name := strings.ToUpper(strings.TrimSpace(content.Value))
+
+		switch content.Name {
+		case "BEGIN":
+			if name == "VEVENT" {
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i].Name == "VEVENT" {
+						stack = stack[:i]
+						break
+					}
+				}
+			}
+
+			child := &component{Name: name}
+			if len(stack) == 0 {
+				roots = append(roots, child)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, child)
+			}
+			stack = append(stack, child)
+
+		case "END":
+			// Close the innermost matching component and anything left open inside it
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].Name == name {
+					stack[i].Complete = true
+					stack = stack[:i]
+					break
+				}
+			}
+
+		default:
+			if len(stack) > 0 {
+				current := stack[len(stack)-1]
+				current.Properties = append(current.Properties, content)
+			}
+		}
+	}
+
+	return roots, lines.Err()
+}
@@ -0,0 +1,222 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxLineLength bounds a single physical line; some feeds embed large
+// descriptions or inline attachments without folding them
+const maxLineLength = 1024 * 1024
+
+// contentLine is a single unfolded iCalendar content line (RFC 5545 section 3.1):
+//
+//	NAME;PARAM=value,"quoted value";OTHER=value:property value
+type contentLine struct {
+	Name   string              // Property or component name, upper-cased
+	Params map[string][]string // Parameter values by upper-cased parameter name
+	Value  string              // Raw property value, still escaped
+}
+
+// Param returns the first value of a parameter, or "" if it is not set
+func (l contentLine) Param(name string) string {
+	if values := l.Params[strings.ToUpper(name)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Text returns the value unescaped as an RFC 5545 TEXT value
+func (l contentLine) Text() string {
+	return unescapeText(l.Value)
+}
+
+// lineReader reads unfolded content lines from iCalendar data. A physical line
+// starting with a space or tab continues the previous line; the line break and
+// that single whitespace character are removed (RFC 5545 section 3.1).
+type lineReader struct {
+	scanner *bufio.Scanner
+	pending string
+	hasNext bool
+}
+
+// newLineReader creates a lineReader for the given iCalendar data
+func newLineReader(reader io.Reader) *lineReader {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+	return &lineReader{scanner: scanner}
+}
+
+// Next returns the next unfolded line. It returns false at the end of the data
+// or on a read error, which is reported by Err.
+func (r *lineReader) Next() (string, bool) {
+	if !r.hasNext {
+		if !r.scanner.Scan() {
+			return "", false
+		}
+		r.pending = r.scanner.Text()
+	}
+
+	var b strings.Builder
+	b.WriteString(r.pending)
+	r.hasNext = false
+
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line != "" && (line[0] == ' ' || line[0] == '\t') {
+			b.WriteString(line[1:])
+			continue
+		}
+		r.pending = line
+		r.hasNext = true
+		break
+	}
+
+	return b.String(), true
+}
+
+// Err returns the first read error, if any
+func (r *lineReader) Err() error {
+	return r.scanner.Err()
+}
+
+// parseContentLine splits an unfolded line into name, parameters and value.
+// Colons and semicolons inside quoted parameter values do not end the
+// parameter list.
+func parseContentLine(line string) (contentLine, error) {
+	result := contentLine{Params: make(map[string][]string)}
+
+	// Name runs up to the first parameter or the value
+	end := strings.IndexAny(line, ";:")
+	if end == -1 {
+		return result, fmt.Errorf("missing ':' in content line %q", line)
+	}
+	name := line[:end]
+	if !isValidName(name) {
+		return result, fmt.Errorf("invalid property name %q", name)
+	}
+	result.Name = strings.ToUpper(name)
+
+	rest := line[end:]
+	for rest[0] == ';' {
+		rest = rest[1:]
+
+		eq := strings.IndexByte(rest, '=')
+		if eq == -1 {
+			return result, fmt.Errorf("missing '=' in parameter of %s", result.Name)
+		}
+		paramName := rest[:eq]
+		if !isValidName(paramName) {
+			return result, fmt.Errorf("invalid parameter name %q in %s", paramName, result.Name)
+		}
+		paramName = strings.ToUpper(paramName)
+		rest = rest[eq+1:]
+
+		// Comma-separated values, each optionally quoted
+		for {
+			var value string
+			if strings.HasPrefix(rest, `"`) {
+				closing := strings.IndexByte(rest[1:], '"')
+				if closing == -1 {
+					return result, fmt.Errorf("unterminated quoted value for %s in %s", paramName, result.Name)
+				}
+				value = rest[1 : closing+1]
+				rest = rest[closing+2:]
+			} else {
+				stop := strings.IndexAny(rest, ",;:")
+				if stop == -1 {
+					return result, fmt.Errorf("missing ':' in content line %q", line)
+				}
+				value = rest[:stop]
+				rest = rest[stop:]
+			}
+			result.Params[paramName] = append(result.Params[paramName], decodeParamValue(value))
+
+			if rest == "" {
+				return result, fmt.Errorf("missing ':' in content line %q", line)
+			}
+			if rest[0] != ',' {
+				break
+			}
+			rest = rest[1:]
+		}
+
+		if rest[0] != ';' && rest[0] != ':' {
+			return result, fmt.Errorf("unexpected %q after parameter %s in %s", rest[0], paramName, result.Name)
+		}
+	}
+
+	result.Value = rest[1:]
+	return result, nil
+}
+
+// isValidName reports whether s is an iana-token or x-name: letters, digits and dashes
+func isValidName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeParamValue applies the caret encoding of RFC 6868 used by some
+// clients to put newlines and double quotes into parameter values
+func decodeParamValue(value string) string {
+	if !strings.Contains(value, "^") {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '^' && i+1 < len(value) {
+			switch value[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case '\'':
+				b.WriteByte('"')
+				i++
+				continue
+			case '^':
+				b.WriteByte('^')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
+
+// unescapeText resolves the backslash escapes of RFC 5545 TEXT values:
+// \\, \;, \, and \n or \N for a line break
+func unescapeText(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			switch value[i+1] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+				i++
+				continue
+			case '\\', ';', ',':
+				b.WriteByte(value[i+1])
+				i++
+				continue
+			}
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,197 @@
+package calendar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/testutil"
+)
+
+func TestLineReader_Unfolding(t *testing.T) {
+	data := "BEGIN:VEVENT\r\n" +
+		"DESCRIPTION:This is a lo\r\n" +
+		" ng description\r\n" +
+		"\t that spans lines\r\n" +
+		"SUMMARY:Short\r\n" +
+		"END:VEVENT"
+
+	var lines []string
+	reader := newLineReader(strings.NewReader(data))
+	for {
+		line, ok := reader.Next()
+		if !ok {
+			break
+		}
+		lines = append(lines, line)
+	}
+	if err := reader.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"BEGIN:VEVENT",
+		"DESCRIPTION:This is a long description that spans lines",
+		"SUMMARY:Short",
+		"END:VEVENT",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected %q, got %q", expected, lines)
+	}
+}
+
+func TestParseContentLine(t *testing.T) {
+	tests := []struct {
+		name           string
+		line           string
+		expectedName   string
+		expectedParams map[string][]string
+		expectedValue  string
+		expectError    bool
+	}{
+		{
+			name:           "plain property",
+			line:           "SUMMARY:Team Meeting",
+			expectedName:   "SUMMARY",
+			expectedParams: map[string][]string{},
+			expectedValue:  "Team Meeting",
+		},
+		{
+			name:           "lower-case names",
+			line:           "dtstart;tzid=Europe/Berlin:20240115T100000",
+			expectedName:   "DTSTART",
+			expectedParams: map[string][]string{"TZID": {"Europe/Berlin"}},
+			expectedValue:  "20240115T100000",
+		},
+		{
+			name:           "colons in the value",
+			line:           "ORGANIZER:MAILTO:organizer@example.com",
+			expectedName:   "ORGANIZER",
+			expectedParams: map[string][]string{},
+			expectedValue:  "MAILTO:organizer@example.com",
+		},
+		{
+			name:         "quoted parameter values",
+			line:         `ATTENDEE;CN="Doe; Jane";DELEGATED-TO="mailto:a@example.com","mailto:b@example.com":mailto:jane@example.com`,
+			expectedName: "ATTENDEE",
+			expectedParams: map[string][]string{
+				"CN":           {"Doe; Jane"},
+				"DELEGATED-TO": {"mailto:a@example.com", "mailto:b@example.com"},
+			},
+			expectedValue: "mailto:jane@example.com",
+		},
+		{
+			name:           "quoted TZID as sent by Outlook",
+			line:           `DTSTART;TZID="W. Europe Standard Time":20240115T100000`,
+			expectedName:   "DTSTART",
+			expectedParams: map[string][]string{"TZID": {"W. Europe Standard Time"}},
+			expectedValue:  "20240115T100000",
+		},
+		{
+			name:           "caret encoded parameter value",
+			line:           `LOCATION;X-ADDRESS=Main St^nRoom ^'A^':Office`,
+			expectedName:   "LOCATION",
+			expectedParams: map[string][]string{"X-ADDRESS": {"Main St\nRoom \"A\""}},
+			expectedValue:  "Office",
+		},
+		{
+			name:           "empty value",
+			line:           "DESCRIPTION:",
+			expectedName:   "DESCRIPTION",
+			expectedParams: map[string][]string{},
+			expectedValue:  "",
+		},
+		{name: "missing colon", line: "INVALID LINE WITHOUT COLON", expectError: true},
+		{name: "invalid name", line: "// Missing END:VEVENT", expectError: true},
+		{name: "missing parameter value", line: "DTSTART;VALUE:20240115", expectError: true},
+		{name: "unterminated quote", line: `ATTENDEE;CN="Jane:mailto:jane@example.com`, expectError: true},
+		{name: "text after quoted value", line: `ATTENDEE;CN="Jane"x:mailto:jane@example.com`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, err := parseContentLine(tt.line)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", line)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if line.Name != tt.expectedName {
+				t.Errorf("Expected name %q, got %q", tt.expectedName, line.Name)
+			}
+			if !reflect.DeepEqual(line.Params, tt.expectedParams) {
+				t.Errorf("Expected params %q, got %q", tt.expectedParams, line.Params)
+			}
+			if line.Value != tt.expectedValue {
+				t.Errorf("Expected value %q, got %q", tt.expectedValue, line.Value)
+			}
+		})
+	}
+}
+
+func TestUnescapeText(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Plain text`, "Plain text"},
+		{`Lunch\, then review`, "Lunch, then review"},
+		{`Room 1\; Room 2`, "Room 1; Room 2"},
+		{`First line\nSecond line\NThird line`, "First line\nSecond line\nThird line"},
+		{`C:\\Temp`, `C:\Temp`},
+		{`\\n is not a newline`, `\n is not a newline`},
+		{`Trailing backslash\`, `Trailing backslash\`},
+	}
+
+	for _, tt := range tests {
+		if got := unescapeText(tt.input); got != tt.expected {
+			t.Errorf("unescapeText(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestManager_ParseICalData_ContentLines(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	manager := NewManager([]string{})
+	targetDate := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:folded@example.com\r\n" +
+		"DTSTART:20240115T100000Z\r\n" +
+		"SUMMARY:Planning\\, budget and a very long title that Outlook folds a\r\n" +
+		" t seventy-five octets\r\n" +
+		"DESCRIPTION:Agenda:\\n1. Budget\\n2. Hiring\r\n" +
+		"LOCATION;ALTREP=\"http://example.com/room;id=5\":Room 5\\; 2nd floor\r\n" +
+		"BEGIN:VALARM\r\n" +
+		"ACTION:DISPLAY\r\n" +
+		"DESCRIPTION:Reminder\r\n" +
+		"END:VALARM\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := manager.parseICalData(strings.NewReader(data), targetDate)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+
+	event := events[0]
+	if event.Summary != "Planning, budget and a very long title that Outlook folds at seventy-five octets" {
+		t.Errorf("Unexpected summary %q", event.Summary)
+	}
+	if event.Description != "Agenda:\n1. Budget\n2. Hiring" {
+		t.Errorf("Expected the alarm not to override the description, got %q", event.Description)
+	}
+	if event.Location != "Room 5; 2nd floor" {
+		t.Errorf("Unexpected location %q", event.Location)
+	}
+}
@@ -0,0 +1,122 @@
+package calendar
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"personal-disorganizer/internal/storage"
+)
+
+// linkPattern matches http(s) links in free text. Angle brackets and quotes
+// end a link, as in Outlook's "Join: <https://...>".
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+// meetingHosts are the video call services whose links count as meeting URLs
+// when found in a description
+var meetingHosts = []string{
+	"zoom.us",
+	"meet.google.com",
+	"teams.microsoft.com",
+	"teams.live.com",
+	"webex.com",
+	"whereby.com",
+	"meet.jit.si",
+	"gotomeeting.com",
+	"chime.aws",
+}
+
+// eventDetails returns the parts of an event shown in its detail view
+func eventDetails(event Event) *storage.EventDetails {
+	return &storage.EventDetails{
+		Location:    event.Location,
+		Description: event.Description,
+		Organizer:   event.Organizer,
+		Attendees:   event.Attendees,
+		MeetingURLs: meetingURLs(event.Location, event.Description),
+	}
+}
+
+// personLabel formats an ORGANIZER or ATTENDEE as "Name <email>", or
+// whichever of the two is known. Attendees that replied get their answer
+// appended, such as "(declined)".
+func personLabel(content contentLine) string {
+	name := strings.TrimSpace(content.Param("CN"))
+	email := strings.TrimSpace(content.Value)
+	if len(email) >= 7 && strings.EqualFold(email[:7], "mailto:") {
+		email = email[7:]
+	}
+
+	var label string
+	switch {
+	case name != "" && email != "" && !strings.EqualFold(name, email):
+		label = name + " <" + email + ">"
+	case name != "":
+		label = name
+	default:
+		label = email
+	}
+
+	switch strings.ToUpper(content.Param("PARTSTAT")) {
+	case "ACCEPTED":
+		label += " (accepted)"
+	case "DECLINED":
+		label += " (declined)"
+	case "TENTATIVE":
+		label += " (tentative)"
+	}
+	return label
+}
+
+// meetingURLs returns the video call links of an event, those in the location
+// first and without duplicates. Any link in the location counts, since rooms
+// are often given as just a link; in the description only links to known
+// meeting services do, leaving out agendas and shared documents.
+func meetingURLs(location, description string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(link string) {
+		if !seen[link] {
+			seen[link] = true
+			urls = append(urls, link)
+		}
+	}
+
+	for _, link := range findLinks(location) {
+		add(link)
+	}
+	for _, link := range findLinks(description) {
+		if isMeetingLink(link) {
+			add(link)
+		}
+	}
+	return urls
+}
+
+// findLinks returns the http(s) links in text, without punctuation that ends
+// the surrounding sentence
+func findLinks(text string) []string {
+	var links []string
+	for _, link := range linkPattern.FindAllString(text, -1) {
+		link = strings.TrimRight(link, ".,;:!?)]")
+		if _, err := url.Parse(link); err == nil {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// isMeetingLink reports whether a link points to a known video call service
+func isMeetingLink(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, meetingHost := range meetingHosts {
+		if host == meetingHost || strings.HasSuffix(host, "."+meetingHost) {
+			return true
+		}
+	}
+	return false
+}
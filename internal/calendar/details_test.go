@@ -0,0 +1,72 @@
+package calendar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/testutil"
+)
+
+func TestManager_ParseICalData_EventDetails(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	data := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:review\r\n" +
+		"DTSTART:20240115T100000Z\r\nDTEND:20240115T113000Z\r\nSUMMARY:Review\r\n" +
+		"LOCATION:Room 4\\, second floor\r\n" +
+		"DESCRIPTION:Agenda: https://docs.example.com/agenda\\n\\nJoin: <https://us02web.zo\r\n om.us/j/123?pwd=abc>\r\n" +
+		"ORGANIZER;CN=Jane Doe:mailto:jane@example.com\r\n" +
+		"ATTENDEE;CN=Bob;PARTSTAT=ACCEPTED:mailto:bob@example.com\r\n" +
+		"ATTENDEE;PARTSTAT=NEEDS-ACTION:MAILTO:carol@example.com\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	manager := NewManager(nil)
+	events, err := manager.parseICalData(strings.NewReader(data), time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Expected one event, got %d (%v)", len(events), err)
+	}
+
+	details := eventTask(events[0], events[0].StartTime).Details
+	if details.Location != "Room 4, second floor" {
+		t.Errorf("Expected the unescaped location, got %q", details.Location)
+	}
+	if details.Description != "Agenda: https://docs.example.com/agenda\n\nJoin: <https://us02web.zoom.us/j/123?pwd=abc>" {
+		t.Errorf("Expected the unescaped description, got %q", details.Description)
+	}
+	if details.Organizer != "Jane Doe <jane@example.com>" {
+		t.Errorf("Unexpected organizer %q", details.Organizer)
+	}
+	if expected := []string{"Bob <bob@example.com> (accepted)", "carol@example.com"}; !reflect.DeepEqual(details.Attendees, expected) {
+		t.Errorf("Expected attendees %v, got %v", expected, details.Attendees)
+	}
+	if expected := []string{"https://us02web.zoom.us/j/123?pwd=abc"}; !reflect.DeepEqual(details.MeetingURLs, expected) {
+		t.Errorf("Expected meeting URLs %v, got %v", expected, details.MeetingURLs)
+	}
+}
+
+func TestMeetingURLs(t *testing.T) {
+	tests := []struct {
+		name        string
+		location    string
+		description string
+		expected    []string
+	}{
+		{name: "nothing", location: "Room 4", description: "Bring snacks."},
+		{name: "meet in description", description: "Join at https://meet.google.com/abc-defg-hij.", expected: []string{"https://meet.google.com/abc-defg-hij"}},
+		{name: "teams", description: "Click here <https://teams.microsoft.com/l/meetup-join/19%3ameeting>",
+			expected: []string{"https://teams.microsoft.com/l/meetup-join/19%3ameeting"}},
+		{name: "other links in description", description: "Slides: https://example.com/slides (draft)"},
+		{name: "link as location", location: "https://rooms.example.com/daily", description: "https://acme.webex.com/meet/jane",
+			expected: []string{"https://rooms.example.com/daily", "https://acme.webex.com/meet/jane"}},
+		{name: "duplicates", location: "https://zoom.us/j/1", description: "https://zoom.us/j/1 or dial in", expected: []string{"https://zoom.us/j/1"}},
+		{name: "look-alike host", description: "https://notzoom.us/j/1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if urls := meetingURLs(tt.location, tt.description); !reflect.DeepEqual(urls, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, urls)
+			}
+		})
+	}
+}
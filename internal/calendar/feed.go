@@ -0,0 +1,302 @@
+package calendar
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"path"
+	"strings"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+)
+
+// fetchTimeout bounds a single feed request, including reading the body, so a
+// slow server cannot hold up the other feeds
+const fetchTimeout = 15 * time.Second
+
+// maxFeedSize bounds the size of a downloaded feed
+const maxFeedSize = 32 << 20
+
+// FeedStatus describes the sync state of one calendar feed
+type FeedStatus struct {
+	URL      string
+	Name     string    // Configured name, or one derived from the URL
+	Syncing  bool      // A fetch is in progress
+	LastSync time.Time // Time of the last successful fetch; zero if none yet
+	Err      error     // Error of the last fetch, nil if it succeeded
+}
+
+// Stale reports whether the last fetch failed and the feed shows the events
+// of an earlier one, which LastSync dates
+func (s FeedStatus) Stale() bool {
+	return s.Err != nil && !s.LastSync.IsZero()
+}
+
+// FeedUpdate is the outcome of fetching one feed. Fetching is safe to run in
+// the background; the update only takes effect once passed to Apply.
+type FeedUpdate struct {
+	URL         string
+	Time        time.Time
+	Err         error
+	series      []*eventSeries
+	notModified bool   // The server confirmed the cached copy is current
+	cacheErr    error  // Failure to update the on-disk cache
+	signature   string // State of a local source's files when read
+}
+
+// feed is the parsed content and sync state of one calendar feed
+type feed struct {
+	series    []*eventSeries
+	status    FeedStatus
+	signature string // See localSignature; empty for remote feeds
+}
+
+// URLs returns the configured feeds without duplicates, in config order
+func (m *Manager) URLs() []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, url := range m.urls {
+		if !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// MarkSyncing records that a fetch of the feed has started. It reports false
+// if one is already in progress.
+func (m *Manager) MarkSyncing(url string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f := m.feed(url)
+	if f.status.Syncing {
+		return false
+	}
+	f.status.Syncing = true
+	return true
+}
+
+// Fetch downloads and parses a feed, reads a local file or directory, or
+// queries the calendars of a CalDAV server. Each request blocks for up to
+// fetchTimeout, and the manager's events are not changed. Remote feeds in the
+// on-disk cache are revalidated with If-None-Match and If-Modified-Since, and
+// successful downloads replace the cached copy.
+func (m *Manager) Fetch(url string) FeedUpdate {
+	if m.sources[url].IsCalDAV() {
+		return m.fetchCalDAV(url)
+	}
+	if path, ok := localPath(url); ok {
+		return m.fetchLocal(url, path)
+	}
+
+	update := FeedUpdate{URL: url, Time: time.Now()}
+
+	// Handle webcal:// URLs
+	requestURL := url
+	if strings.HasPrefix(requestURL, "webcal://") {
+		requestURL = "https://" + requestURL[9:]
+	}
+
+	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		update.Err = fmt.Errorf("invalid calendar URL: %w", err)
+		return update
+	}
+
+	// Only revalidate when the cached events are loaded, as a 304 carries no body
+	if entry, err := m.readCacheEntry(url); err == nil && m.hasEvents(url) {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	if err := m.authorize(req, url); err != nil {
+		update.Err = fmt.Errorf("calendar auth failed: %w", err)
+		return update
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		update.Err = fmt.Errorf("failed to fetch calendar: %w", err)
+		return update
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		// The secret may have changed, so it is looked up again next time
+		m.forgetSecret(url)
+		update.Err = fmt.Errorf("calendar request failed: %d", resp.StatusCode)
+		return update
+	case http.StatusNotModified:
+		update.notModified = true
+		update.cacheErr = m.touchCache(url, update.Time)
+		return update
+	default:
+		update.Err = fmt.Errorf("calendar request failed: %d", resp.StatusCode)
+		return update
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		update.Err = fmt.Errorf("failed to read calendar: %w", err)
+		return update
+	}
+	if len(body) > maxFeedSize {
+		update.Err = fmt.Errorf("calendar larger than %d bytes", maxFeedSize)
+		return update
+	}
+	if update.series, err = m.parseFeed(bytes.NewReader(body)); err != nil {
+		update.Err = fmt.Errorf("failed to parse calendar: %w", err)
+		return update
+	}
+
+	update.cacheErr = m.writeCache(cacheEntry{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    update.Time,
+	}, body)
+	return update
+}
+
+// Apply stores the result of a fetch. Failed fetches keep the feed's previous
+// events, which are then stale, and are logged.
+func (m *Manager) Apply(update FeedUpdate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if update.cacheErr != nil && m.logger != nil {
+		m.logger.LogError(fmt.Errorf("calendar cache update failed for %s: %w", update.URL, update.cacheErr))
+	}
+
+	f := m.feed(update.URL)
+	f.status.Syncing = false
+	f.status.Err = update.Err
+	f.signature = update.signature
+	if update.Err != nil {
+		if m.logger != nil {
+			m.logger.LogError(fmt.Errorf("calendar fetch failed for %s: %w", update.URL, update.Err))
+		}
+		return
+	}
+	if !update.notModified {
+		f.series = update.series
+	}
+	f.status.LastSync = update.Time
+}
+
+// hasEvents reports whether events of a feed have been loaded
+func (m *Manager) hasEvents(url string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.feeds[url]
+	return ok && !f.status.LastSync.IsZero()
+}
+
+// Statuses returns the sync state of every configured feed, in config order
+func (m *Manager) Statuses() []FeedStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var statuses []FeedStatus
+	for _, url := range m.URLs() {
+		status := FeedStatus{URL: url}
+		if f, ok := m.feeds[url]; ok {
+			status = f.status
+		}
+		status.Name = m.sources[url].Name
+		if status.Name == "" {
+			status.Name = feedName(url)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// feed returns the state of a feed, creating it on first use. The caller must
+// hold m.mu for writing.
+func (m *Manager) feed(url string) *feed {
+	f, ok := m.feeds[url]
+	if !ok {
+		f = &feed{status: FeedStatus{URL: url}}
+		m.feeds[url] = f
+	}
+	return f
+}
+
+// authorize adds the configured headers and credentials of a feed to a request
+func (m *Manager) authorize(req *http.Request, url string) error {
+	source := m.sources[url]
+	for name, value := range source.Headers {
+		req.Header.Set(name, value)
+	}
+	if source.Auth == nil {
+		return nil
+	}
+
+	secret, err := m.secret(url, *source.Auth)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(source.Auth.Type) {
+	case "basic":
+		req.SetBasicAuth(source.Auth.Username, secret)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+secret)
+	default:
+		return fmt.Errorf("unsupported auth type %q", source.Auth.Type)
+	}
+	return nil
+}
+
+// secret returns the auth secret of a feed, resolving it only once so
+// password commands do not run on every refresh
+func (m *Manager) secret(url string, auth storage.CalendarAuth) (string, error) {
+	m.mu.RLock()
+	secret, ok := m.secrets[url]
+	m.mu.RUnlock()
+	if ok {
+		return secret, nil
+	}
+
+	secret, err := auth.ResolveSecret()
+	if err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	m.secrets[url] = secret
+	m.mu.Unlock()
+	return secret, nil
+}
+
+// forgetSecret drops the resolved secret of a feed
+func (m *Manager) forgetSecret(url string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.secrets, url)
+}
+
+// feedName derives a short name for a feed: the file name of its URL or path
+// without extension, or its host
+func feedName(source string) string {
+	u, err := neturl.Parse(source)
+	if err != nil {
+		return source
+	}
+	name := strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path))
+	if name == "" || name == "." || name == "/" {
+		return u.Host
+	}
+	return name
+}
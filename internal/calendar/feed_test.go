@@ -0,0 +1,178 @@
+package calendar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+	"personal-disorganizer/internal/testutil"
+)
+
+// feedServer serves a calendar that can be swapped or made to fail
+type feedServer struct {
+	*httptest.Server
+	body     atomic.Value
+	status   atomic.Int32
+	requests atomic.Int32
+}
+
+func newFeedServer(t *testing.T, body string) *feedServer {
+	t.Helper()
+
+	s := &feedServer{}
+	s.body.Store(body)
+	s.status.Store(http.StatusOK)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		w.WriteHeader(int(s.status.Load()))
+		w.Write([]byte(s.body.Load().(string)))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestManager_FetchAndApply(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	server := newFeedServer(t, singleEvent("DTSTART:20240115T100000Z", "SUMMARY:Planning"))
+
+	manager := NewManager([]string{server.URL + "/work.ics"})
+	logger := &testutil.MockLogger{}
+	manager.SetLogger(logger)
+
+	// Nothing is shown before the first fetch
+	if events := manager.EventsOn(date); len(events) != 0 {
+		t.Fatalf("Expected no events before fetching, got %d", len(events))
+	}
+
+	if !manager.MarkSyncing(server.URL + "/work.ics") {
+		t.Fatal("Expected the first fetch to start")
+	}
+	if manager.MarkSyncing(server.URL + "/work.ics") {
+		t.Error("Expected a second fetch of the same feed to be refused")
+	}
+	if status := manager.Statuses()[0]; !status.Syncing {
+		t.Error("Expected the feed to be syncing")
+	}
+
+	update := manager.Fetch(server.URL + "/work.ics")
+	if update.Err != nil {
+		t.Fatalf("Unexpected error: %v", update.Err)
+	}
+	// Fetching alone does not change the stored events
+	if events := manager.EventsOn(date); len(events) != 0 {
+		t.Errorf("Expected no events before applying, got %d", len(events))
+	}
+
+	manager.Apply(update)
+	events := manager.EventsOn(date)
+	if len(events) != 1 || events[0].Text != "Planning" {
+		t.Fatalf("Expected the planning event, got %+v", events)
+	}
+	status := manager.Statuses()[0]
+	if status.Syncing || status.Err != nil || status.LastSync.IsZero() {
+		t.Errorf("Expected a successful sync, got %+v", status)
+	}
+
+	// Reading events does not go back to the server
+	requests := server.requests.Load()
+	manager.EventsOn(date)
+	manager.EventsOn(date.AddDate(0, 0, 1))
+	if server.requests.Load() != requests {
+		t.Error("Expected EventsOn to use the stored events")
+	}
+
+	// A failing feed keeps its last events
+	server.status.Store(http.StatusInternalServerError)
+	manager.Apply(manager.Fetch(server.URL + "/work.ics"))
+	if events := manager.EventsOn(date); len(events) != 1 {
+		t.Errorf("Expected the last fetched events to be kept, got %d", len(events))
+	}
+	status = manager.Statuses()[0]
+	if status.Err == nil || status.LastSync.IsZero() {
+		t.Errorf("Expected an error with the last sync time kept, got %+v", status)
+	}
+	if logger.GetErrorCount() != 1 {
+		t.Errorf("Expected 1 logged error, got %d", logger.GetErrorCount())
+	}
+
+	// A later refresh replaces the events
+	server.status.Store(http.StatusOK)
+	server.body.Store(singleEvent("DTSTART:20240115T120000Z", "SUMMARY:Lunch"))
+	manager.Apply(manager.Fetch(server.URL + "/work.ics"))
+	if events := manager.EventsOn(date); len(events) != 1 || events[0].Text != "Lunch" {
+		t.Errorf("Expected the refreshed event, got %+v", events)
+	}
+}
+
+func TestManager_FetchTimeout(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	manager := NewManager([]string{slow.URL})
+	manager.client.Timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	update := manager.Fetch(slow.URL)
+	if update.Err == nil {
+		t.Error("Expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the fetch to give up quickly, took %v", elapsed)
+	}
+}
+
+func TestManager_URLs(t *testing.T) {
+	manager := NewManager([]string{"https://a.example.com/a.ics", "https://b.example.com/b.ics", "https://a.example.com/a.ics"})
+
+	urls := manager.URLs()
+	if strings.Join(urls, " ") != "https://a.example.com/a.ics https://b.example.com/b.ics" {
+		t.Errorf("Expected duplicate feeds to be dropped, got %v", urls)
+	}
+	if statuses := manager.Statuses(); len(statuses) != 2 || statuses[1].URL != urls[1] {
+		t.Errorf("Expected a status per feed, got %+v", statuses)
+	}
+}
+
+func TestManager_EventsBetween(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	body := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:standup\r\nDTSTART:20240101T090000Z\r\nRRULE:FREQ=WEEKLY\r\nSUMMARY:Standup\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:trip\r\nDTSTART;VALUE=DATE:20240112\r\nDTEND;VALUE=DATE:20240116\r\nSUMMARY:Trip\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	server := newFeedServer(t, body)
+	url := server.URL + "/work.ics"
+	manager := NewManagerWithSources([]storage.CalendarSource{{URL: url, Name: "Work"}})
+	manager.Apply(manager.Fetch(url))
+
+	from := time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 23, 0, 0, 0, 0, time.UTC)
+	var days []string
+	for _, task := range manager.EventsBetween(from, to) {
+		if task.CalendarName != "Work" {
+			t.Errorf("Expected the calendar name on %q, got %q", task.Text, task.CalendarName)
+		}
+		days = append(days, task.Date.Format("01-02")+" "+task.Text)
+	}
+	sort.Strings(days)
+
+	// The trip started before the range and the standup after it is left out
+	expected := []string{"01-13 Trip", "01-14 Trip", "01-15 Standup", "01-15 Trip", "01-22 Standup"}
+	if !reflect.DeepEqual(days, expected) {
+		t.Errorf("Expected %v, got %v", expected, days)
+	}
+
+	if events := manager.EventsOn(time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)); len(events) != 0 {
+		t.Errorf("Expected the trip to end before its DTEND, got %+v", events)
+	}
+}
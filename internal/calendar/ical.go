@@ -1,11 +1,13 @@
 package calendar
 
 import (
-	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"personal-disorganizer/internal/storage"
@@ -13,11 +15,32 @@ import (
 
 // Event represents a calendar event
 type Event struct {
-	Summary     string
-	Description string
-	StartTime   time.Time
-	EndTime     time.Time
-	Location    string
+	Summary      string
+	Description  string
+	StartTime    time.Time
+	EndTime      time.Time // Exclusive; all-day events end at midnight after their last day
+	Location     string
+	AllDay       bool      // DTSTART is a date rather than a time
+	UID          string    // Identifies the event, shared by all instances of a recurring event
+	RecurrenceID time.Time // Original start of an instance of a recurring event; zero otherwise
+	Organizer    string    // "Name <email>", or whichever of the two is known
+	Attendees    []string  // Like Organizer, with the reply if there is one: "Name <email> (accepted)"
+}
+
+// ID returns a task ID for the event that stays the same across refreshes.
+// It is derived from the UID and, for instances of recurring events, their
+// original start, so moved instances keep their ID. Events without a UID fall
+// back to their summary and start.
+func (e Event) ID() string {
+	key := e.UID
+	if key == "" {
+		key = e.Summary + "\x00" + e.StartTime.UTC().Format("20060102T150405Z")
+	}
+	if !e.RecurrenceID.IsZero() {
+		key += "\x00" + e.RecurrenceID.UTC().Format("20060102T150405Z")
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "cal_" + hex.EncodeToString(sum[:8])
 }
 
 // Logger interface for error logging
@@ -25,17 +48,55 @@ type Logger interface {
 	LogError(err error)
 }
 
-// Manager handles calendar integration
+// Manager handles calendar integration. Feeds are fetched with Fetch and
+// Apply, and their parsed events are kept in memory for EventsOn.
 type Manager struct {
-	urls   []string
-	logger Logger
+	urls    []string
+	sources map[string]storage.CalendarSource // Options of each enabled calendar by URL
+	logger  Logger
+	client  *http.Client
+	
+	// Directory of the on-disk feed cache; empty disables caching
+	cacheDir string
+	
+	mu      sync.RWMutex
+	feeds   map[string]*feed
+	secrets map[string]string // Resolved auth secrets by URL
+	
+	taskStates map[string]*taskSyncState // State of the last task sync by CalDAV source
 }
 
-// NewManager creates a new calendar manager
+// NewManager creates a new calendar manager for plain calendar URLs
 func NewManager(urls []string) *Manager {
-	return &Manager{
-		urls: urls,
+	sources := make([]storage.CalendarSource, len(urls))
+	for i, url := range urls {
+		sources[i] = storage.CalendarSource{URL: url}
+	}
+	return NewManagerWithSources(sources)
+}
+
+// NewManagerWithSources creates a new calendar manager for configured
+// calendars. Disabled calendars are left out.
+func NewManagerWithSources(sources []storage.CalendarSource) *Manager {
+	m := &Manager{
+		urls:    []string{},
+		sources: make(map[string]storage.CalendarSource),
+		client:  &http.Client{Timeout: fetchTimeout},
+		feeds:   make(map[string]*feed),
+		secrets: make(map[string]string),
+		
+		taskStates: make(map[string]*taskSyncState),
 	}
+	for _, source := range sources {
+		if !source.IsEnabled() {
+			continue
+		}
+		m.urls = append(m.urls, source.URL)
+		if _, exists := m.sources[source.URL]; !exists {
+			m.sources[source.URL] = source
+		}
+	}
+	return m
 }
 
 // SetLogger sets the logger instance for error logging
@@ -43,146 +104,267 @@ func (m *Manager) SetLogger(logger Logger) {
 	m.logger = logger
 }
 
-// FetchEvents fetches events from all configured calendars for a specific date
+// FetchEvents fetches all configured calendars and returns their events for
+// a specific date. It blocks until every feed has been fetched; interactive
+// callers should use Fetch in the background and EventsOn instead.
 func (m *Manager) FetchEvents(date time.Time) ([]storage.Task, error) {
-	var allTasks []storage.Task
+	for _, url := range m.URLs() {
+		// Errors are logged and the other calendars still load
+		m.Apply(m.Fetch(url))
+	}
+	return m.EventsOn(date), nil
+}
+
+// EventsOn returns the stored events of all feeds that occur on a date, as
+// calendar tasks. It does not fetch.
+func (m *Manager) EventsOn(date time.Time) []storage.Task {
+	return m.EventsBetween(date, storage.AddDays(date, 1))
+}
+
+// EventsBetween returns the stored events of all feeds that occur on the days
+// from from up to, but not including, to, as calendar tasks. Events spanning
+// several days yield a task for each day in the range, dated on that day. Each
+// series is expanded once for the whole range. It does not fetch.
+func (m *Manager) EventsBetween(from, to time.Time) []storage.Task {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	
-	for _, url := range m.urls {
-		events, err := m.fetchEventsFromURL(url, date)
-		if err != nil {
-			// Log error but continue with other calendars
-			if m.logger != nil {
-				m.logger.LogError(fmt.Errorf("calendar fetch failed for %s: %w", url, err))
-			}
+	first := storage.StartOfDay(from)
+	end := storage.StartOfDay(to)
+	
+	var allTasks []storage.Task
+	for _, url := range m.URLs() {
+		f, ok := m.feeds[url]
+		if !ok {
 			continue
 		}
 		
-		// Convert events to tasks
-		for _, event := range events {
-			task := storage.Task{
-				ID:         fmt.Sprintf("cal_%d", time.Now().UnixNano()),
-				Text:       event.Summary,
-				Done:       false,
-				Date:       date,
-				IsCalendar: true,
-				StartTime:  event.StartTime,
-				Priority:   -1, // Calendar events have highest priority
-				CreatedAt:  time.Now(),
-				Level:      0,
+		// Convert events to tasks, one for each day they cover
+		source := m.sources[url]
+		for _, s := range f.series {
+			for _, event := range s.occurrences(first, end) {
+				day := storage.StartOfDay(event.StartTime)
+				if day.Before(first) {
+					day = first
+				}
+				last := storage.LastDay(event.StartTime, event.EndTime)
+				for ; !day.After(last) && day.Before(end); day = storage.AddDays(day, 1) {
+					task := eventTask(event, day)
+					task.CalendarName = source.Name
+					task.CalendarColor = source.Color
+					allTasks = append(allTasks, task)
+				}
 			}
-			allTasks = append(allTasks, task)
 		}
 	}
 	
-	return allTasks, nil
+	return allTasks
 }
 
-// fetchEventsFromURL fetches events from a single iCal URL
-func (m *Manager) fetchEventsFromURL(url string, date time.Time) ([]Event, error) {
-	// Handle webcal:// URLs
-	if strings.HasPrefix(url, "webcal://") {
-		url = "https://" + url[9:]
+// eventTask converts an event into a calendar task on a date
+func eventTask(event Event, date time.Time) storage.Task {
+	return storage.Task{
+		ID:         event.ID(),
+		Text:       event.Summary,
+		Done:       false,
+		Date:       date,
+		IsCalendar: true,
+		StartTime:  event.StartTime,
+		EndTime:    event.EndTime,
+		AllDay:     event.AllDay,
+		Details:    eventDetails(event),
+		Priority:   -1, // Calendar events have highest priority
+		CreatedAt:  time.Now(),
+		Level:      0,
 	}
-	
-	// Fetch the iCal data
-	resp, err := http.Get(url)
+}
+
+// parseICalData parses iCal data and extracts events for the specified date.
+// Recurring events contribute each of their instances on that date, and events
+// spanning several days are included on each of them.
+func (m *Manager) parseICalData(reader io.Reader, targetDate time.Time) ([]Event, error) {
+	series, err := m.parseFeed(reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch calendar: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("calendar request failed: %d", resp.StatusCode)
+	return m.eventsOn(series, targetDate), nil
+}
+
+// parseFeed parses iCal data into event series
+func (m *Manager) parseFeed(reader io.Reader) ([]*eventSeries, error) {
+	calendars, err := parseComponents(reader)
+	if err != nil {
+		return nil, err
 	}
+	return m.buildSeries(calendars, newTimeZones(calendars)), nil
+}
+
+// eventsOn returns the instances of event series that occur on a date
+func (m *Manager) eventsOn(series []*eventSeries, targetDate time.Time) []Event {
+	var events []Event
 	
-	// Parse the iCal data
-	events, err := m.parseICalData(resp.Body, date)
-	if err != nil && m.logger != nil {
-		m.logger.LogError(fmt.Errorf("calendar parse failed for %s: %w", url, err))
+	from := storage.StartOfDay(targetDate)
+	to := storage.AddDays(targetDate, 1)
+	for _, s := range series {
+		for _, event := range s.occurrences(from, to) {
+			// Check if event occurs on target date
+			if m.eventOccursOnDate(event, targetDate) {
+				events = append(events, event)
+			}
+		}
 	}
-	return events, err
+	
+	return events
 }
 
-// parseICalData parses iCal data and extracts events for the specified date
-func (m *Manager) parseICalData(reader io.Reader, targetDate time.Time) ([]Event, error) {
-	var events []Event
-	var currentEvent *Event
+// buildSeries groups the VEVENTs of the calendars into series. VEVENTs with a
+// RECURRENCE-ID override an instance of the series with the same UID; without
+// such a series they stand on their own.
+func (m *Manager) buildSeries(calendars []*component, zones *timeZones) []*eventSeries {
+	build := func(c *component) Event {
+		return m.buildEvent(c, zones)
+	}
 	
-	scanner := bufio.NewScanner(reader)
+	var series []*eventSeries
+	byUID := make(map[string]*eventSeries)
+	var overrides []*component
 	
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		
-		if line == "BEGIN:VEVENT" {
-			currentEvent = &Event{}
-		} else if line == "END:VEVENT" {
-			if currentEvent != nil {
-				// Check if event occurs on target date
-				if m.eventOccursOnDate(*currentEvent, targetDate) {
-					events = append(events, *currentEvent)
+	for _, calendar := range calendars {
+		calendar.Walk(func(c *component) {
+			// Events without END:VEVENT are dropped
+			if c.Name != "VEVENT" || !c.Complete {
+				return
+			}
+			if c.Property("RECURRENCE-ID") != nil {
+				overrides = append(overrides, c)
+				return
+			}
+			
+			s := newEventSeries(c, zones, build)
+			series = append(series, s)
+			if uid := c.Property("UID"); uid != nil {
+				if _, exists := byUID[uid.Value]; !exists {
+					byUID[uid.Value] = s
 				}
 			}
-			currentEvent = nil
-		} else if currentEvent != nil {
-			m.parseEventLine(currentEvent, line)
+		})
+	}
+	
+	for _, override := range overrides {
+		if uid := override.Property("UID"); uid != nil {
+			if s, ok := byUID[uid.Value]; ok {
+				s.addOverride(override)
+				continue
+			}
 		}
+		series = append(series, newEventSeries(override, zones, build))
 	}
 	
-	return events, scanner.Err()
+	return series
+}
+
+// buildEvent reads the fields of a VEVENT. Without DTEND the end follows
+// from DURATION; all-day events without either last one day.
+func (m *Manager) buildEvent(c *component, zones *timeZones) Event {
+	event := Event{}
+	for _, property := range c.Properties {
+		m.applyEventProperty(&event, property, zones)
+	}
+	
+	if !event.EndTime.IsZero() || event.StartTime.IsZero() {
+		return event
+	}
+	if duration := c.Property("DURATION"); duration != nil {
+		if d, err := parseDuration(duration.Value); err == nil {
+			if event.AllDay {
+				// Whole days, so the event still ends at midnight across DST changes
+				event.EndTime = storage.AddDays(event.StartTime, int(d/(24*time.Hour)))
+			} else {
+				event.EndTime = event.StartTime.Add(d)
+			}
+		}
+	} else if event.AllDay {
+		event.EndTime = storage.AddDays(event.StartTime, 1)
+	}
+	return event
 }
 
-// parseEventLine parses a single line of event data
+// parseEventLine parses a single unfolded line of event data
 func (m *Manager) parseEventLine(event *Event, line string) {
-	parts := strings.SplitN(line, ":", 2)
-	if len(parts) != 2 {
+	content, err := parseContentLine(line)
+	if err != nil {
 		return
 	}
-	
-	key := strings.ToUpper(parts[0])
-	value := parts[1]
-	
-	switch {
-	case strings.HasPrefix(key, "SUMMARY"):
-		event.Summary = value
-	case strings.HasPrefix(key, "DESCRIPTION"):
-		event.Description = value
-	case strings.HasPrefix(key, "LOCATION"):
-		event.Location = value
-	case strings.HasPrefix(key, "DTSTART"):
-		if t, err := m.parseDateTime(value); err == nil {
+	m.applyEventProperty(event, content, newTimeZones(nil))
+}
+
+// applyEventProperty sets the event field a property describes, resolving
+// times through the calendar's zones
+func (m *Manager) applyEventProperty(event *Event, content contentLine, zones *timeZones) {
+	switch content.Name {
+	case "SUMMARY":
+		event.Summary = content.Text()
+	case "DESCRIPTION":
+		event.Description = content.Text()
+	case "LOCATION":
+		event.Location = content.Text()
+	case "ORGANIZER":
+		event.Organizer = personLabel(content)
+	case "ATTENDEE":
+		if label := personLabel(content); label != "" {
+			event.Attendees = append(event.Attendees, label)
+		}
+	case "DTSTART":
+		if t, err := parseDateTimeIn(content.Value, content.Param("TZID"), zones); err == nil {
 			event.StartTime = t
+			event.AllDay = isDateValue(content)
 		}
-	case strings.HasPrefix(key, "DTEND"):
-		if t, err := m.parseDateTime(value); err == nil {
+	case "UID":
+		event.UID = strings.TrimSpace(content.Value)
+	case "RECURRENCE-ID":
+		if t, err := parseDateTimeIn(content.Value, content.Param("TZID"), zones); err == nil {
+			event.RecurrenceID = t
+		}
+	case "DTEND":
+		if t, err := parseDateTimeIn(content.Value, content.Param("TZID"), zones); err == nil {
 			event.EndTime = t
 		}
 	}
 }
 
-// parseDateTime parses iCal datetime format
+// parseDateTime parses an iCal DATE or DATE-TIME value without TZID. Times
+// ending in Z are UTC; floating times and dates are local.
 func (m *Manager) parseDateTime(value string) (time.Time, error) {
-	// Remove timezone info for now - simplified parsing
-	value = strings.Split(value, ";")[0]
+	return parseDateTimeIn(value, "", newTimeZones(nil))
+}
+
+// parseDateTimeIn parses an iCal DATE or DATE-TIME value. UTC times ignore
+// tzid. Dates are whole local days regardless of tzid, so all-day events stay
+// on their day. Other times are wall-clock times in the zone named by tzid.
+func parseDateTimeIn(value, tzid string, zones *timeZones) (time.Time, error) {
+	value = strings.TrimSpace(value)
 	
-	// Try different datetime formats
-	formats := []string{
-		"20060102T150405Z",
-		"20060102T150405",
-		"20060102",
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
 	}
-	
-	for _, format := range formats {
-		if t, err := time.Parse(format, value); err == nil {
-			return t, nil
-		}
+	if t, err := time.Parse("20060102T150405", value); err == nil {
+		return zones.resolve(t, tzid), nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		return inLocation(t, time.Local), nil
 	}
 	
 	return time.Time{}, fmt.Errorf("unable to parse datetime: %s", value)
 }
 
-// eventOccursOnDate checks if an event occurs on the specified date
+// isDateValue reports whether a property holds a DATE rather than a DATE-TIME
+func isDateValue(content contentLine) bool {
+	return strings.EqualFold(content.Param("VALUE"), "DATE") || len(strings.TrimSpace(content.Value)) == len("20060102")
+}
+
+// eventOccursOnDate checks if an event covers any part of the specified local
+// date. Events ending at midnight do not reach into the following day.
 func (m *Manager) eventOccursOnDate(event Event, date time.Time) bool {
-	eventDate := event.StartTime.Truncate(24 * time.Hour)
-	targetDate := date.Truncate(24 * time.Hour)
-	return eventDate.Equal(targetDate)
-}
\ No newline at end of file
+	day := storage.StartOfDay(date)
+	return !day.Before(storage.StartOfDay(event.StartTime)) && !day.After(storage.LastDay(event.StartTime, event.EndTime))
+}
@@ -163,6 +163,8 @@ END:VCALENDAR`
 }
 
 func TestManager_ParseICalData(t *testing.T) {
+	// Dates in these cases are UTC days
+	testutil.SetLocalZone(t, "UTC")
 	manager := NewManager([]string{})
 	targetDate := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
 
@@ -307,7 +309,7 @@ func TestManager_ParseEventLine(t *testing.T) {
 			name: "parse with parameters",
 			line: "DTSTART;TZID=America/New_York:20240115T100000",
 			expected: Event{
-				StartTime: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+				StartTime: time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC),
 			},
 		},
 		{
@@ -368,19 +370,19 @@ func TestManager_ParseDateTime(t *testing.T) {
 		{
 			name:        "parse local datetime",
 			input:       "20240115T100000",
-			expected:    time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+			expected:    time.Date(2024, 1, 15, 10, 0, 0, 0, time.Local),
 			expectError: false,
 		},
 		{
 			name:        "parse date only",
 			input:       "20240115",
-			expected:    time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+			expected:    time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local),
 			expectError: false,
 		},
 		{
 			name:        "parse with timezone parameter",
 			input:       "20240115T100000", // Simplified for testing
-			expected:    time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+			expected:    time.Date(2024, 1, 15, 10, 0, 0, 0, time.Local),
 			expectError: false,
 		},
 		{
@@ -417,6 +419,8 @@ func TestManager_ParseDateTime(t *testing.T) {
 }
 
 func TestManager_EventOccursOnDate(t *testing.T) {
+	// Dates in these cases are UTC days
+	testutil.SetLocalZone(t, "UTC")
 	manager := NewManager([]string{})
 	targetDate := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
 	
@@ -467,6 +471,8 @@ func TestManager_EventOccursOnDate(t *testing.T) {
 }
 
 func TestManager_FetchEventsFromFile(t *testing.T) {
+	// Dates in these cases are UTC days
+	testutil.SetLocalZone(t, "UTC")
 	// Test parsing actual iCal files
 	manager := NewManager([]string{})
 	logger := &testutil.MockLogger{}
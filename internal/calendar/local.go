@@ -0,0 +1,145 @@
+package calendar
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// localPath returns the file system path of a local calendar source: a
+// file:// URL or a plain path, with ~ standing for the home directory. It
+// reports false for remote feeds.
+func localPath(source string) (string, bool) {
+	if strings.HasPrefix(source, "file://") {
+		if u, err := url.Parse(source); err == nil && u.Path != "" {
+			return u.Path, true
+		}
+		return strings.TrimPrefix(source, "file://"), true
+	}
+	if strings.Contains(source, "://") {
+		return "", false
+	}
+
+	if source == "~" || strings.HasPrefix(source, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, source[1:]), true
+		}
+	}
+	return source, true
+}
+
+// localFiles returns the calendar files of a local source: the file itself,
+// or the .ics files directly inside a directory, as vdirsyncer stores them
+func localFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.EqualFold(filepath.Ext(entry.Name()), ".ics") {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// localSignature summarises the names, sizes and modification times of a
+// local source's files, so changes can be noticed without reading them
+func localSignature(path string) string {
+	files, err := localFiles(path)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	var b strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
+
+// fetchLocal reads a local calendar file or directory. Files in a directory
+// that cannot be read, for example while a sync tool is rewriting them, are
+// skipped.
+func (m *Manager) fetchLocal(source, path string) FeedUpdate {
+	// Taken first, so changes made while reading trigger another read
+	update := FeedUpdate{URL: source, Time: time.Now(), signature: localSignature(path)}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		update.Err = fmt.Errorf("failed to read calendar: %w", err)
+		return update
+	}
+	files, err := localFiles(path)
+	if err != nil {
+		update.Err = fmt.Errorf("failed to read calendar: %w", err)
+		return update
+	}
+
+	var calendars []*component
+	for _, file := range files {
+		parsed, err := parseFile(file)
+		if err != nil {
+			if !info.IsDir() {
+				update.Err = fmt.Errorf("failed to parse calendar: %w", err)
+				return update
+			}
+			continue
+		}
+		calendars = append(calendars, parsed...)
+	}
+
+	// Files of a directory share one series store, so overrides kept in their
+	// own file still find their event
+	update.series = m.buildSeries(calendars, newTimeZones(calendars))
+	return update
+}
+
+// parseFile parses the components of a calendar file
+func parseFile(path string) ([]*component, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseComponents(file)
+}
+
+// ChangedLocalSources returns the local sources whose files changed since
+// they were last read and that are not being read already
+func (m *Manager) ChangedLocalSources() []string {
+	var changed []string
+	for _, source := range m.URLs() {
+		path, ok := localPath(source)
+		if !ok {
+			continue
+		}
+		signature := localSignature(path)
+
+		m.mu.RLock()
+		f, known := m.feeds[source]
+		if known && !f.status.Syncing && f.signature != signature {
+			changed = append(changed, source)
+		}
+		m.mu.RUnlock()
+	}
+	return changed
+}
@@ -0,0 +1,144 @@
+package calendar
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/testutil"
+)
+
+func TestLocalPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	tests := []struct {
+		source   string
+		expected string
+		local    bool
+	}{
+		{"https://example.com/work.ics", "", false},
+		{"webcal://example.com/work.ics", "", false},
+		{"file:///home/user/calendar.ics", "/home/user/calendar.ics", true},
+		{"file:///home/user/My%20Calendar.ics", "/home/user/My Calendar.ics", true},
+		{"/var/lib/calendars/work", "/var/lib/calendars/work", true},
+		{"calendars/work.ics", "calendars/work.ics", true},
+		{"~/calendars/work", filepath.Join(home, "calendars/work"), true},
+	}
+
+	for _, tt := range tests {
+		path, local := localPath(tt.source)
+		if path != tt.expected || local != tt.local {
+			t.Errorf("localPath(%q) = %q, %v, expected %q, %v", tt.source, path, local, tt.expected, tt.local)
+		}
+	}
+}
+
+// writeCalendarFile writes a calendar file with a modification time, so
+// changes are noticed regardless of the file system's time resolution
+func writeCalendarFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// summariesOn returns the sorted summaries of a manager's events on a date
+func summariesOn(manager *Manager, date time.Time) []string {
+	var summaries []string
+	for _, task := range manager.EventsOn(date) {
+		summaries = append(summaries, task.Text)
+	}
+	sort.Strings(summaries)
+	return summaries
+}
+
+func TestManager_LocalFile(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	modTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(testutil.TempDir(t), "export.ics")
+	writeCalendarFile(t, path, singleEvent("DTSTART:20240115T100000Z", "SUMMARY:Planning"), modTime)
+
+	manager := NewManager([]string{"file://" + path})
+	manager.Apply(manager.Fetch("file://" + path))
+	if summaries := summariesOn(manager, date); !reflect.DeepEqual(summaries, []string{"Planning"}) {
+		t.Fatalf("Expected the planning event, got %v", summaries)
+	}
+	if changed := manager.ChangedLocalSources(); len(changed) != 0 {
+		t.Errorf("Expected no changes right after reading, got %v", changed)
+	}
+
+	writeCalendarFile(t, path, singleEvent("DTSTART:20240115T100000Z", "SUMMARY:Planning (moved)"), modTime.Add(time.Minute))
+	changed := manager.ChangedLocalSources()
+	if !reflect.DeepEqual(changed, []string{"file://" + path}) {
+		t.Fatalf("Expected the file to be changed, got %v", changed)
+	}
+	manager.Apply(manager.Fetch(changed[0]))
+	if summaries := summariesOn(manager, date); !reflect.DeepEqual(summaries, []string{"Planning (moved)"}) {
+		t.Errorf("Expected the updated event, got %v", summaries)
+	}
+
+	// A removed file keeps the last events and is not reread until it changes again
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if changed := manager.ChangedLocalSources(); len(changed) != 1 {
+		t.Fatalf("Expected the removal to be noticed, got %v", changed)
+	}
+	manager.Apply(manager.Fetch("file://" + path))
+	if status := manager.Statuses()[0]; !status.Stale() {
+		t.Errorf("Expected the feed to be stale, got %+v", status)
+	}
+	if summaries := summariesOn(manager, date); len(summaries) != 1 {
+		t.Errorf("Expected the last events to be kept, got %v", summaries)
+	}
+	if changed := manager.ChangedLocalSources(); len(changed) != 0 {
+		t.Errorf("Expected a missing file not to be reread, got %v", changed)
+	}
+}
+
+func TestManager_LocalDirectory(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	modTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	dir := testutil.TempDir(t)
+
+	// One event per file, with a moved instance of a series in its own file
+	writeCalendarFile(t, filepath.Join(dir, "standup.ics"), "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:standup\r\n"+
+		"DTSTART:20240108T090000Z\r\nRRULE:FREQ=WEEKLY\r\nSUMMARY:Standup\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n", modTime)
+	writeCalendarFile(t, filepath.Join(dir, "standup-moved.ics"), "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:standup\r\n"+
+		"RECURRENCE-ID:20240115T090000Z\r\nDTSTART:20240115T110000Z\r\nSUMMARY:Late standup\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n", modTime)
+	writeCalendarFile(t, filepath.Join(dir, "review.ICS"), singleEvent("DTSTART:20240115T150000Z", "SUMMARY:Review"), modTime)
+	writeCalendarFile(t, filepath.Join(dir, "notes.txt"), singleEvent("DTSTART:20240115T150000Z", "SUMMARY:Not a calendar"), modTime)
+	writeCalendarFile(t, filepath.Join(dir, "broken.ics"), "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:No end", modTime)
+
+	manager := NewManager([]string{dir})
+	update := manager.Fetch(dir)
+	if update.Err != nil {
+		t.Fatalf("Unexpected error: %v", update.Err)
+	}
+	manager.Apply(update)
+
+	if summaries := summariesOn(manager, date); !reflect.DeepEqual(summaries, []string{"Late standup", "Review"}) {
+		t.Errorf("Expected the moved standup and the review, got %v", summaries)
+	}
+
+	// New files are noticed
+	writeCalendarFile(t, filepath.Join(dir, "lunch.ics"), singleEvent("DTSTART:20240115T120000Z", "SUMMARY:Lunch"), modTime)
+	if changed := manager.ChangedLocalSources(); len(changed) != 1 {
+		t.Fatalf("Expected the directory to be changed, got %v", changed)
+	}
+	manager.Apply(manager.Fetch(dir))
+	if summaries := summariesOn(manager, date); !reflect.DeepEqual(summaries, []string{"Late standup", "Lunch", "Review"}) {
+		t.Errorf("Expected the new event, got %v", summaries)
+	}
+}
@@ -0,0 +1,409 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+)
+
+// maxRulePeriods bounds rule expansion for rules that rarely or never match,
+// such as the 30th of February
+const maxRulePeriods = 100000
+
+// weekdayNum is a BYDAY entry such as "MO" (ordinal 0) or "-1FR"
+type weekdayNum struct {
+	ordinal int
+	weekday time.Weekday
+}
+
+// recurrenceRule is a parsed RRULE (RFC 5545 section 3.3.10). Rules are
+// expanded on whole days; BYHOUR, BYMINUTE and BYSECOND are not supported.
+type recurrenceRule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time // Last possible instance start; zero if unbounded
+	byDay      []weekdayNum
+	byMonthDay []int
+	byMonth    []time.Month
+	bySetPos   []int
+	weekStart  time.Weekday
+}
+
+// parseRecurrenceRule parses an RRULE value. A date-only UNTIL includes the
+// whole day; other UNTIL values are read like the event's DTSTART.
+func parseRecurrenceRule(value, tzid string, zones *timeZones) (*recurrenceRule, error) {
+	rule := &recurrenceRule{interval: 1, weekStart: time.Monday}
+
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+			switch rule.freq {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+			default:
+				return nil, fmt.Errorf("unsupported frequency %q", val)
+			}
+		case "INTERVAL":
+			if rule.interval, err = strconv.Atoi(val); err != nil || rule.interval < 1 {
+				return nil, fmt.Errorf("invalid interval %q", val)
+			}
+		case "COUNT":
+			if rule.count, err = strconv.Atoi(val); err != nil || rule.count < 1 {
+				return nil, fmt.Errorf("invalid count %q", val)
+			}
+		case "UNTIL":
+			if len(strings.TrimSpace(val)) == len("20060102") {
+				day, err := parseDateTimeIn(val, "", zones)
+				if err != nil {
+					return nil, err
+				}
+				rule.until = storage.AddDays(day, 1).Add(-time.Nanosecond)
+			} else if rule.until, err = parseDateTimeIn(val, tzid, zones); err != nil {
+				return nil, err
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				ordinal, weekday, err := parseOrdinalWeekday(day)
+				if err != nil {
+					return nil, err
+				}
+				rule.byDay = append(rule.byDay, weekdayNum{ordinal: ordinal, weekday: weekday})
+			}
+		case "BYMONTHDAY":
+			if rule.byMonthDay, err = parseIntList(val, -31, 31); err != nil {
+				return nil, err
+			}
+		case "BYMONTH":
+			months, err := parseIntList(val, 1, 12)
+			if err != nil {
+				return nil, err
+			}
+			for _, month := range months {
+				rule.byMonth = append(rule.byMonth, time.Month(month))
+			}
+		case "BYSETPOS":
+			if rule.bySetPos, err = parseIntList(val, -366, 366); err != nil {
+				return nil, err
+			}
+		case "WKST":
+			weekday, ok := weekdayCodes[strings.ToUpper(val)]
+			if !ok {
+				return nil, fmt.Errorf("invalid week start %q", val)
+			}
+			rule.weekStart = weekday
+		}
+	}
+
+	if rule.freq == "" {
+		return nil, fmt.Errorf("missing FREQ in %q", value)
+	}
+	return rule, nil
+}
+
+// parseIntList parses a comma-separated list of non-zero integers within [min, max]
+func parseIntList(value string, min, max int) ([]int, error) {
+	var numbers []int
+	for _, part := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, fmt.Errorf("invalid number %q", part)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// each calls fn with the start of every instance in order, beginning with
+// start itself, until fn returns false, the rule ends or the instances pass
+// limit. Rules without COUNT skip the periods before skip; with COUNT, every
+// instance counts and expansion always begins at start. start, skip and limit
+// are wall-clock times with their fields in UTC; resolve turns wall-clock
+// times into instants.
+func (r *recurrenceRule) each(start, skip, limit time.Time, resolve func(time.Time) time.Time, fn func(time.Time) bool) {
+	emitted := 0
+	emit := func(wall time.Time) bool {
+		instant := resolve(wall)
+		if !r.until.IsZero() && instant.After(r.until) {
+			return false
+		}
+		emitted++
+		if !fn(instant) {
+			return false
+		}
+		return r.count == 0 || emitted < r.count
+	}
+
+	// DTSTART is always the first instance
+	if !emit(start) {
+		return
+	}
+
+	first := r.firstPeriod(start, skip)
+	for period := first; period < first+maxRulePeriods; period++ {
+		days := r.periodDays(start, period)
+		if len(days) > 0 && days[0].After(limit) {
+			return
+		}
+		for _, day := range days {
+			if !day.After(start) {
+				continue
+			}
+			if day.After(limit) || !emit(day) {
+				return
+			}
+		}
+		if len(days) == 0 && r.periodStart(start, period).After(limit) {
+			return
+		}
+	}
+}
+
+// firstPeriod returns the period before the one containing skip, or 0 for
+// rules with COUNT
+func (r *recurrenceRule) firstPeriod(start, skip time.Time) int {
+	if r.count > 0 || !skip.After(start) {
+		return 0
+	}
+
+	var elapsed int
+	switch r.freq {
+	case "WEEKLY":
+		elapsed = int((skip.Unix() - r.periodStart(start, 0).Unix()) / (7 * 24 * 60 * 60))
+	case "MONTHLY":
+		elapsed = (skip.Year()-start.Year())*12 + int(skip.Month()-start.Month())
+	case "YEARLY":
+		elapsed = skip.Year() - start.Year()
+	default:
+		elapsed = int((skip.Unix() - start.Unix()) / (24 * 60 * 60))
+	}
+	return max(elapsed/r.interval-1, 0)
+}
+
+// periodStart returns the first day of the nth period after start's period
+func (r *recurrenceRule) periodStart(start time.Time, period int) time.Time {
+	steps := period * r.interval
+	switch r.freq {
+	case "WEEKLY":
+		offset := (int(start.Weekday()) - int(r.weekStart) + 7) % 7
+		return start.AddDate(0, 0, 7*steps-offset)
+	case "MONTHLY":
+		return time.Date(start.Year(), start.Month()+time.Month(steps), 1, 0, 0, 0, 0, time.UTC)
+	case "YEARLY":
+		return time.Date(start.Year()+steps, time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return start.AddDate(0, 0, steps)
+	}
+}
+
+// periodDays returns the days of the nth period that match the rule, in
+// order, at the time of day of start
+func (r *recurrenceRule) periodDays(start time.Time, period int) []time.Time {
+	first := r.periodStart(start, period)
+
+	var days []time.Time
+	switch r.freq {
+	case "DAILY":
+		if r.matchesWeekday(first) && r.matchesMonthDay(first) && r.matchesMonth(first) {
+			days = append(days, first)
+		}
+
+	case "WEEKLY":
+		for i := 0; i < 7; i++ {
+			day := first.AddDate(0, 0, i)
+			matches := day.Weekday() == start.Weekday()
+			if len(r.byDay) > 0 {
+				matches = r.matchesWeekday(day)
+			}
+			if matches && r.matchesMonth(day) {
+				days = append(days, day)
+			}
+		}
+
+	case "MONTHLY":
+		if r.matchesMonth(first) {
+			days = r.monthDays(first.Year(), first.Month(), start.Day())
+		}
+
+	case "YEARLY":
+		switch {
+		case len(r.byMonth) > 0:
+			for _, month := range r.byMonth {
+				days = append(days, r.monthDays(first.Year(), month, start.Day())...)
+			}
+		case len(r.byMonthDay) > 0:
+			for month := time.January; month <= time.December; month++ {
+				days = append(days, r.monthDays(first.Year(), month, start.Day())...)
+			}
+		case len(r.byDay) > 0:
+			days = r.yearWeekdays(first.Year())
+		default:
+			days = r.monthDays(first.Year(), start.Month(), start.Day())
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	days = r.applySetPos(days)
+
+	hour, minute, second := start.Clock()
+	for i, day := range days {
+		days[i] = time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, time.UTC)
+	}
+	return days
+}
+
+// monthDays returns the days of a month selected by BYMONTHDAY and BYDAY.
+// Without either, the month's defaultDay is used if the month has it.
+func (r *recurrenceRule) monthDays(year int, month time.Month, defaultDay int) []time.Time {
+	daysInMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	date := func(day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	var days []time.Time
+	switch {
+	case len(r.byMonthDay) > 0:
+		// BYDAY only limits the days chosen by BYMONTHDAY
+		for _, n := range r.byMonthDay {
+			day := n
+			if n < 0 {
+				day = daysInMonth + n + 1
+			}
+			if day >= 1 && day <= daysInMonth && r.matchesWeekday(date(day)) {
+				days = append(days, date(day))
+			}
+		}
+
+	case len(r.byDay) > 0:
+		for _, wd := range r.byDay {
+			if wd.ordinal != 0 {
+				if day, ok := nthWeekday(year, month, wd.weekday, wd.ordinal); ok {
+					days = append(days, date(day))
+				}
+				continue
+			}
+			for day := 1; day <= daysInMonth; day++ {
+				if date(day).Weekday() == wd.weekday {
+					days = append(days, date(day))
+				}
+			}
+		}
+
+	case defaultDay <= daysInMonth:
+		days = append(days, date(defaultDay))
+	}
+
+	return uniqueDays(days)
+}
+
+// yearWeekdays returns the days of a year selected by BYDAY, with ordinals
+// counting weeks of the whole year
+func (r *recurrenceRule) yearWeekdays(year int) []time.Time {
+	firstDay := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	lastDay := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	var days []time.Time
+	for _, wd := range r.byDay {
+		var matches []time.Time
+		for day := firstDay; !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() == wd.weekday {
+				matches = append(matches, day)
+			}
+		}
+		switch {
+		case wd.ordinal == 0:
+			days = append(days, matches...)
+		case wd.ordinal > 0 && wd.ordinal <= len(matches):
+			days = append(days, matches[wd.ordinal-1])
+		case wd.ordinal < 0 && -wd.ordinal <= len(matches):
+			days = append(days, matches[len(matches)+wd.ordinal])
+		}
+	}
+	return uniqueDays(days)
+}
+
+// applySetPos keeps the BYSETPOS positions of a period's sorted days
+func (r *recurrenceRule) applySetPos(days []time.Time) []time.Time {
+	if len(r.bySetPos) == 0 {
+		return days
+	}
+
+	var selected []time.Time
+	for _, pos := range r.bySetPos {
+		index := pos - 1
+		if pos < 0 {
+			index = len(days) + pos
+		}
+		if index >= 0 && index < len(days) {
+			selected = append(selected, days[index])
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Before(selected[j]) })
+	return uniqueDays(selected)
+}
+
+// matchesWeekday reports whether BYDAY, ignoring ordinals, allows the day
+func (r *recurrenceRule) matchesWeekday(day time.Time) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+	for _, wd := range r.byDay {
+		if wd.weekday == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesMonthDay reports whether BYMONTHDAY allows the day
+func (r *recurrenceRule) matchesMonthDay(day time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, n := range r.byMonthDay {
+		if n == day.Day() || n < 0 && daysInMonth+n+1 == day.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesMonth reports whether BYMONTH allows the day
+func (r *recurrenceRule) matchesMonth(day time.Time) bool {
+	if len(r.byMonth) == 0 {
+		return true
+	}
+	for _, month := range r.byMonth {
+		if month == day.Month() {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueDays removes repeated days from a list, keeping the first of each
+func uniqueDays(days []time.Time) []time.Time {
+	seen := make(map[time.Time]bool, len(days))
+	unique := days[:0]
+	for _, day := range days {
+		if !seen[day] {
+			seen[day] = true
+			unique = append(unique, day)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,319 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+)
+
+// eventSeries is a VEVENT together with its recurrence: the RRULE, extra
+// RDATE instances, EXDATE exclusions and RECURRENCE-ID overrides of single
+// instances. Events without recurrence are series with a single instance.
+type eventSeries struct {
+	event     Event // The first instance
+	startWall time.Time
+	resolve   func(time.Time) time.Time // Turns wall-clock times into instants like DTSTART
+	duration  time.Duration
+	days      int // Length in days of all-day events, kept exact across DST changes
+	rule      *recurrenceRule
+	rdates    []time.Time
+	exdates   map[int64]bool      // Excluded instance starts (Unix seconds)
+	exdays    []time.Time         // Excluded days from date-only EXDATEs
+	overrides map[int64]component // Replacement VEVENTs by original instance start (Unix seconds)
+	zones     *timeZones
+	build     func(*component) Event
+
+	countOnce   sync.Once
+	countStarts []time.Time // All instance starts of a rule with COUNT, expanded once
+}
+
+// newEventSeries reads the recurrence of a VEVENT. Malformed recurrence
+// properties are ignored, leaving the event's own DTSTART as its only instance.
+func newEventSeries(c *component, zones *timeZones, build func(*component) Event) *eventSeries {
+	series := &eventSeries{
+		event:     build(c),
+		resolve:   func(wall time.Time) time.Time { return wall },
+		exdates:   make(map[int64]bool),
+		overrides: make(map[int64]component),
+		zones:     zones,
+		build:     build,
+	}
+	event := series.event
+
+	if !event.EndTime.IsZero() {
+		series.duration = event.EndTime.Sub(event.StartTime)
+		series.days = storage.DaysBetween(event.StartTime, event.EndTime)
+	}
+
+	start := c.Property("DTSTART")
+	if start == nil || event.StartTime.IsZero() {
+		return series
+	}
+	tzid := start.Param("TZID")
+	value := strings.TrimSpace(start.Value)
+	wall, err := parseWallClock(value)
+	if err != nil {
+		return series
+	}
+	series.startWall = wall
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		series.resolve = func(wall time.Time) time.Time { return wall }
+	case len(value) == len("20060102"):
+		series.resolve = func(wall time.Time) time.Time { return inLocation(wall, time.Local) }
+	default:
+		series.resolve = func(wall time.Time) time.Time { return zones.resolve(wall, tzid) }
+	}
+
+	if rrule := c.Property("RRULE"); rrule != nil {
+		if rule, err := parseRecurrenceRule(rrule.Value, tzid, zones); err == nil {
+			series.rule = rule
+		}
+	}
+	for _, property := range c.PropertiesNamed("RDATE") {
+		series.rdates = append(series.rdates, series.dateList(property)...)
+	}
+	for _, property := range c.PropertiesNamed("EXDATE") {
+		for _, date := range series.dateList(property) {
+			if isDateValue(property) {
+				series.exdays = append(series.exdays, date)
+			} else {
+				series.exdates[date.Unix()] = true
+			}
+		}
+	}
+
+	return series
+}
+
+// dateList parses the comma-separated dates of an RDATE or EXDATE. Periods
+// ("start/end") count from their start.
+func (s *eventSeries) dateList(property contentLine) []time.Time {
+	var dates []time.Time
+	for _, value := range strings.Split(property.Value, ",") {
+		value, _, _ = strings.Cut(value, "/")
+		if date, err := parseDateTimeIn(value, property.Param("TZID"), s.zones); err == nil {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// addOverride registers a VEVENT with a RECURRENCE-ID that replaces one instance
+func (s *eventSeries) addOverride(c *component) {
+	id := c.Property("RECURRENCE-ID")
+	if id == nil {
+		return
+	}
+	original, err := parseDateTimeIn(id.Value, id.Param("TZID"), s.zones)
+	if err != nil {
+		return
+	}
+	s.overrides[original.Unix()] = *c
+}
+
+// occurrences returns the instances of the series that overlap [from, to),
+// ordered by start. Instances without an end overlap if they start in range.
+func (s *eventSeries) occurrences(from, to time.Time) []Event {
+	var instances []Event
+	seen := make(map[int64]bool)
+
+	add := func(start time.Time) {
+		key := start.Unix()
+		if seen[key] || s.exdates[key] || s.excludedDay(start) {
+			return
+		}
+		seen[key] = true
+		if _, ok := s.overrides[key]; ok {
+			return
+		}
+
+		instance := s.event
+		instance.StartTime = start
+		if s.recurring() {
+			instance.RecurrenceID = start
+		}
+		switch {
+		case s.event.EndTime.IsZero():
+		case s.event.AllDay:
+			instance.EndTime = storage.AddDays(start, s.days)
+		default:
+			instance.EndTime = start.Add(s.duration)
+		}
+		if overlaps(instance, from, to) {
+			instances = append(instances, instance)
+		}
+	}
+
+	if s.rule != nil {
+		s.ruleStarts(from, to, func(start time.Time) bool {
+			add(start)
+			return true
+		})
+	} else {
+		add(s.event.StartTime)
+	}
+	for _, rdate := range s.rdates {
+		add(rdate)
+	}
+
+	// Overrides may move an instance into or out of the range. They only
+	// replace instances the series actually has.
+	for key, override := range s.overrides {
+		if !seen[key] && !s.hasInstance(time.Unix(key, 0)) {
+			continue
+		}
+		if status := override.Property("STATUS"); status != nil && strings.EqualFold(strings.TrimSpace(status.Value), "CANCELLED") {
+			continue
+		}
+		event := s.build(&override)
+		if overlaps(event, from, to) {
+			instances = append(instances, event)
+		}
+	}
+
+	sort.SliceStable(instances, func(i, j int) bool {
+		return instances[i].StartTime.Before(instances[j].StartTime)
+	})
+	return instances
+}
+
+// ruleStarts calls fn with the starts of the rule's instances before to, in
+// order, until fn returns false. Rules without COUNT are expanded from shortly
+// before from; rules with COUNT are expanded in full once and then reused.
+func (s *eventSeries) ruleStarts(from, to time.Time, fn func(time.Time) bool) {
+	if s.rule.count > 0 {
+		s.countOnce.Do(func() {
+			limit := time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+			s.rule.each(s.startWall, s.startWall, limit, s.resolve, func(start time.Time) bool {
+				s.countStarts = append(s.countStarts, start)
+				return true
+			})
+		})
+		for _, start := range s.countStarts {
+			if !start.Before(to) || !fn(start) {
+				return
+			}
+		}
+		return
+	}
+
+	// Wall-clock bounds with margins for the zone offset and instances that
+	// start before from but still overlap it
+	skip := from.UTC().Add(-max(s.duration, 0)).AddDate(0, 0, -2)
+	limit := to.UTC().AddDate(0, 0, 2)
+	s.rule.each(s.startWall, skip, limit, s.resolve, func(start time.Time) bool {
+		return start.Before(to) && fn(start)
+	})
+}
+
+// hasInstance reports whether the series has an instance starting at start,
+// taking exclusions into account
+func (s *eventSeries) hasInstance(start time.Time) bool {
+	if s.exdates[start.Unix()] || s.excludedDay(start) {
+		return false
+	}
+	for _, rdate := range s.rdates {
+		if rdate.Equal(start) {
+			return true
+		}
+	}
+	if s.rule == nil {
+		return start.Equal(s.event.StartTime)
+	}
+
+	found := false
+	s.ruleStarts(start, start.Add(time.Second), func(instance time.Time) bool {
+		found = instance.Equal(start)
+		return !found
+	})
+	return found
+}
+
+// recurring reports whether the series has more than its DTSTART instance
+func (s *eventSeries) recurring() bool {
+	return s.rule != nil || len(s.rdates) > 0
+}
+
+// overlaps reports whether an event overlaps [from, to)
+func overlaps(event Event, from, to time.Time) bool {
+	if !event.StartTime.Before(to) {
+		return false
+	}
+	if event.EndTime.After(event.StartTime) {
+		return event.EndTime.After(from)
+	}
+	return !event.StartTime.Before(from)
+}
+
+// excludedDay reports whether a date-only EXDATE removes the instance
+func (s *eventSeries) excludedDay(start time.Time) bool {
+	for _, day := range s.exdays {
+		if storage.SameDay(day, start) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDuration parses an iCalendar DURATION such as "PT1H30M", "P1D" or "-PT15M"
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(value, "-"):
+		sign = -1
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	number := 0
+	digits := false
+	inTime := false
+	for _, r := range value[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			number = number*10 + int(r-'0')
+			digits = true
+			continue
+		case r == 'T' && !digits:
+			inTime = true
+			continue
+		}
+		if !digits {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+
+		unit := time.Duration(0)
+		switch {
+		case r == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case r == 'H' && inTime:
+			unit = time.Hour
+		case r == 'M' && inTime:
+			unit = time.Minute
+		case r == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		total += time.Duration(number) * unit
+		number, digits = 0, false
+	}
+	if digits {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	return sign * total, nil
+}
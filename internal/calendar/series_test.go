@@ -0,0 +1,519 @@
+package calendar
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/testutil"
+)
+
+// instanceStarts expands the events of a calendar between two local dates and
+// returns their start times as "2006-01-02 15:04" in local time
+func instanceStarts(t *testing.T, ics string, from, to time.Time) []string {
+	t.Helper()
+
+	manager := NewManager([]string{})
+	calendars, err := parseComponents(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var starts []string
+	for _, series := range manager.buildSeries(calendars, newTimeZones(calendars)) {
+		for _, event := range series.occurrences(from, to) {
+			starts = append(starts, event.StartTime.Local().Format("2006-01-02 15:04"))
+		}
+	}
+	return starts
+}
+
+// singleEvent wraps VEVENT properties in a calendar
+func singleEvent(properties ...string) string {
+	return "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:series@example.com\r\n" +
+		strings.Join(properties, "\r\n") +
+		"\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+}
+
+func TestEventSeries_RecurrenceRules(t *testing.T) {
+	testutil.SetLocalZone(t, "Europe/Berlin")
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name       string
+		properties []string
+		expected   []string
+	}{
+		{
+			name:       "single event",
+			properties: []string{"DTSTART:20240115T090000Z"},
+			expected:   []string{"2024-01-15 10:00"},
+		},
+		{
+			name:       "daily with count",
+			properties: []string{"DTSTART:20240115T090000Z", "RRULE:FREQ=DAILY;COUNT=3"},
+			expected:   []string{"2024-01-15 10:00", "2024-01-16 10:00", "2024-01-17 10:00"},
+		},
+		{
+			name:       "every other day until a date",
+			properties: []string{"DTSTART;TZID=Europe/Berlin:20240220T080000", "RRULE:FREQ=DAILY;INTERVAL=2;UNTIL=20240226"},
+			expected:   []string{"2024-02-20 08:00", "2024-02-22 08:00", "2024-02-24 08:00", "2024-02-26 08:00"},
+		},
+		{
+			name:       "weekly standup on two days",
+			properties: []string{"DTSTART;TZID=Europe/Berlin:20240205T093000", "RRULE:FREQ=WEEKLY;BYDAY=MO,TH;UNTIL=20240215T235959Z"},
+			expected:   []string{"2024-02-05 09:30", "2024-02-08 09:30", "2024-02-12 09:30", "2024-02-15 09:30"},
+		},
+		{
+			name:       "biweekly",
+			properties: []string{"DTSTART;TZID=Europe/Berlin:20240101T140000", "RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4"},
+			expected:   []string{"2024-01-01 14:00", "2024-01-15 14:00", "2024-01-29 14:00", "2024-02-12 14:00"},
+		},
+		{
+			name:       "started before the range",
+			properties: []string{"DTSTART;TZID=Europe/Berlin:20231002T100000", "RRULE:FREQ=WEEKLY;BYDAY=FR", "EXDATE;TZID=Europe/Berlin:20240105T100000,20240112T100000"},
+			expected:   []string{"2024-01-19 10:00", "2024-01-26 10:00", "2024-02-02 10:00", "2024-02-09 10:00", "2024-02-16 10:00", "2024-02-23 10:00"},
+		},
+		{
+			name:       "monthly on a day of the month",
+			properties: []string{"DTSTART:20231215T120000Z", "RRULE:FREQ=MONTHLY;BYMONTHDAY=15,-1"},
+			expected:   []string{"2024-01-15 13:00", "2024-01-31 13:00", "2024-02-15 13:00", "2024-02-29 13:00"},
+		},
+		{
+			name:       "monthly on the first monday",
+			properties: []string{"DTSTART;TZID=Europe/Berlin:20231204T100000", "RRULE:FREQ=MONTHLY;BYDAY=1MO"},
+			expected:   []string{"2024-01-01 10:00", "2024-02-05 10:00"},
+		},
+		{
+			name:       "monthly on the last weekday",
+			properties: []string{"DTSTART;TZID=Europe/Berlin:20231229T160000", "RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1"},
+			expected:   []string{"2024-01-31 16:00", "2024-02-29 16:00"},
+		},
+		{
+			name:       "monthly skips months without the day",
+			properties: []string{"DTSTART;TZID=Europe/Berlin:20231130T100000", "RRULE:FREQ=MONTHLY"},
+			expected:   []string{"2024-01-30 10:00"},
+		},
+		{
+			name:       "yearly birthday",
+			properties: []string{"DTSTART;VALUE=DATE:19900210", "RRULE:FREQ=YEARLY"},
+			expected:   []string{"2024-02-10 00:00"},
+		},
+		{
+			name:       "extra dates and exclusions",
+			properties: []string{"DTSTART:20240108T090000Z", "RRULE:FREQ=WEEKLY;COUNT=3", "RDATE:20240110T150000Z", "EXDATE:20240115T090000Z"},
+			expected:   []string{"2024-01-08 10:00", "2024-01-10 16:00", "2024-01-22 10:00"},
+		},
+		{
+			name:       "all-day exclusion of a timed instance",
+			properties: []string{"DTSTART:20240108T090000Z", "RRULE:FREQ=DAILY;COUNT=3", "EXDATE;VALUE=DATE:20240109"},
+			expected:   []string{"2024-01-08 10:00", "2024-01-10 10:00"},
+		},
+		{
+			name:       "unsupported frequency keeps the first instance",
+			properties: []string{"DTSTART:20240108T090000Z", "RRULE:FREQ=HOURLY;COUNT=3"},
+			expected:   []string{"2024-01-08 10:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			starts := instanceStarts(t, singleEvent(tt.properties...), from, to)
+			if !reflect.DeepEqual(starts, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, starts)
+			}
+		})
+	}
+}
+
+func TestEventSeries_KeepsLocalTimeAcrossDST(t *testing.T) {
+	testutil.SetLocalZone(t, "Europe/Berlin")
+	from := time.Date(2024, 3, 28, 0, 0, 0, 0, time.Local)
+	to := time.Date(2024, 4, 2, 0, 0, 0, 0, time.Local)
+
+	starts := instanceStarts(t, singleEvent("DTSTART;TZID=Europe/Berlin:20240325T090000", "RRULE:FREQ=DAILY"), from, to)
+	expected := []string{"2024-03-28 09:00", "2024-03-29 09:00", "2024-03-30 09:00", "2024-03-31 09:00", "2024-04-01 09:00"}
+	if !reflect.DeepEqual(starts, expected) {
+		t.Errorf("Expected %v, got %v", expected, starts)
+	}
+}
+
+func TestEventSeries_OldStartFarFutureRange(t *testing.T) {
+	testutil.SetLocalZone(t, "Europe/Berlin")
+	// Further from DTSTART than maxRulePeriods days
+	from := time.Date(2400, 3, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2400, 3, 8, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name       string
+		properties []string
+		expected   []string
+	}{
+		{
+			name:       "daily",
+			properties: []string{"DTSTART;TZID=Europe/Berlin:19700101T090000", "RRULE:FREQ=DAILY;BYDAY=MO,TH"},
+			expected:   []string{"2400-03-02 09:00", "2400-03-06 09:00"},
+		},
+		{
+			name:       "weekly",
+			properties: []string{"DTSTART;TZID=Europe/Berlin:19700105T100000", "RRULE:FREQ=WEEKLY;BYDAY=MO,FR"},
+			expected:   []string{"2400-03-03 10:00", "2400-03-06 10:00"},
+		},
+		{
+			name:       "monthly",
+			properties: []string{"DTSTART;TZID=Europe/Berlin:19700102T120000", "RRULE:FREQ=MONTHLY;BYMONTHDAY=2,-1"},
+			expected:   []string{"2400-03-02 12:00"},
+		},
+		{
+			name:       "yearly with a long first instance",
+			properties: []string{"DTSTART;VALUE=DATE:19700225", "DTEND;VALUE=DATE:19700305", "RRULE:FREQ=YEARLY"},
+			expected:   []string{"2400-02-25 00:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			starts := instanceStarts(t, singleEvent(tt.properties...), from, to)
+			if !reflect.DeepEqual(starts, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, starts)
+			}
+		})
+	}
+}
+
+func TestEventSeries_CountRuleReusedAcrossRanges(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	calendars, err := parseComponents(strings.NewReader(singleEvent("DTSTART:20240101T090000Z", "RRULE:FREQ=WEEKLY;COUNT=10")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	series := NewManager([]string{}).buildSeries(calendars, newTimeZones(calendars))[0]
+
+	ranges := []struct {
+		from, to time.Time
+		expected int
+	}{
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), 5},
+		{time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), 5},
+		{time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 0},
+	}
+	for _, r := range ranges {
+		if got := len(series.occurrences(r.from, r.to)); got != r.expected {
+			t.Errorf("Expected %d instances from %s, got %d", r.expected, r.from.Format("2006-01-02"), got)
+		}
+	}
+}
+
+func TestEventSeries_Overrides(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	ics := "BEGIN:VCALENDAR\r\n" +
+		// The moved instance is listed before its series, as some servers do
+		"BEGIN:VEVENT\r\n" +
+		"UID:standup@example.com\r\n" +
+		"RECURRENCE-ID:20240115T090000Z\r\n" +
+		"DTSTART:20240116T130000Z\r\n" +
+		"SUMMARY:Standup (moved)\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:standup@example.com\r\n" +
+		"DTSTART:20240108T090000Z\r\n" +
+		"DTEND:20240108T091500Z\r\n" +
+		"RRULE:FREQ=WEEKLY;COUNT=4\r\n" +
+		"SUMMARY:Standup\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:standup@example.com\r\n" +
+		"RECURRENCE-ID:20240122T090000Z\r\n" +
+		"DTSTART:20240122T090000Z\r\n" +
+		"STATUS:CANCELLED\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	manager := NewManager([]string{})
+	calendars, err := parseComponents(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	series := manager.buildSeries(calendars, newTimeZones(calendars))
+	if len(series) != 1 {
+		t.Fatalf("Expected overrides to join their series, got %d series", len(series))
+	}
+
+	var got []string
+	for _, event := range series[0].occurrences(from, to) {
+		got = append(got, event.StartTime.Format("01-02 15:04")+" "+event.Summary)
+	}
+	expected := []string{"01-08 09:00 Standup", "01-16 13:00 Standup (moved)", "01-29 09:00 Standup"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// Instances keep the length of the first one
+	if first := series[0].occurrences(from, to)[2]; first.EndTime.Sub(first.StartTime) != 15*time.Minute {
+		t.Errorf("Expected a 15 minute instance, got %v", first.EndTime.Sub(first.StartTime))
+	}
+}
+
+func TestEventSeries_OverridesOfMissingInstances(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	ics := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:review@example.com\r\n" +
+		"DTSTART:20240108T090000Z\r\n" +
+		"RRULE:FREQ=WEEKLY;UNTIL=20240122T090000Z\r\n" +
+		"EXDATE:20240115T090000Z\r\n" +
+		"SUMMARY:Review\r\n" +
+		"END:VEVENT\r\n" +
+		// Overrides an excluded instance
+		"BEGIN:VEVENT\r\n" +
+		"UID:review@example.com\r\n" +
+		"RECURRENCE-ID:20240115T090000Z\r\n" +
+		"DTSTART:20240116T090000Z\r\n" +
+		"SUMMARY:Review (excluded)\r\n" +
+		"END:VEVENT\r\n" +
+		// Overrides an instance after the rule ends
+		"BEGIN:VEVENT\r\n" +
+		"UID:review@example.com\r\n" +
+		"RECURRENCE-ID:20240129T090000Z\r\n" +
+		"DTSTART:20240130T090000Z\r\n" +
+		"SUMMARY:Review (after the end)\r\n" +
+		"END:VEVENT\r\n" +
+		// Moves the last instance into the range from outside it
+		"BEGIN:VEVENT\r\n" +
+		"UID:review@example.com\r\n" +
+		"RECURRENCE-ID:20240122T090000Z\r\n" +
+		"DTSTART:20240220T090000Z\r\n" +
+		"SUMMARY:Review (moved)\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	manager := NewManager([]string{})
+	calendars, err := parseComponents(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	series := manager.buildSeries(calendars, newTimeZones(calendars))
+
+	var got []string
+	for _, event := range series[0].occurrences(from, to) {
+		got = append(got, event.StartTime.Format("01-02 15:04")+" "+event.Summary)
+	}
+	expected := []string{"01-08 09:00 Review", "02-20 09:00 Review (moved)"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// The moved instance is found even when the range excludes its original start
+	got = nil
+	for _, event := range series[0].occurrences(time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC), to) {
+		got = append(got, event.Summary)
+	}
+	if !reflect.DeepEqual(got, []string{"Review (moved)"}) {
+		t.Errorf("Expected only the moved instance, got %v", got)
+	}
+}
+
+func TestManager_ParseICalData_RecurringEvent(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	manager := NewManager([]string{})
+
+	ics := singleEvent("DTSTART:20240101T090000Z", "RRULE:FREQ=WEEKLY;BYDAY=MO", "SUMMARY:Weekly standup")
+	for _, tt := range []struct {
+		date     time.Time
+		expected int
+	}{
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1},
+		{time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), 0},
+		{time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), 1},
+	} {
+		events, err := manager.parseICalData(strings.NewReader(ics), tt.date)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(events) != tt.expected {
+			t.Errorf("Expected %d events on %s, got %d", tt.expected, tt.date.Format("2006-01-02"), len(events))
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    time.Duration
+		expectError bool
+	}{
+		{input: "PT1H30M", expected: 90 * time.Minute},
+		{input: "P1D", expected: 24 * time.Hour},
+		{input: "P1W", expected: 7 * 24 * time.Hour},
+		{input: "P1DT2H", expected: 26 * time.Hour},
+		{input: "-PT15M", expected: -15 * time.Minute},
+		{input: "PT", expectError: true},
+		{input: "1H", expectError: true},
+		{input: "PT5", expectError: true},
+		{input: "P1H", expectError: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDuration(tt.input)
+		if tt.expectError {
+			if err == nil {
+				t.Errorf("parseDuration(%q): expected error, got %v", tt.input, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.expected {
+			t.Errorf("parseDuration(%q) = %v, %v, expected %v", tt.input, got, err, tt.expected)
+		}
+	}
+}
+
+func TestManager_ParseICalData_MultiDayEvents(t *testing.T) {
+	testutil.SetLocalZone(t, "Europe/Berlin")
+	manager := NewManager([]string{})
+
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:conference@example.com\r\n" +
+		"DTSTART;VALUE=DATE:20240115\r\n" +
+		"DTEND;VALUE=DATE:20240118\r\n" +
+		"SUMMARY:Conference\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:holiday@example.com\r\n" +
+		"DTSTART;VALUE=DATE:20240116\r\n" +
+		"SUMMARY:Holiday\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:night@example.com\r\n" +
+		"DTSTART;TZID=Europe/Berlin:20240116T220000\r\n" +
+		"DURATION:PT4H\r\n" +
+		"SUMMARY:Night shift\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:late@example.com\r\n" +
+		"DTSTART;TZID=Europe/Berlin:20240115T230000\r\n" +
+		"DTEND;TZID=Europe/Berlin:20240116T000000\r\n" +
+		"SUMMARY:Ends at midnight\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:weekend@example.com\r\n" +
+		"DTSTART;VALUE=DATE:20240113\r\n" +
+		"DURATION:P2D\r\n" +
+		"RRULE:FREQ=WEEKLY;COUNT=2\r\n" +
+		"SUMMARY:Weekend trip\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	tests := []struct {
+		day      int
+		expected []string
+	}{
+		{14, []string{"Weekend trip"}},
+		{15, []string{"Conference", "Ends at midnight"}},
+		{16, []string{"Conference", "Holiday", "Night shift"}},
+		{17, []string{"Conference", "Night shift"}},
+		{18, nil},
+		{21, []string{"Weekend trip"}},
+	}
+
+	for _, tt := range tests {
+		date := time.Date(2024, 1, tt.day, 0, 0, 0, 0, time.Local)
+		events, err := manager.parseICalData(strings.NewReader(data), date)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var summaries []string
+		for _, event := range events {
+			summaries = append(summaries, event.Summary)
+		}
+		sort.Strings(summaries)
+		if !reflect.DeepEqual(summaries, tt.expected) {
+			t.Errorf("Expected %v on Jan %d, got %v", tt.expected, tt.day, summaries)
+		}
+	}
+}
+
+func TestManager_BuildEvent_AllDay(t *testing.T) {
+	testutil.SetLocalZone(t, "Europe/Berlin")
+	manager := NewManager([]string{})
+
+	calendars, err := parseComponents(strings.NewReader(singleEvent("DTSTART;VALUE=DATE:20240330", "SUMMARY:Trip")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	event := manager.buildEvent(calendars[0].Components[0], newTimeZones(calendars))
+
+	if !event.AllDay {
+		t.Error("Expected a date-only DTSTART to make an all-day event")
+	}
+	// March 30 is a 24 hour day and the event still ends at local midnight
+	if expected := time.Date(2024, 3, 31, 0, 0, 0, 0, time.Local); !event.EndTime.Equal(expected) {
+		t.Errorf("Expected the event to end at %v, got %v", expected, event.EndTime)
+	}
+}
+
+func TestEvent_StableIDs(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	manager := NewManager([]string{})
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	ics := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:standup@example.com\r\nDTSTART:20240108T090000Z\r\nRRULE:FREQ=WEEKLY;COUNT=3\r\nSUMMARY:Standup\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:standup@example.com\r\nRECURRENCE-ID:20240115T090000Z\r\nDTSTART:20240116T130000Z\r\nSUMMARY:Standup (moved)\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:review@example.com\r\nDTSTART:20240110T150000Z\r\nSUMMARY:Review\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART:20240111T150000Z\r\nSUMMARY:No UID\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	ids := func() map[string]string {
+		series, err := manager.parseFeed(strings.NewReader(ics))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		ids := make(map[string]string)
+		for _, s := range series {
+			for _, event := range s.occurrences(from, to) {
+				ids[event.StartTime.Format("01-02 15:04")] = event.ID()
+			}
+		}
+		return ids
+	}
+
+	first, second := ids(), ids()
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same IDs on every parse, got %v and %v", first, second)
+	}
+	if len(first) != 5 {
+		t.Fatalf("Expected 5 instances, got %v", first)
+	}
+
+	unique := make(map[string]bool)
+	for _, id := range first {
+		if !strings.HasPrefix(id, "cal_") {
+			t.Errorf("Expected a calendar task ID, got %q", id)
+		}
+		unique[id] = true
+	}
+	if len(unique) != len(first) {
+		t.Errorf("Expected every instance to have its own ID, got %v", first)
+	}
+
+	// The moved instance keeps the ID of the slot it replaces
+	original := Event{UID: "standup@example.com", RecurrenceID: time.Date(2024, 1, 15, 10, 0, 0, 0, time.FixedZone("CET", 3600))}
+	if first["01-16 13:00"] != original.ID() {
+		t.Errorf("Expected the moved instance to keep its original ID")
+	}
+	if first["01-10 15:00"] != (Event{UID: "review@example.com"}).ID() {
+		t.Errorf("Expected a single event's ID to depend only on its UID")
+	}
+}
@@ -0,0 +1,373 @@
+package calendar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"personal-disorganizer/internal/storage"
+)
+
+// TaskSyncResult is the outcome of syncing tasks with a CalDAV server
+type TaskSyncResult struct {
+	URL       string
+	Tasks     []storage.Task // The tasks passed to SyncTasks with the server's changes applied; nil if the sync failed
+	Changed   int            // Tasks created, changed or deleted on the server since the last sync
+	Conflicts []string       // Tasks changed on both sides, or on the server during the sync
+	Err       error          // First error; tasks it did not affect were still synced
+}
+
+// todoState is what the last sync knew about one task on the server
+type todoState struct {
+	URL         string `json:"url"`
+	ETag        string `json:"etag"`
+	Fingerprint string `json:"fingerprint"` // taskFingerprint of the task as last synced
+}
+
+// taskSyncState is the sync state of the task calendar of one CalDAV source
+type taskSyncState struct {
+	Collection string               `json:"collection"`
+	Todos      map[string]todoState `json:"todos"` // By task ID, which is the VTODO's UID
+}
+
+// remoteTodo is a VTODO read from the server
+type remoteTodo struct {
+	davObject
+	task storage.Task
+}
+
+// TaskSources returns the CalDAV calendars configured to sync tasks
+func (m *Manager) TaskSources() []string {
+	var sources []string
+	for _, url := range m.URLs() {
+		if source := m.sources[url]; source.IsCalDAV() && source.SyncTasks {
+			sources = append(sources, url)
+		}
+	}
+	return sources
+}
+
+// SyncTasks syncs tasks with the task calendar of a CalDAV source, as VTODOs
+// named after the task IDs. Changes are detected against the ETags and task
+// contents of the last sync: tasks changed on one side are copied to the
+// other, and deletions are carried over unless the other side changed the
+// task. When both sides changed a task the local version wins, as it does
+// between instances. Uploads are conditional on the ETag, so a task changed
+// on the server in the meantime is left for the next sync.
+//
+// It blocks on the network and does not change tasks; the result holds the
+// tasks with the server's changes applied.
+func (m *Manager) SyncTasks(source string, tasks []storage.Task) TaskSyncResult {
+	result := TaskSyncResult{URL: source}
+
+	collection, err := m.taskCollection(source)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	objects, err := m.calendarQuery(source, collection.URL, "VTODO")
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	state := m.taskState(source, collection.URL)
+	next := make(map[string]todoState)
+	fail := func(err error) {
+		if result.Err == nil {
+			result.Err = err
+		}
+	}
+	conflict := func(task storage.Task) {
+		result.Conflicts = append(result.Conflicts, task.Text)
+	}
+
+	// upload writes a task to the server, keeping the previous state if it fails
+	upload := func(task storage.Task, target, etag string) error {
+		newETag, err := m.putObject(source, target, etag, taskTodo(task))
+		if err != nil {
+			if s, known := state[task.ID]; known {
+				next[task.ID] = s
+			}
+			if !errors.Is(err, errPreconditionFailed) {
+				fail(err)
+			}
+			return err
+		}
+		next[task.ID] = todoState{URL: target, ETag: newETag, Fingerprint: taskFingerprint(task)}
+		return nil
+	}
+
+	var remoteIDs []string
+	remote := make(map[string]remoteTodo)
+	for _, object := range objects {
+		if task, ok := parseTodo(object.Data); ok {
+			if _, seen := remote[task.ID]; !seen {
+				remoteIDs = append(remoteIDs, task.ID)
+			}
+			remote[task.ID] = remoteTodo{davObject: object, task: task}
+		}
+	}
+
+	synced := make([]storage.Task, 0, len(tasks)+len(remote))
+	local := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		local[task.ID] = true
+		s, known := state[task.ID]
+		r, onServer := remote[task.ID]
+		localChanged := !known || taskFingerprint(task) != s.Fingerprint
+
+		switch {
+		case !onServer && !known:
+			// Created here
+			upload(task, collection.URL+neturl.PathEscape(task.ID)+".ics", "")
+		case !onServer && !localChanged:
+			// Deleted on the server
+			result.Changed++
+			continue
+		case !onServer:
+			// Deleted on the server but changed here, so it is restored
+			upload(task, s.URL, "")
+		case !known && taskFingerprint(task) == taskFingerprint(r.task):
+			// Already the same on both sides
+			next[task.ID] = todoState{URL: r.URL, ETag: r.ETag, Fingerprint: taskFingerprint(task)}
+		case localChanged:
+			remoteChanged := !known || r.ETag != s.ETag
+			if remoteChanged {
+				conflict(task)
+			}
+			if err := upload(task, r.URL, r.ETag); errors.Is(err, errPreconditionFailed) && !remoteChanged {
+				conflict(task)
+			}
+		case r.ETag != s.ETag:
+			// Changed on the server
+			task = applyTodo(task, r.task)
+			next[task.ID] = todoState{URL: r.URL, ETag: r.ETag, Fingerprint: taskFingerprint(task)}
+			result.Changed++
+		default:
+			next[task.ID] = s
+		}
+		synced = append(synced, task)
+	}
+
+	// Tasks only on the server
+	for _, id := range remoteIDs {
+		if local[id] {
+			continue
+		}
+		r := remote[id]
+		s, known := state[id]
+
+		if known && r.ETag == s.ETag {
+			// Deleted here
+			if err := m.deleteObject(source, r.URL, r.ETag); err != nil {
+				next[id] = s
+				if errors.Is(err, errPreconditionFailed) {
+					conflict(r.task)
+				} else {
+					fail(err)
+				}
+			}
+			continue
+		}
+
+		// Created on the server, or changed there after being deleted here
+		synced = append(synced, r.task)
+		next[id] = todoState{URL: r.URL, ETag: r.ETag, Fingerprint: taskFingerprint(r.task)}
+		result.Changed++
+	}
+
+	if err := m.saveTaskState(source, collection.URL, next); err != nil {
+		fail(err)
+	}
+	result.Tasks = synced
+	return result
+}
+
+// taskCollection returns the calendar that holds a source's tasks: the one
+// named by task_calendar, or else the first that supports VTODOs
+func (m *Manager) taskCollection(source string) (davCollection, error) {
+	collections, err := m.discoverCalendars(source)
+	if err != nil {
+		return davCollection{}, err
+	}
+
+	name := m.sources[source].TaskCalendar
+	for _, collection := range collections {
+		if name == "" && collection.supports("VTODO") {
+			return collection, nil
+		}
+		if name != "" && (strings.EqualFold(collection.Name, name) || sameResource(collection.URL, resolveHref(source, name))) {
+			return collection, nil
+		}
+	}
+	if name != "" {
+		return davCollection{}, fmt.Errorf("task calendar %q not found at %s", name, source)
+	}
+	return davCollection{}, fmt.Errorf("no CalDAV calendar for tasks at %s", source)
+}
+
+// taskFingerprint summarises the parts of a task that are synced, to notice
+// local changes since the last sync
+func taskFingerprint(task storage.Task) string {
+	fields := []string{
+		task.Text,
+		strconv.FormatBool(task.Done),
+		storage.StartOfDay(task.Date).Format("2006-01-02"),
+		task.ParentID,
+		strconv.Itoa(task.Priority),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// applyTodo copies the synced parts of a task read from the server onto the
+// local task, keeping what VTODOs do not carry, such as recurrence
+func applyTodo(local, remote storage.Task) storage.Task {
+	local.Text = remote.Text
+	local.Done = remote.Done
+	local.Date = remote.Date
+	local.ParentID = remote.ParentID
+	local.Priority = remote.Priority
+	return local
+}
+
+// taskTodo writes a task as an iCalendar object with one VTODO, due on the
+// task's day
+func taskTodo(task storage.Task) string {
+	return string(storage.ICalendar([]storage.Task{task}, storage.ExportTodo))
+}
+
+// parseTodo reads the first VTODO of an iCalendar object as a task. Tasks
+// without a due date or start are put on today. It reports false if there
+// is no VTODO with a UID.
+func parseTodo(data string) (storage.Task, bool) {
+	calendars, err := parseComponents(strings.NewReader(data))
+	if err != nil {
+		return storage.Task{}, false
+	}
+	var todo *component
+	for _, calendar := range calendars {
+		calendar.Walk(func(c *component) {
+			if todo == nil && c.Name == "VTODO" {
+				todo = c
+			}
+		})
+	}
+	if todo == nil {
+		return storage.Task{}, false
+	}
+
+	zones := newTimeZones(calendars)
+	now := time.Now()
+	task := storage.Task{CreatedAt: now}
+	var due, start time.Time
+	var status string
+	var completed bool
+	for _, property := range todo.Properties {
+		switch property.Name {
+		case "UID":
+			task.ID = strings.TrimSpace(property.Text())
+		case "SUMMARY":
+			task.Text = property.Text()
+		case "STATUS":
+			status = strings.ToUpper(strings.TrimSpace(property.Value))
+		case "COMPLETED":
+			completed = true
+		case "DUE", "DTSTART":
+			t, err := parseDateTimeIn(property.Value, property.Param("TZID"), zones)
+			if err != nil {
+				continue
+			}
+			if property.Name == "DUE" {
+				due = t
+			} else {
+				start = t
+			}
+		case "CREATED":
+			if t, err := parseDateTimeIn(property.Value, property.Param("TZID"), zones); err == nil {
+				task.CreatedAt = t
+			}
+		case "RELATED-TO":
+			if reltype := property.Param("RELTYPE"); reltype == "" || strings.EqualFold(reltype, "PARENT") {
+				task.ParentID = strings.TrimSpace(property.Text())
+			}
+		case storage.OrderProperty:
+			task.Priority, _ = strconv.Atoi(strings.TrimSpace(property.Value))
+		}
+	}
+	if task.ID == "" {
+		return storage.Task{}, false
+	}
+
+	task.Done = status == "COMPLETED" || status == "" && completed
+	switch {
+	case !due.IsZero():
+		task.Date = storage.StartOfDay(due)
+	case !start.IsZero():
+		task.Date = storage.StartOfDay(start)
+	default:
+		task.Date = storage.StartOfDay(now)
+	}
+	return task, true
+}
+
+// taskStatePath returns the file of a source's task sync state in the cache
+func (m *Manager) taskStatePath(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(m.cacheDir, "tasks-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// taskState returns the state of the last task sync with a collection,
+// loading it from the cache on first use. State kept for another collection
+// is not used, so changing task_calendar starts afresh.
+func (m *Manager) taskState(source, collection string) map[string]todoState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, ok := m.taskStates[source]
+	if !ok && m.cacheDir != "" {
+		if data, err := os.ReadFile(m.taskStatePath(source)); err == nil {
+			state = &taskSyncState{}
+			if err := json.Unmarshal(data, state); err != nil {
+				state = nil
+			}
+		}
+	}
+
+	todos := make(map[string]todoState)
+	if state != nil && state.Collection == collection {
+		for id, todo := range state.Todos {
+			todos[id] = todo
+		}
+	}
+	return todos
+}
+
+// saveTaskState records the state of a task sync, in the cache if enabled
+func (m *Manager) saveTaskState(source, collection string, todos map[string]todoState) error {
+	state := &taskSyncState{Collection: collection, Todos: todos}
+	m.mu.Lock()
+	m.taskStates[source] = state
+	m.mu.Unlock()
+
+	if m.cacheDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(m.cacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create calendar cache: %w", err)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode task sync state: %w", err)
+	}
+	return storage.WriteFileAtomic(m.taskStatePath(source), data, 0644)
+}
@@ -0,0 +1,374 @@
+package calendar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embed the tz database so TZIDs resolve on systems without zoneinfo files
+	_ "time/tzdata"
+)
+
+// timeZones resolves the TZID parameters of one calendar. Names known to the
+// tz database are resolved there; other names, such as the Windows zone names
+// used by Outlook, fall back to the calendar's VTIMEZONE definitions.
+type timeZones struct {
+	defined  map[string]*vtimezone
+	loaded   map[string]*time.Location
+	floating *time.Location // Zone of times without TZID or UTC marker
+}
+
+// newTimeZones collects the VTIMEZONE definitions of a calendar. Floating
+// times are read in the calendar's X-WR-TIMEZONE if it has one (as Google and
+// Apple calendars do), otherwise in local time.
+func newTimeZones(calendars []*component) *timeZones {
+	zones := &timeZones{
+		defined:  make(map[string]*vtimezone),
+		loaded:   make(map[string]*time.Location),
+		floating: time.Local,
+	}
+
+	for _, calendar := range calendars {
+		calendar.Walk(func(c *component) {
+			if c.Name != "VTIMEZONE" {
+				return
+			}
+			if id := c.Property("TZID"); id != nil {
+				zones.defined[strings.TrimSpace(id.Value)] = parseVTimezone(c)
+			}
+		})
+
+		if calendar.Name == "VCALENDAR" {
+			if name := calendar.Property("X-WR-TIMEZONE"); name != nil {
+				if loc := zones.location(name.Value); loc != nil {
+					zones.floating = loc
+				}
+			}
+		}
+	}
+
+	return zones
+}
+
+// location loads a zone from the tz database, or returns nil if it is unknown
+func (z *timeZones) location(tzid string) *time.Location {
+	// Globally unique TZIDs start with a slash (RFC 5545 section 3.2.19)
+	tzid = strings.TrimPrefix(strings.TrimSpace(tzid), "/")
+	if tzid == "" {
+		return nil
+	}
+
+	if loc, ok := z.loaded[tzid]; ok {
+		return loc
+	}
+	loc, err := time.LoadLocation(tzid)
+	if err != nil || tzid == "Local" {
+		loc = nil
+	}
+	z.loaded[tzid] = loc
+	return loc
+}
+
+// resolve returns the instant of a wall-clock time, given with its fields in
+// UTC, in the zone named by tzid. Unknown zones are treated as floating time.
+func (z *timeZones) resolve(wall time.Time, tzid string) time.Time {
+	if tzid == "" {
+		return inLocation(wall, z.floating)
+	}
+	if loc := z.location(tzid); loc != nil {
+		return inLocation(wall, loc)
+	}
+	if definition, ok := z.defined[strings.TrimSpace(tzid)]; ok {
+		return definition.resolve(wall)
+	}
+	return inLocation(wall, z.floating)
+}
+
+// inLocation returns the instant at which a location's clocks show the fields of wall
+func inLocation(wall time.Time, loc *time.Location) time.Time {
+	year, month, day := wall.Date()
+	hour, minute, second := wall.Clock()
+	return time.Date(year, month, day, hour, minute, second, 0, loc)
+}
+
+// vtimezone is a time zone defined inside a calendar by its observances, the
+// STANDARD and DAYLIGHT periods with their UTC offsets
+type vtimezone struct {
+	observances []observance
+}
+
+// observance is a STANDARD or DAYLIGHT subcomponent of a VTIMEZONE
+type observance struct {
+	name       string
+	start      time.Time // First onset as wall-clock time before the change, fields in UTC
+	offsetFrom int       // Seconds east of UTC before the onset
+	offsetTo   int       // Seconds east of UTC from the onset on
+	rule       *yearlyRule
+	rdates     []time.Time
+}
+
+// yearlyRule is the yearly RRULE that time zone observances repeat by
+type yearlyRule struct {
+	month    time.Month
+	weekday  time.Weekday
+	ordinal  int // Nth weekday of the month, negative counts from the end; 0 uses monthDay
+	monthDay int
+	until    time.Time
+}
+
+// parseVTimezone reads the observances of a VTIMEZONE component. Observances
+// with missing or invalid offsets are skipped.
+func parseVTimezone(c *component) *vtimezone {
+	zone := &vtimezone{}
+
+	for _, child := range c.Components {
+		if child.Name != "STANDARD" && child.Name != "DAYLIGHT" {
+			continue
+		}
+
+		var obs observance
+		var err error
+		if name := child.Property("TZNAME"); name != nil {
+			obs.name = name.Text()
+		}
+		if obs.offsetFrom, err = parseUTCOffset(child.Property("TZOFFSETFROM")); err != nil {
+			continue
+		}
+		if obs.offsetTo, err = parseUTCOffset(child.Property("TZOFFSETTO")); err != nil {
+			continue
+		}
+		if start := child.Property("DTSTART"); start != nil {
+			if obs.start, err = parseWallClock(start.Value); err != nil {
+				continue
+			}
+		}
+		if rule := child.Property("RRULE"); rule != nil {
+			obs.rule = parseYearlyRule(rule.Value, obs.start)
+		}
+		for _, rdate := range child.PropertiesNamed("RDATE") {
+			for _, value := range strings.Split(rdate.Value, ",") {
+				if date, err := parseWallClock(value); err == nil {
+					obs.rdates = append(obs.rdates, date)
+				}
+			}
+		}
+
+		zone.observances = append(zone.observances, obs)
+	}
+
+	return zone
+}
+
+// resolve returns the instant of a wall-clock time in this zone
+func (z *vtimezone) resolve(wall time.Time) time.Time {
+	obs := z.observanceAt(wall)
+	if obs == nil {
+		return inLocation(wall, time.Local)
+	}
+	return inLocation(wall, time.FixedZone(obs.name, obs.offsetTo))
+}
+
+// observanceAt returns the observance in effect at a wall-clock time: the one
+// with the latest onset not after it. Before the first onset, the earliest
+// observance's previous offset applies.
+func (z *vtimezone) observanceAt(wall time.Time) *observance {
+	var current *observance
+	var currentOnset time.Time
+	var earliest *observance
+
+	for i := range z.observances {
+		obs := &z.observances[i]
+		if earliest == nil || obs.start.Before(earliest.start) {
+			earliest = obs
+		}
+		if onset, ok := obs.lastOnset(wall); ok && (current == nil || onset.After(currentOnset)) {
+			current, currentOnset = obs, onset
+		}
+	}
+
+	if current == nil && earliest != nil {
+		return &observance{name: earliest.name, offsetTo: earliest.offsetFrom}
+	}
+	return current
+}
+
+// lastOnset returns the latest onset of the observance that is not after wall
+func (o *observance) lastOnset(wall time.Time) (time.Time, bool) {
+	var last time.Time
+	found := false
+	consider := func(onset time.Time) {
+		if !onset.After(wall) && (!found || onset.After(last)) {
+			last, found = onset, true
+		}
+	}
+
+	consider(o.start)
+	for _, rdate := range o.rdates {
+		consider(rdate)
+	}
+	if o.rule != nil {
+		for year := wall.Year() - 1; year <= wall.Year(); year++ {
+			onset, ok := o.rule.onset(year, o.start)
+			if ok && !onset.Before(o.start) && (o.rule.until.IsZero() || !onset.After(o.rule.until)) {
+				consider(onset)
+			}
+		}
+	}
+
+	return last, found
+}
+
+// parseYearlyRule reads the subset of RRULE used by time zones:
+// FREQ=YEARLY with BYMONTH and BYDAY (e.g. -1SU) or BYMONTHDAY.
+// It returns nil for rules it cannot follow.
+func parseYearlyRule(value string, start time.Time) *yearlyRule {
+	rule := &yearlyRule{month: start.Month(), monthDay: start.Day()}
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			if strings.ToUpper(val) != "YEARLY" {
+				return nil
+			}
+		case "BYMONTH":
+			month, err := strconv.Atoi(val)
+			if err != nil || month < 1 || month > 12 {
+				return nil
+			}
+			rule.month = time.Month(month)
+		case "BYDAY":
+			ordinal, weekday, err := parseOrdinalWeekday(val)
+			if err != nil || ordinal == 0 {
+				return nil
+			}
+			rule.ordinal, rule.weekday = ordinal, weekday
+		case "BYMONTHDAY":
+			day, err := strconv.Atoi(val)
+			if err != nil || day < 1 || day > 31 {
+				return nil
+			}
+			rule.monthDay = day
+		case "UNTIL":
+			if until, err := parseWallClock(val); err == nil {
+				rule.until = until
+			}
+		}
+	}
+
+	return rule
+}
+
+// onset returns the rule's onset in a year at the wall-clock time of start
+func (r *yearlyRule) onset(year int, start time.Time) (time.Time, bool) {
+	day := r.monthDay
+	if r.ordinal != 0 {
+		var ok bool
+		if day, ok = nthWeekday(year, r.month, r.weekday, r.ordinal); !ok {
+			return time.Time{}, false
+		}
+	}
+
+	hour, minute, second := start.Clock()
+	onset := time.Date(year, r.month, day, hour, minute, second, 0, time.UTC)
+	if onset.Month() != r.month {
+		return time.Time{}, false
+	}
+	return onset, true
+}
+
+// weekdayCodes maps iCalendar weekday codes to weekdays
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// parseOrdinalWeekday parses BYDAY values such as "SU", "2MO" or "-1SU"
+func parseOrdinalWeekday(value string) (int, time.Weekday, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return 0, 0, fmt.Errorf("invalid weekday %q", value)
+	}
+
+	weekday, ok := weekdayCodes[value[len(value)-2:]]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid weekday %q", value)
+	}
+
+	ordinal := 0
+	if prefix := value[:len(value)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return 0, 0, fmt.Errorf("invalid weekday ordinal %q", value)
+		}
+		ordinal = n
+	}
+
+	return ordinal, weekday, nil
+}
+
+// nthWeekday returns the day of the month of its nth weekday; negative n
+// counts from the end of the month. It returns false if there is no such day.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) (int, bool) {
+	daysInMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	var day int
+	if n > 0 {
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).Weekday()
+		day = 1 + (int(weekday)-int(first)+7)%7 + 7*(n-1)
+	} else {
+		last := time.Date(year, month, daysInMonth, 0, 0, 0, 0, time.UTC).Weekday()
+		day = daysInMonth - (int(last)-int(weekday)+7)%7 + 7*(n+1)
+	}
+
+	if day < 1 || day > daysInMonth {
+		return 0, false
+	}
+	return day, true
+}
+
+// parseUTCOffset parses a UTC offset such as "+0100", "-0500" or "+053000" into seconds
+func parseUTCOffset(property *contentLine) (int, error) {
+	if property == nil {
+		return 0, fmt.Errorf("missing UTC offset")
+	}
+
+	value := strings.TrimSpace(property.Value)
+	if (len(value) != 5 && len(value) != 7) || (value[0] != '+' && value[0] != '-') {
+		return 0, fmt.Errorf("invalid UTC offset %q", value)
+	}
+
+	var parts [3]int
+	for i := 0; 1+2*i < len(value); i++ {
+		n, err := strconv.Atoi(value[1+2*i : 3+2*i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid UTC offset %q", value)
+		}
+		parts[i] = n
+	}
+
+	seconds := parts[0]*3600 + parts[1]*60 + parts[2]
+	if value[0] == '-' {
+		seconds = -seconds
+	}
+	return seconds, nil
+}
+
+// parseWallClock parses a DATE or DATE-TIME value into its fields, in UTC,
+// without interpreting the time zone
+func parseWallClock(value string) (time.Time, error) {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "Z")
+
+	for _, format := range []string{"20060102T150405", "20060102"} {
+		if t, err := time.Parse(format, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse datetime: %s", value)
+}
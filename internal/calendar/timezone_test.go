@@ -0,0 +1,200 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/testutil"
+)
+
+// outlookCalendar wraps events in a calendar with the VTIMEZONE Outlook sends
+// for central European time
+func outlookCalendar(events string) string {
+	return "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VTIMEZONE\r\n" +
+		"TZID:W. Europe Standard Time\r\n" +
+		"BEGIN:STANDARD\r\n" +
+		"DTSTART:16010101T030000\r\n" +
+		"TZOFFSETFROM:+0200\r\n" +
+		"TZOFFSETTO:+0100\r\n" +
+		"RRULE:FREQ=YEARLY;INTERVAL=1;BYDAY=-1SU;BYMONTH=10\r\n" +
+		"END:STANDARD\r\n" +
+		"BEGIN:DAYLIGHT\r\n" +
+		"DTSTART:16010101T020000\r\n" +
+		"TZOFFSETFROM:+0100\r\n" +
+		"TZOFFSETTO:+0200\r\n" +
+		"RRULE:FREQ=YEARLY;INTERVAL=1;BYDAY=-1SU;BYMONTH=3\r\n" +
+		"END:DAYLIGHT\r\n" +
+		"END:VTIMEZONE\r\n" +
+		events +
+		"END:VCALENDAR\r\n"
+}
+
+func TestParseDateTimeIn(t *testing.T) {
+	testutil.SetLocalZone(t, "America/Los_Angeles")
+	calendars, err := parseComponents(strings.NewReader(outlookCalendar("")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	zones := newTimeZones(calendars)
+
+	tests := []struct {
+		name     string
+		value    string
+		tzid     string
+		expected time.Time
+	}{
+		{
+			name:     "UTC",
+			value:    "20240115T100000Z",
+			expected: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "floating time is local",
+			value:    "20240115T100000",
+			expected: time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "tz database zone",
+			value:    "20240115T100000",
+			tzid:     "Europe/Berlin",
+			expected: time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "tz database zone in summer",
+			value:    "20240715T100000",
+			tzid:     "Europe/Berlin",
+			expected: time.Date(2024, 7, 15, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "globally unique TZID",
+			value:    "20240115T100000",
+			tzid:     "/America/New_York",
+			expected: time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "VTIMEZONE in winter",
+			value:    "20240115T100000",
+			tzid:     "W. Europe Standard Time",
+			expected: time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "VTIMEZONE after the spring change",
+			value:    "20240331T120000",
+			tzid:     "W. Europe Standard Time",
+			expected: time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "VTIMEZONE before the spring change",
+			value:    "20240330T120000",
+			tzid:     "W. Europe Standard Time",
+			expected: time.Date(2024, 3, 30, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "VTIMEZONE after the autumn change",
+			value:    "20241027T120000",
+			tzid:     "W. Europe Standard Time",
+			expected: time.Date(2024, 10, 27, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "unknown zone falls back to local time",
+			value:    "20240115T100000",
+			tzid:     "Nowhere Standard Time",
+			expected: time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDateTimeIn(tt.value, tt.tzid, zones)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got.UTC())
+			}
+		})
+	}
+}
+
+func TestNthWeekday(t *testing.T) {
+	tests := []struct {
+		year     int
+		month    time.Month
+		weekday  time.Weekday
+		n        int
+		expected int
+		ok       bool
+	}{
+		{2024, time.March, time.Sunday, -1, 31, true},
+		{2024, time.October, time.Sunday, -1, 27, true},
+		{2024, time.March, time.Sunday, 2, 10, true},
+		{2024, time.November, time.Sunday, 1, 3, true},
+		{2024, time.February, time.Friday, 5, 0, false},
+	}
+
+	for _, tt := range tests {
+		day, ok := nthWeekday(tt.year, tt.month, tt.weekday, tt.n)
+		if day != tt.expected || ok != tt.ok {
+			t.Errorf("nthWeekday(%d, %s, %s, %d) = %d, %v, expected %d, %v", tt.year, tt.month, tt.weekday, tt.n, day, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestManager_ParseICalData_LocalDays(t *testing.T) {
+	losAngeles := testutil.SetLocalZone(t, "America/Los_Angeles")
+	manager := NewManager([]string{})
+
+	data := outlookCalendar(
+		// 19:00 on Jan 15 in Los Angeles is already Jan 16 in UTC
+		"BEGIN:VEVENT\r\n" +
+			"UID:evening@example.com\r\n" +
+			"DTSTART:20240116T030000Z\r\n" +
+			"SUMMARY:Evening call\r\n" +
+			"END:VEVENT\r\n" +
+			// 08:00 in Berlin is still Jan 14 in Los Angeles
+			"BEGIN:VEVENT\r\n" +
+			"UID:berlin@example.com\r\n" +
+			"DTSTART;TZID=W. Europe Standard Time:20240115T080000\r\n" +
+			"SUMMARY:Berlin standup\r\n" +
+			"END:VEVENT\r\n" +
+			"BEGIN:VEVENT\r\n" +
+			"UID:allday@example.com\r\n" +
+			"DTSTART;VALUE=DATE:20240115\r\n" +
+			"SUMMARY:Holiday\r\n" +
+			"END:VEVENT\r\n")
+
+	events, err := manager.parseICalData(strings.NewReader(data), time.Date(2024, 1, 15, 0, 0, 0, 0, losAngeles))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var summaries []string
+	for _, event := range events {
+		summaries = append(summaries, event.Summary)
+	}
+	if strings.Join(summaries, ",") != "Evening call,Holiday" {
+		t.Errorf("Expected the evening call and the holiday, got %v", summaries)
+	}
+}
+
+func TestNewTimeZones_CalendarDefaultZone(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+
+	data := "BEGIN:VCALENDAR\r\n" +
+		"X-WR-TIMEZONE:Asia/Tokyo\r\n" +
+		"END:VCALENDAR\r\n"
+	calendars, err := parseComponents(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got, err := parseDateTimeIn("20240115T100000", "", newTimeZones(calendars))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := time.Date(2024, 1, 15, 1, 0, 0, 0, time.UTC); !got.Equal(expected) {
+		t.Errorf("Expected floating time in the calendar zone %v, got %v", expected, got.UTC())
+	}
+}
@@ -35,13 +35,19 @@ func (h *System) GetHelpText() (string, error) {
 - **n**: Go to next day
 - **p**: Go to previous day
 - **h**: View history of all tasks
+- **t**: Show the selected day as a timeline of events, scheduled tasks and free time
 
 ## Task Management
-- **Enter**: Edit selected task or add new task (when on "+")
+- **Enter**: Edit selected task or add new task (when on "+"); on a calendar event, show its details
 - **Space**: Toggle task completion (☐ ↔ ☑)
 - **d**: Delete selected task
 - **Tab**: Indent task (increase hierarchy level)
 - **Shift+Tab**: Outdent task (decrease hierarchy level)
+- **c**: Collapse or expand a task's subtasks (on a subtask, collapses its parent)
+- **R**: Make the task repeat (e.g. "daily", "weekdays", "weekly on mon,thu", "monthly on 15", "every 3 days after done")
+- **P**: On a calendar event, add a "Prepare: ..." task on its day or a chosen lead time earlier (e.g. "2", "1w"); 🔗 marks events with an open preparation task
+- **u**: Undo the last task change
+- **Ctrl+R**: Redo the last undone change
 
 ## Task Reordering
 - **Shift+↑**: Move task up (within day or to previous day)
@@ -62,6 +68,22 @@ func (h *System) GetHelpText() (string, error) {
 - **Esc**: Cancel editing
 - Standard text editing (cursor movement, backspace, etc.)
 
+## Event Details
+- **o**: Open the event's meeting link
+- **1-9**: Open one of several meeting links
+- **P**: Add a task to prepare for the event
+- **Esc**: Back to the task list
+
+## Timeline
+- **↑/↓ or k/j**: Select a half-hour slot, or a task when the unscheduled list is selected
+- **n/p**: Next/previous day
+- **f**: Jump to the next free slot
+- **Enter**: On a free slot, pick an unscheduled task to schedule there; on a task block, pick it up and press Enter again at a new slot to move it
+- **Tab**: Switch between the timeline and the unscheduled tasks
+- **+/-**: Lengthen or shorten the selected task by 30 minutes
+- **x**: Unschedule the selected task
+- **Esc**: Cancel a move, or back to the task list
+
 ## Quotes
 - **r**: Refresh quote (get new random quote)
 
@@ -70,9 +92,10 @@ func (h *System) GetHelpText() (string, error) {
 
 ## Configuration
 
-The application stores data in **~/.config/personal-disorganizer/**:
+The application stores data in **~/.config/personal-disorganizer/** (or **$XDG_CONFIG_HOME**, **--config-dir**, **$PERSONAL_DISORGANIZER_CONFIG_DIR**):
 - **config.json**: Main configuration
 - **data.json**: Task and completion data
+- **backups/**: Rolling hourly backups of data.json, used automatically if it is damaged
 - **quotes/**: Optional quote files
 - **themes/**: Custom theme definitions
 
@@ -119,7 +142,8 @@ Press **q** to close help.`
 func (h *System) GetKeyboardShortcuts() string {
 	return strings.Join([]string{
 		"Navigation: ↑/↓/n/p/h",
-		"Tasks: Enter/Space/d/Tab",
+		"Tasks: Enter/Space/d/Tab/c/R",
+		"Undo: u/Ctrl+R",
 		"Reorder: Shift+↑/↓",
 		"Search: /",
 		"Quit: q",
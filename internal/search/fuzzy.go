@@ -31,7 +31,7 @@ func (e *Engine) Search(query string, tasks []storage.Task) []Result {
 	
 	var results []Result
 	query = strings.ToLower(query)
-	today := time.Now().Truncate(24 * time.Hour)
+	today := storage.StartOfDay(time.Now())
 	
 	for _, task := range tasks {
 		score := e.calculateScore(query, task.Text)
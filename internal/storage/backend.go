@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Backend names accepted in Config.Backend
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// Backend is the persistence layer used by the application
+type Backend interface {
+	// LoadData loads all application data
+	LoadData() (*AppData, error)
+	// SaveData replaces all persisted data
+	SaveData(data *AppData) error
+	// UpsertTask inserts or replaces a single task
+	UpsertTask(task Task) error
+	// DeleteTask removes a single task by ID
+	DeleteTask(taskID string) error
+	// ExternalChange reports whether another process modified the data since it was last loaded
+	ExternalChange() (bool, error)
+	// FlushExport rewrites the iCalendar export file if tasks were written since it was last rewritten
+	FlushExport()
+
+	// GetConfig returns the current configuration
+	GetConfig() *Config
+	// ConfigDir returns the directory holding config and data files
+	ConfigDir() string
+
+	// CreateTask creates a new task with a unique ID
+	CreateTask(text string, date time.Time) *Task
+	// LogError logs an error to the error log file
+	LogError(err error)
+	// RecoveredFrom reports the backup the last LoadData fell back to, if any
+	RecoveredFrom() string
+	// PurgeData deletes all application data and config files
+	PurgeData() error
+	// Close releases any resources held by the backend
+	Close() error
+}
+
+// Compile-time checks that both backends satisfy the interface
+var (
+	_ Backend = (*Storage)(nil)
+	_ Backend = (*SQLiteStorage)(nil)
+)
+
+// NewBackend creates the storage backend selected in the configuration
+func NewBackend(paths Paths) (Backend, error) {
+	s, err := NewStorageWithPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	switch s.GetConfig().Backend {
+	case "", BackendJSON:
+		return s, nil
+	case BackendSQLite:
+		return NewSQLiteStorage(s)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", s.GetConfig().Backend)
+	}
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// secretCommandTimeout bounds how long a password command may take
+const secretCommandTimeout = 10 * time.Second
+
+// CalendarSource is an entry of calendar_urls in config.json. It is either a
+// plain string with the URL or path, or an object with options:
+//
+//	{"url": "https://example.com/team.ics", "name": "Team", "color": "#50fa7b",
+//	 "auth": {"type": "bearer", "secret_env": "TEAM_CALENDAR_TOKEN"}}
+//
+// With "type": "caldav" the URL is a CalDAV server, principal or calendar
+// collection, whose calendars are discovered and read through WebDAV.
+type CalendarSource struct {
+	URL          string            `json:"url"`
+	Name         string            `json:"name,omitempty"`    // Shown as a badge on events and in the footer
+	Color        string            `json:"color,omitempty"`   // Event color: hex ("#ff79c6") or ANSI number ("205")
+	Enabled      *bool             `json:"enabled,omitempty"` // Defaults to true; false skips the calendar
+	Auth         *CalendarAuth     `json:"auth,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`       // Extra HTTP request headers
+	Type         string            `json:"type,omitempty"`          // "caldav" for CalDAV servers; iCalendar feeds otherwise
+	SyncTasks    bool              `json:"sync_tasks,omitempty"`    // Sync tasks both ways as VTODOs (CalDAV only)
+	TaskCalendar string            `json:"task_calendar,omitempty"` // Name or URL of the calendar for tasks; defaults to the first that holds tasks
+}
+
+// CalendarAuth holds credentials for a private feed. The secret is the
+// password for basic auth or the token for bearer auth. Rather than writing
+// it into the config, it can be read from an environment variable or from the
+// output of a command such as a password manager.
+type CalendarAuth struct {
+	Type          string `json:"type"` // "basic" or "bearer"
+	Username      string `json:"username,omitempty"`
+	Secret        string `json:"secret,omitempty"`
+	SecretEnv     string `json:"secret_env,omitempty"`
+	SecretCommand string `json:"secret_command,omitempty"`
+}
+
+// IsEnabled reports whether the calendar should be loaded
+func (c CalendarSource) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// IsCalDAV reports whether the calendar is read from a CalDAV server
+func (c CalendarSource) IsCalDAV() bool {
+	return strings.EqualFold(c.Type, "caldav")
+}
+
+// UnmarshalJSON accepts both a plain URL string and an object
+func (c *CalendarSource) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*c = CalendarSource{URL: url}
+		return nil
+	}
+
+	// The alias type avoids recursing into this method
+	type source CalendarSource
+	var s source
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("calendar entry must be a URL or an object: %w", err)
+	}
+	if s.URL == "" {
+		return fmt.Errorf("calendar entry without url")
+	}
+	*c = CalendarSource(s)
+	return nil
+}
+
+// MarshalJSON writes entries without options as plain strings, so configs
+// keep their simple form when saved
+func (c CalendarSource) MarshalJSON() ([]byte, error) {
+	if c.Name == "" && c.Color == "" && c.Enabled == nil && c.Auth == nil && len(c.Headers) == 0 &&
+		c.Type == "" && !c.SyncTasks && c.TaskCalendar == "" {
+		return json.Marshal(c.URL)
+	}
+	type source CalendarSource
+	return json.Marshal(source(c))
+}
+
+// ResolveSecret returns the secret from the config, the environment variable
+// or the command, in that order of preference
+func (a CalendarAuth) ResolveSecret() (string, error) {
+	switch {
+	case a.Secret != "":
+		return a.Secret, nil
+	case a.SecretEnv != "":
+		secret := os.Getenv(a.SecretEnv)
+		if secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", a.SecretEnv)
+		}
+		return secret, nil
+	case a.SecretCommand != "":
+		ctx, cancel := context.WithTimeout(context.Background(), secretCommandTimeout)
+		defer cancel()
+		shell, flag := "sh", "-c"
+		if runtime.GOOS == "windows" {
+			shell, flag = "cmd", "/C"
+		}
+		output, err := exec.CommandContext(ctx, shell, flag, a.SecretCommand).Output()
+		if err != nil {
+			return "", fmt.Errorf("secret command failed: %w", err)
+		}
+		// Password managers print a trailing newline
+		secret := strings.TrimRight(string(output), "\r\n")
+		if secret == "" {
+			return "", fmt.Errorf("secret command printed nothing")
+		}
+		return secret, nil
+	}
+	return "", fmt.Errorf("no secret configured")
+}
+
+// EventDetails holds the parts of a calendar event shown in its detail view
+type EventDetails struct {
+	Location    string   `json:"location,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Organizer   string   `json:"organizer,omitempty"`
+	Attendees   []string `json:"attendees,omitempty"`
+	MeetingURLs []string `json:"meeting_urls,omitempty"` // Video call links found in the location or description
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCalendarSource_JSON(t *testing.T) {
+	data := `{"calendar_urls": [
+		"https://example.com/plain.ics",
+		{"url": "https://example.com/team.ics", "name": "Team", "color": "#50fa7b",
+		 "auth": {"type": "bearer", "secret_env": "TEAM_TOKEN"}, "headers": {"X-Client": "pd"}},
+		{"url": "~/calendars/old", "enabled": false}
+	]}`
+
+	var config Config
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(config.CalendarURLs) != 3 {
+		t.Fatalf("Expected 3 calendars, got %d", len(config.CalendarURLs))
+	}
+
+	plain, team, old := config.CalendarURLs[0], config.CalendarURLs[1], config.CalendarURLs[2]
+	if plain.URL != "https://example.com/plain.ics" || !plain.IsEnabled() {
+		t.Errorf("Unexpected plain entry %+v", plain)
+	}
+	if team.Name != "Team" || team.Color != "#50fa7b" || team.Auth == nil || team.Auth.SecretEnv != "TEAM_TOKEN" || team.Headers["X-Client"] != "pd" {
+		t.Errorf("Unexpected team entry %+v", team)
+	}
+	if old.IsEnabled() {
+		t.Error("Expected the disabled entry to be disabled")
+	}
+
+	// Entries without options are saved in their plain form
+	saved, err := json.Marshal(config.CalendarURLs[:1])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(saved) != `["https://example.com/plain.ics"]` {
+		t.Errorf("Expected a plain string, got %s", saved)
+	}
+	saved, err = json.Marshal(team)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var reloaded CalendarSource
+	if err := json.Unmarshal(saved, &reloaded); err != nil || reloaded.Name != "Team" || reloaded.Auth.Type != "bearer" {
+		t.Errorf("Expected the entry to survive a round trip, got %+v (%v)", reloaded, err)
+	}
+
+	var dav CalendarSource
+	if err := json.Unmarshal([]byte(`{"url": "https://cloud.example.com/remote.php/dav", "type": "CalDAV", "sync_tasks": true}`), &dav); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !dav.IsCalDAV() || !dav.SyncTasks || plain.IsCalDAV() {
+		t.Errorf("Unexpected CalDAV entry %+v", dav)
+	}
+
+	for _, invalid := range []string{`{"name": "No URL"}`, `42`} {
+		var source CalendarSource
+		if err := json.Unmarshal([]byte(invalid), &source); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestCalendarAuth_ResolveSecret(t *testing.T) {
+	t.Setenv("PD_TEST_CALENDAR_TOKEN", "from-env")
+
+	tests := []struct {
+		name        string
+		auth        CalendarAuth
+		expected    string
+		expectError bool
+	}{
+		{name: "inline", auth: CalendarAuth{Secret: "inline", SecretEnv: "PD_TEST_CALENDAR_TOKEN"}, expected: "inline"},
+		{name: "environment", auth: CalendarAuth{SecretEnv: "PD_TEST_CALENDAR_TOKEN"}, expected: "from-env"},
+		{name: "unset environment", auth: CalendarAuth{SecretEnv: "PD_TEST_CALENDAR_UNSET"}, expectError: true},
+		{name: "command", auth: CalendarAuth{SecretCommand: "echo from-command"}, expected: "from-command"},
+		{name: "failing command", auth: CalendarAuth{SecretCommand: "exit 3"}, expectError: true},
+		{name: "nothing", auth: CalendarAuth{}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, err := tt.auth.ResolveSecret()
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %q", secret)
+				}
+				return
+			}
+			if err != nil || secret != tt.expected {
+				t.Errorf("Expected %q, got %q (%v)", tt.expected, secret, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"time"
+)
+
+// StartOfDay returns midnight in the local time zone of the day containing t.
+// Task dates are stored this way so that days follow the user's time zone
+// rather than UTC.
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.In(time.Local).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+// AddDays returns the start of the day n days after the day containing t.
+// Unlike adding multiples of 24 hours, it stays on midnight across
+// daylight saving time changes.
+func AddDays(t time.Time, n int) time.Time {
+	year, month, day := t.In(time.Local).Date()
+	return time.Date(year, month, day+n, 0, 0, 0, 0, time.Local)
+}
+
+// SameDay reports whether a and b fall on the same local day
+func SameDay(a, b time.Time) bool {
+	return StartOfDay(a).Equal(StartOfDay(b))
+}
+
+// DaysBetween returns the number of local days from the day containing a to
+// the day containing b, negative if b is on an earlier day
+func DaysBetween(a, b time.Time) int {
+	ay, am, ad := a.In(time.Local).Date()
+	by, bm, bd := b.In(time.Local).Date()
+	// Calendar dates in UTC are exactly 24 hours apart
+	from := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+	to := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// LastDay returns the start of the last local day touched by the span from
+// start up to, but not including, end. Spans ending at midnight stop on the
+// day before; empty spans cover only the day of start.
+func LastDay(start, end time.Time) time.Time {
+	if !end.After(start) {
+		return StartOfDay(start)
+	}
+	return StartOfDay(end.Add(-time.Nanosecond))
+}
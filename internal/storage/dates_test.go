@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/testutil"
+)
+
+func TestStartOfDay(t *testing.T) {
+	berlin := testutil.SetLocalZone(t, "Europe/Berlin")
+
+	tests := []struct {
+		name     string
+		input    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "late evening local time",
+			input:    time.Date(2024, 1, 15, 23, 30, 0, 0, berlin),
+			expected: time.Date(2024, 1, 15, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:     "UTC time on the next local day",
+			input:    time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC),
+			expected: time.Date(2024, 1, 16, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:     "day of the spring DST change",
+			input:    time.Date(2024, 3, 31, 12, 0, 0, 0, berlin),
+			expected: time.Date(2024, 3, 31, 0, 0, 0, 0, berlin),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StartOfDay(tt.input); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAddDays(t *testing.T) {
+	berlin := testutil.SetLocalZone(t, "Europe/Berlin")
+
+	// The spring DST change makes March 31 only 23 hours long
+	start := time.Date(2024, 3, 30, 0, 0, 0, 0, berlin)
+	for n, expected := range []time.Time{
+		time.Date(2024, 3, 30, 0, 0, 0, 0, berlin),
+		time.Date(2024, 3, 31, 0, 0, 0, 0, berlin),
+		time.Date(2024, 4, 1, 0, 0, 0, 0, berlin),
+	} {
+		if got := AddDays(start, n); !got.Equal(expected) {
+			t.Errorf("AddDays(%d) = %v, expected %v", n, got, expected)
+		}
+	}
+
+	if !SameDay(time.Date(2024, 4, 1, 0, 30, 0, 0, berlin), time.Date(2024, 3, 31, 23, 0, 0, 0, time.UTC)) {
+		t.Error("Expected times on the same local day to match")
+	}
+}
+
+func TestDaysBetweenAndLastDay(t *testing.T) {
+	berlin := testutil.SetLocalZone(t, "Europe/Berlin")
+
+	if got := DaysBetween(time.Date(2024, 3, 30, 23, 0, 0, 0, berlin), time.Date(2024, 4, 1, 1, 0, 0, 0, berlin)); got != 2 {
+		t.Errorf("Expected 2 days across the DST change, got %d", got)
+	}
+	if got := DaysBetween(time.Date(2024, 1, 16, 0, 0, 0, 0, berlin), time.Date(2024, 1, 15, 23, 0, 0, 0, berlin)); got != -1 {
+		t.Errorf("Expected -1 day, got %d", got)
+	}
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      time.Time
+		expected time.Time
+	}{
+		{
+			name:     "all-day event ending at midnight",
+			start:    time.Date(2024, 1, 15, 0, 0, 0, 0, berlin),
+			end:      time.Date(2024, 1, 18, 0, 0, 0, 0, berlin),
+			expected: time.Date(2024, 1, 17, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:     "overnight event",
+			start:    time.Date(2024, 1, 15, 22, 0, 0, 0, berlin),
+			end:      time.Date(2024, 1, 16, 2, 0, 0, 0, berlin),
+			expected: time.Date(2024, 1, 16, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:     "no end",
+			start:    time.Date(2024, 1, 15, 22, 0, 0, 0, berlin),
+			expected: time.Date(2024, 1, 15, 0, 0, 0, 0, berlin),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LastDay(tt.start, tt.end); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
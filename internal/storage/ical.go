@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Component types tasks are exported as, accepted in Config.ExportFormat
+const (
+	ExportTodo  = "todo"  // VTODO with a due date and completion state
+	ExportEvent = "event" // All-day VEVENT on the task's date
+)
+
+// OrderProperty keeps a task's order among its siblings, which iCalendar
+// has no property for. Other clients keep unknown properties.
+const OrderProperty = "X-PERSONAL-DISORGANIZER-ORDER"
+
+// icalUTC is the iCalendar format of a UTC date-time
+const icalUTC = "20060102T150405Z"
+
+// ICalendar renders tasks as an iCalendar object (RFC 5545), one VTODO or
+// all-day VEVENT per task depending on format. The UID of each component is
+// the task's ID, so calendar apps subscribed to the file recognise tasks
+// across updates. Calendar events and projected occurrences are left out.
+func ICalendar(tasks []Task, format string) []byte {
+	stamp := time.Now().UTC().Format(icalUTC)
+
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//personal-disorganizer//EN\r\n")
+	for _, task := range tasks {
+		if task.IsCalendar || task.Virtual {
+			continue
+		}
+		if format == ExportEvent {
+			writeEvent(&b, task, stamp)
+		} else {
+			writeTodo(&b, task, stamp)
+		}
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+	return []byte(b.String())
+}
+
+// writeTodo writes a task as a VTODO due on the task's date
+func writeTodo(b *strings.Builder, task Task, stamp string) {
+	b.WriteString("BEGIN:VTODO\r\n")
+	writeCommon(b, task, stamp, task.Text)
+	b.WriteString("DUE;VALUE=DATE:" + StartOfDay(task.Date).Format("20060102") + "\r\n")
+	if task.Done {
+		b.WriteString("STATUS:COMPLETED\r\nPERCENT-COMPLETE:100\r\n")
+	} else {
+		b.WriteString("STATUS:NEEDS-ACTION\r\n")
+	}
+	b.WriteString("END:VTODO\r\n")
+}
+
+// writeEvent writes a task as an all-day VEVENT. Events have no completion
+// state, so done tasks get a check mark in front of their text.
+func writeEvent(b *strings.Builder, task Task, stamp string) {
+	summary := task.Text
+	if task.Done {
+		summary = "✓ " + summary
+	}
+	day := StartOfDay(task.Date)
+
+	b.WriteString("BEGIN:VEVENT\r\n")
+	writeCommon(b, task, stamp, summary)
+	b.WriteString("DTSTART;VALUE=DATE:" + day.Format("20060102") + "\r\n")
+	b.WriteString("DTEND;VALUE=DATE:" + AddDays(day, 1).Format("20060102") + "\r\n")
+	b.WriteString("TRANSP:TRANSPARENT\r\n")
+	b.WriteString("END:VEVENT\r\n")
+}
+
+// writeCommon writes the properties shared by both component types
+func writeCommon(b *strings.Builder, task Task, stamp, summary string) {
+	b.WriteString(foldLine("UID:" + escapeText(task.ID)))
+	b.WriteString("DTSTAMP:" + stamp + "\r\n")
+	if !task.CreatedAt.IsZero() {
+		b.WriteString("CREATED:" + task.CreatedAt.UTC().Format(icalUTC) + "\r\n")
+	}
+	b.WriteString(foldLine("SUMMARY:" + escapeText(summary)))
+	if task.ParentID != "" {
+		b.WriteString(foldLine("RELATED-TO;RELTYPE=PARENT:" + escapeText(task.ParentID)))
+	}
+	if task.Priority != 0 {
+		b.WriteString(fmt.Sprintf("%s:%d\r\n", OrderProperty, task.Priority))
+	}
+}
+
+// escapeText applies the backslash escapes of iCalendar TEXT values
+func escapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// foldLine ends a content line with CRLF, splitting it into lines of at most
+// 75 octets continued by a leading space. UTF-8 sequences are kept whole.
+func foldLine(line string) string {
+	var b strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // The leading space counts
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+// exportICalendar writes the configured export file. A failed export is
+// logged, since the tasks themselves were saved.
+func (s *Storage) exportICalendar(tasks []Task) {
+	if s.config == nil || s.config.ExportICS == "" {
+		return
+	}
+	path, err := resolvePath(s.dataDir, s.config.ExportICS)
+	if err == nil {
+		err = WriteFileAtomic(path, ICalendar(tasks, s.config.ExportFormat), 0644)
+	}
+	if err != nil {
+		s.LogError(fmt.Errorf("failed to export tasks to %s: %w", s.config.ExportICS, err))
+	}
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/testutil"
+)
+
+func TestICalendar(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	date := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	tasks := []Task{
+		{ID: "parent", Text: "Plan trip; book, pack", Date: date, CreatedAt: created, Priority: 2},
+		{ID: "child", Text: "Book hotel", Date: date, Done: true, ParentID: "parent"},
+		{ID: "event", Text: "Standup", Date: date, IsCalendar: true},
+		{ID: "projected", Text: "Water plants", Date: date, Virtual: true},
+	}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected []string
+		absent   []string
+	}{
+		{
+			name:   "todos",
+			format: ExportTodo,
+			expected: []string{
+				"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+				"BEGIN:VTODO\r\nUID:parent\r\n",
+				"CREATED:20240301T093000Z\r\n",
+				`SUMMARY:Plan trip\; book\, pack` + "\r\n",
+				"DUE;VALUE=DATE:20240315\r\n",
+				"STATUS:NEEDS-ACTION\r\n",
+				OrderProperty + ":2\r\n",
+				"UID:child\r\n",
+				"STATUS:COMPLETED\r\nPERCENT-COMPLETE:100\r\n",
+				"RELATED-TO;RELTYPE=PARENT:parent\r\n",
+				"END:VCALENDAR\r\n",
+			},
+			absent: []string{"VEVENT", "UID:event", "UID:projected"},
+		},
+		{
+			name:   "all-day events",
+			format: ExportEvent,
+			expected: []string{
+				"BEGIN:VEVENT\r\nUID:parent\r\n",
+				"DTSTART;VALUE=DATE:20240315\r\nDTEND;VALUE=DATE:20240316\r\n",
+				"SUMMARY:✓ Book hotel\r\n",
+				"RELATED-TO;RELTYPE=PARENT:parent\r\n",
+			},
+			absent: []string{"VTODO", "STATUS:", "UID:event"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ics := string(ICalendar(tasks, tt.format))
+			for _, expected := range tt.expected {
+				if !strings.Contains(ics, expected) {
+					t.Errorf("Expected export to contain %q, got:\n%s", expected, ics)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(ics, absent) {
+					t.Errorf("Expected export not to contain %q, got:\n%s", absent, ics)
+				}
+			}
+		})
+	}
+}
+
+func TestFoldLine(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("ä", 80)
+	folded := foldLine(line)
+
+	if !strings.HasSuffix(folded, "\r\n") {
+		t.Fatalf("Expected folded line to end with CRLF, got %q", folded)
+	}
+	parts := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+	if len(parts) < 2 {
+		t.Fatalf("Expected a long line to be folded, got %q", folded)
+	}
+	for i, part := range parts {
+		if len(part) > 75 {
+			t.Errorf("Expected at most 75 octets per line, line %d has %d", i, len(part))
+		}
+		if i > 0 && !strings.HasPrefix(part, " ") {
+			t.Errorf("Expected continuation line %d to start with a space, got %q", i, part)
+		}
+	}
+
+	// Unfolding restores the line without splitting characters
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line+"\r\n" {
+		t.Errorf("Expected unfolding to restore the line, got %q", unfolded)
+	}
+}
+
+func TestStorage_ExportOnSave(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+
+	storage := &Storage{
+		configDir: tempDir,
+		dataDir:   tempDir,
+		dataPath:  filepath.Join(tempDir, "data.json"),
+		config:    &Config{ExportICS: "export/tasks.ics"},
+	}
+	if err := os.MkdirAll(filepath.Join(tempDir, "export"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	exportPath := filepath.Join(tempDir, "export", "tasks.ics")
+
+	if err := storage.SaveData(&AppData{Tasks: []Task{{ID: "a", Text: "First"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if err := storage.UpsertTask(Task{ID: "b", Text: "Second"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+
+	// Writes only mark the export as pending
+	testutil.AssertFileNotExists(t, exportPath)
+	storage.FlushExport()
+
+	ics, err := os.ReadFile(exportPath)
+	if err != nil {
+		t.Fatalf("Expected export file to be written: %v", err)
+	}
+	if !strings.Contains(string(ics), "UID:a\r\n") || !strings.Contains(string(ics), "UID:b\r\n") {
+		t.Errorf("Expected export to contain both tasks, got:\n%s", ics)
+	}
+}
+
+func TestStorage_ExportFailureDoesNotFailSave(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+
+	storage := &Storage{
+		configDir: tempDir,
+		dataDir:   tempDir,
+		dataPath:  filepath.Join(tempDir, "data.json"),
+		config:    &Config{ExportICS: "missing/dir/tasks.ics"},
+	}
+
+	if err := storage.SaveData(&AppData{Tasks: []Task{{ID: "a", Text: "First"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	storage.FlushExport()
+
+	logData, err := os.ReadFile(filepath.Join(tempDir, "error.log"))
+	if err != nil || !strings.Contains(string(logData), "failed to export tasks") {
+		t.Errorf("Expected the failed export to be logged, got %q (%v)", logData, err)
+	}
+}
+
+func TestSQLiteStorage_ExportOnTaskWrites(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	s := newTestSQLiteStorage(t, tempDir)
+	s.config = &Config{ExportICS: filepath.Join(tempDir, "tasks.ics")}
+	exportPath := filepath.Join(tempDir, "tasks.ics")
+
+	if err := s.SaveData(&AppData{Tasks: []Task{{ID: "a", Text: "First"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if err := s.UpsertTask(Task{ID: "b", Text: "Second"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	if err := s.DeleteTask("a"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+
+	// The export is written once, when the storage is closed
+	testutil.AssertFileNotExists(t, exportPath)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	ics, err := os.ReadFile(exportPath)
+	if err != nil {
+		t.Fatalf("Expected export file to be written: %v", err)
+	}
+	if strings.Contains(string(ics), "UID:a\r\n") || !strings.Contains(string(ics), "UID:b\r\n") {
+		t.Errorf("Expected export to contain only task b, got:\n%s", ics)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package storage
+
+// fileLock is a no-op on platforms without flock
+type fileLock struct{}
+
+// lockFile does nothing on platforms without flock; atomic renames still
+// prevent torn files, but concurrent writers are not serialised
+func lockFile(path string) (*fileLock, error) {
+	return &fileLock{}, nil
+}
+
+// unlock releases the lock
+func (l *fileLock) unlock() {}
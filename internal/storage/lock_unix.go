@@ -0,0 +1,35 @@
+//go:build unix
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileLock is an advisory lock shared by all instances using the same data file
+type fileLock struct {
+	file *os.File
+}
+
+// lockFile takes an exclusive advisory lock on path, blocking until it is available
+func lockFile(path string) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock data file: %w", err)
+	}
+
+	return &fileLock{file: file}, nil
+}
+
+// unlock releases the lock
+func (l *fileLock) unlock() {
+	syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	l.file.Close()
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"encoding/json"
+)
+
+// MergeTasks performs a three-way merge by task ID. base is the task list as
+// last synchronised with disk, local is the in-memory list and remote is what
+// another instance wrote. Changes on either side are kept; when both sides
+// changed the same task the local version wins. Tasks deleted on one side stay
+// deleted unless the other side modified them. changed reports whether the
+// result differs from remote, i.e. whether it needs to be written back.
+func MergeTasks(base, local, remote []Task) (merged []Task, changed bool) {
+	baseByID := indexTasks(base)
+	localByID := indexTasks(local)
+	remoteByID := indexTasks(remote)
+
+	merged = make([]Task, 0, len(remote)+len(local))
+
+	// Keep the remote order, which is what is on disk
+	for _, remoteTask := range remote {
+		baseTask, inBase := baseByID[remoteTask.ID]
+		localTask, inLocal := localByID[remoteTask.ID]
+
+		switch {
+		case inLocal:
+			if inBase && tasksEqual(localTask, baseTask) {
+				merged = append(merged, remoteTask)
+			} else {
+				merged = append(merged, localTask)
+				changed = changed || !tasksEqual(localTask, remoteTask)
+			}
+		case !inBase:
+			// Created by the other instance
+			merged = append(merged, remoteTask)
+		case !tasksEqual(remoteTask, baseTask):
+			// Deleted here but modified there; keep the modification
+			merged = append(merged, remoteTask)
+		default:
+			// Deleted here
+			changed = true
+		}
+	}
+
+	// Tasks that only exist locally
+	for _, localTask := range local {
+		if _, inRemote := remoteByID[localTask.ID]; inRemote {
+			continue
+		}
+
+		baseTask, inBase := baseByID[localTask.ID]
+		if !inBase || !tasksEqual(localTask, baseTask) {
+			// Created here, or deleted there but modified here
+			merged = append(merged, localTask)
+			changed = true
+		}
+	}
+
+	return merged, changed
+}
+
+// ChangedTaskIDs returns the IDs of tasks that were created, modified or
+// deleted going from one task list to another
+func ChangedTaskIDs(before, after []Task) []string {
+	beforeByID := indexTasks(before)
+	afterByID := indexTasks(after)
+
+	var changed []string
+	for _, task := range after {
+		if old, existed := beforeByID[task.ID]; !existed || !tasksEqual(old, task) {
+			changed = append(changed, task.ID)
+		}
+	}
+	for _, task := range before {
+		if _, exists := afterByID[task.ID]; !exists {
+			changed = append(changed, task.ID)
+		}
+	}
+	return changed
+}
+
+// removeTask returns tasks without the task with the given ID
+func removeTask(tasks []Task, taskID string) []Task {
+	for i := range tasks {
+		if tasks[i].ID == taskID {
+			return append(tasks[:i:i], tasks[i+1:]...)
+		}
+	}
+	return tasks
+}
+
+// indexTasks maps tasks by ID
+func indexTasks(tasks []Task) map[string]Task {
+	byID := make(map[string]Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	return byID
+}
+
+// tasksEqual compares tasks by their persisted form, so that values which
+// went through a JSON round trip compare equal to the in-memory originals
+func tasksEqual(a, b Task) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return string(aJSON) == string(bJSON)
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeTasks(t *testing.T) {
+	a := Task{ID: "a", Text: "A"}
+	b := Task{ID: "b", Text: "B"}
+	c := Task{ID: "c", Text: "C"}
+	aDone := Task{ID: "a", Text: "A", Done: true}
+	aEdited := Task{ID: "a", Text: "A (edited)"}
+
+	tests := []struct {
+		name          string
+		base          []Task
+		local         []Task
+		remote        []Task
+		expected      []Task
+		expectChanged bool
+	}{
+		{
+			name:     "no changes",
+			base:     []Task{a, b},
+			local:    []Task{a, b},
+			remote:   []Task{a, b},
+			expected: []Task{a, b},
+		},
+		{
+			name:     "remote added a task",
+			base:     []Task{a},
+			local:    []Task{a},
+			remote:   []Task{a, b},
+			expected: []Task{a, b},
+		},
+		{
+			name:          "local added a task",
+			base:          []Task{a},
+			local:         []Task{a, c},
+			remote:        []Task{a},
+			expected:      []Task{a, c},
+			expectChanged: true,
+		},
+		{
+			name:     "remote modified a task",
+			base:     []Task{a, b},
+			local:    []Task{a, b},
+			remote:   []Task{aDone, b},
+			expected: []Task{aDone, b},
+		},
+		{
+			name:          "local modified a task",
+			base:          []Task{a, b},
+			local:         []Task{aDone, b},
+			remote:        []Task{a, b},
+			expected:      []Task{aDone, b},
+			expectChanged: true,
+		},
+		{
+			name:          "both modified the same task, local wins",
+			base:          []Task{a},
+			local:         []Task{aEdited},
+			remote:        []Task{aDone},
+			expected:      []Task{aEdited},
+			expectChanged: true,
+		},
+		{
+			name:     "remote deleted an unmodified task",
+			base:     []Task{a, b},
+			local:    []Task{a, b},
+			remote:   []Task{b},
+			expected: []Task{b},
+		},
+		{
+			name:          "local deleted an unmodified task",
+			base:          []Task{a, b},
+			local:         []Task{b},
+			remote:        []Task{a, b},
+			expected:      []Task{b},
+			expectChanged: true,
+		},
+		{
+			name:          "remote deleted a locally modified task",
+			base:          []Task{a, b},
+			local:         []Task{aDone, b},
+			remote:        []Task{b},
+			expected:      []Task{b, aDone},
+			expectChanged: true,
+		},
+		{
+			name:     "local deleted a remotely modified task",
+			base:     []Task{a, b},
+			local:    []Task{b},
+			remote:   []Task{aDone, b},
+			expected: []Task{aDone, b},
+		},
+		{
+			name:     "both sides changed different tasks",
+			base:     []Task{a, b},
+			local:    []Task{aDone, b, c},
+			remote:   []Task{a},
+			expected: []Task{aDone, c},
+			// b was deleted remotely, a and c are local changes
+			expectChanged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, changed := MergeTasks(tt.base, tt.local, tt.remote)
+
+			if changed != tt.expectChanged {
+				t.Errorf("Expected changed=%v, got %v", tt.expectChanged, changed)
+			}
+
+			if len(merged) != len(tt.expected) {
+				t.Fatalf("Expected %d tasks, got %d: %+v", len(tt.expected), len(merged), merged)
+			}
+			for i := range tt.expected {
+				if !tasksEqual(merged[i], tt.expected[i]) {
+					t.Errorf("Task %d: expected %+v, got %+v", i, tt.expected[i], merged[i])
+				}
+			}
+		})
+	}
+}
+
+func TestChangedTaskIDs(t *testing.T) {
+	a := Task{ID: "a", Text: "A"}
+	b := Task{ID: "b", Text: "B"}
+	c := Task{ID: "c", Text: "C"}
+	bDone := Task{ID: "b", Text: "B", Done: true}
+
+	changed := ChangedTaskIDs([]Task{a, b}, []Task{a, bDone, c})
+	if strings.Join(changed, ",") != "b,c" {
+		t.Errorf("Expected the modified and created tasks, got %v", changed)
+	}
+
+	changed = ChangedTaskIDs([]Task{a, b}, []Task{b})
+	if strings.Join(changed, ",") != "a" {
+		t.Errorf("Expected the deleted task, got %v", changed)
+	}
+
+	if changed = ChangedTaskIDs([]Task{a, b}, []Task{b, a}); len(changed) != 0 {
+		t.Errorf("Expected reordering alone to change nothing, got %v", changed)
+	}
+}
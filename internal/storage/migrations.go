@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CurrentSchemaVersion is the data schema version written by this binary
+const CurrentSchemaVersion = 8
+
+// ErrNewerSchema is returned when a data file was written by a newer binary
+var ErrNewerSchema = errors.New("data file was written by a newer version of personal-disorganizer")
+
+// migration upgrades raw data from one schema version to the next
+type migration struct {
+	description string
+	apply       func(raw map[string]interface{}) error
+}
+
+// migrations maps a schema version to the step that upgrades it to version+1.
+// Steps that only add optional task fields have nothing to convert; they exist
+// because the version bump stops older binaries, which would drop the new
+// fields, from writing the file.
+var migrations = map[int]migration{
+	0: {
+		description: "add schema version and normalise empty task lists",
+		apply:       migrateV0ToV1,
+	},
+	1: {
+		description: "add task recurrence rules",
+		apply:       migrateV1ToV2,
+	},
+	2: {
+		description: "add rollover bookkeeping to tasks",
+		apply:       migrateV2ToV3,
+	},
+	3: {
+		description: "replace task levels with explicit parent IDs",
+		apply:       migrateV3ToV4,
+	},
+	4: {
+		description: "add collapsed state to tasks",
+		apply:       migrateV4ToV5,
+	},
+	5: {
+		description: "store task dates as local midnight",
+		apply:       migrateV5ToV6,
+	},
+	6: {
+		description: "link preparation tasks to calendar events",
+		apply:       migrateV6ToV7,
+	},
+	7: {
+		description: "add scheduled start and duration to tasks",
+		apply:       migrateV7ToV8,
+	},
+}
+
+// migrateV0ToV1 upgrades files written before the schema was versioned
+func migrateV0ToV1(raw map[string]interface{}) error {
+	if tasks, ok := raw["tasks"]; !ok || tasks == nil {
+		raw["tasks"] = []interface{}{}
+	}
+	return nil
+}
+
+// migrateV1ToV2 adds optional task recurrence rules
+func migrateV1ToV2(raw map[string]interface{}) error {
+	return nil
+}
+
+// migrateV2ToV3 adds the optional overdue_since and defer_count task fields
+func migrateV2ToV3(raw map[string]interface{}) error {
+	return nil
+}
+
+// migrateV3ToV4 turns the implicit hierarchy, where a task's parent was the
+// closest preceding task of a lower level in priority order, into explicit
+// parent IDs. Levels that skipped a step become children of the closest
+// shallower task. Priorities are renumbered to order tasks among their siblings.
+func migrateV3ToV4(raw map[string]interface{}) error {
+	rawTasks, _ := raw["tasks"].([]interface{})
+
+	type entry struct {
+		task     map[string]interface{}
+		priority float64
+		level    float64
+	}
+
+	// Group regular tasks by day
+	days := make(map[time.Time][]entry)
+	for i, value := range rawTasks {
+		task, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("task %d is not an object", i)
+		}
+		level, _ := task["level"].(float64)
+		delete(task, "level")
+
+		if isCalendar, _ := task["is_calendar"].(bool); isCalendar {
+			continue
+		}
+
+		var day time.Time
+		if date, ok := task["date"].(string); ok {
+			parsed, err := time.Parse(time.RFC3339Nano, date)
+			if err != nil {
+				return fmt.Errorf("task %d has an invalid date: %w", i, err)
+			}
+			day = parsed.Truncate(24 * time.Hour)
+		}
+
+		priority, _ := task["priority"].(float64)
+		days[day] = append(days[day], entry{task: task, priority: priority, level: level})
+	}
+
+	for _, entries := range days {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].priority > entries[j].priority
+		})
+
+		// Walk the day in display order, keeping the chain of open ancestors
+		var ancestors []entry
+		siblings := make(map[string][]map[string]interface{})
+		for _, e := range entries {
+			for len(ancestors) > 0 && ancestors[len(ancestors)-1].level >= e.level {
+				ancestors = ancestors[:len(ancestors)-1]
+			}
+
+			parentID := ""
+			if len(ancestors) > 0 {
+				parentID, _ = ancestors[len(ancestors)-1].task["id"].(string)
+				e.task["parent_id"] = parentID
+			}
+			siblings[parentID] = append(siblings[parentID], e.task)
+			ancestors = append(ancestors, e)
+		}
+
+		for _, group := range siblings {
+			for i, task := range group {
+				task["priority"] = len(group) - i
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrateV4ToV5 adds the optional collapsed task flag
+func migrateV4ToV5(raw map[string]interface{}) error {
+	return nil
+}
+
+// migrateV5ToV6 moves task dates from midnight UTC to midnight in the local
+// time zone of the same calendar day. Days used to be bucketed in UTC, which put
+// tasks on the previous day for users west of Greenwich.
+func migrateV5ToV6(raw map[string]interface{}) error {
+	rawTasks, _ := raw["tasks"].([]interface{})
+
+	for i, value := range rawTasks {
+		task, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("task %d is not an object", i)
+		}
+
+		for _, field := range []string{"date", "overdue_since"} {
+			date, ok := task[field].(string)
+			if !ok {
+				continue
+			}
+			parsed, err := time.Parse(time.RFC3339Nano, date)
+			if err != nil {
+				return fmt.Errorf("task %d has an invalid %s: %w", i, field, err)
+			}
+
+			// Only whole UTC days were written by the old bucketing
+			utc := parsed.UTC()
+			if utc.IsZero() || !utc.Equal(utc.Truncate(24*time.Hour)) {
+				continue
+			}
+			year, month, day := utc.Date()
+			task[field] = time.Date(year, month, day, 0, 0, 0, 0, time.Local).Format(time.RFC3339Nano)
+		}
+	}
+
+	return nil
+}
+
+// migrateV6ToV7 adds the optional event_id of preparation tasks
+func migrateV6ToV7(raw map[string]interface{}) error {
+	return nil
+}
+
+// migrateV7ToV8 adds the optional scheduled_at and duration_minutes task fields
+func migrateV7ToV8(raw map[string]interface{}) error {
+	return nil
+}
+
+// schemaVersion reads the version field from raw data (files without one are version 0)
+func schemaVersion(raw map[string]interface{}) (int, error) {
+	value, ok := raw["version"]
+	if !ok || value == nil {
+		return 0, nil
+	}
+
+	number, ok := value.(float64)
+	if !ok || number != float64(int(number)) || number < 0 {
+		return 0, fmt.Errorf("invalid schema version: %v", value)
+	}
+	return int(number), nil
+}
+
+// migrateData upgrades raw data step by step to CurrentSchemaVersion
+func migrateData(raw map[string]interface{}, from int) error {
+	for version := from; version < CurrentSchemaVersion; version++ {
+		step, ok := migrations[version]
+		if !ok {
+			return fmt.Errorf("no migration registered from schema version %d", version)
+		}
+		if err := step.apply(raw); err != nil {
+			return fmt.Errorf("migration from schema version %d (%s) failed: %w", version, step.description, err)
+		}
+		raw["version"] = version + 1
+	}
+	return nil
+}
+
+// decodeData parses data file contents, migrating older schemas in memory.
+// It returns the schema version the contents were written with.
+func decodeData(contents []byte) (*AppData, int, error) {
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(contents, &raw); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse data file: %w", err)
+	}
+
+	version, err := schemaVersion(raw)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse data file: %w", err)
+	}
+	if version > CurrentSchemaVersion {
+		return nil, version, fmt.Errorf("%w (schema version %d, this binary supports up to %d)", ErrNewerSchema, version, CurrentSchemaVersion)
+	}
+
+	if version < CurrentSchemaVersion {
+		if err := migrateData(raw, version); err != nil {
+			return nil, version, err
+		}
+		if contents, err = json.Marshal(raw); err != nil {
+			return nil, version, fmt.Errorf("failed to encode migrated data: %w", err)
+		}
+	}
+
+	appData := &AppData{}
+	if err := json.Unmarshal(contents, appData); err != nil {
+		return nil, version, fmt.Errorf("failed to parse data file: %w", err)
+	}
+
+	return appData, version, nil
+}
@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/testutil"
+)
+
+func TestSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         map[string]interface{}
+		expected    int
+		expectError bool
+	}{
+		{
+			name:     "missing version is version 0",
+			raw:      map[string]interface{}{},
+			expected: 0,
+		},
+		{
+			name:     "explicit version",
+			raw:      map[string]interface{}{"version": float64(1)},
+			expected: 1,
+		},
+		{
+			name:        "fractional version",
+			raw:         map[string]interface{}{"version": 1.5},
+			expectError: true,
+		},
+		{
+			name:        "string version",
+			raw:         map[string]interface{}{"version": "1"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := schemaVersion(tt.raw)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if version != tt.expected {
+				t.Errorf("Expected version %d, got %d", tt.expected, version)
+			}
+		})
+	}
+}
+
+func TestMigrations_Registry(t *testing.T) {
+	// Every version below the current one needs a step to the next
+	for version := 0; version < CurrentSchemaVersion; version++ {
+		if _, ok := migrations[version]; !ok {
+			t.Errorf("Missing migration from schema version %d", version)
+		}
+	}
+}
+
+func TestDecodeData(t *testing.T) {
+	tests := []struct {
+		name            string
+		contents        string
+		expectedVersion int
+		expectedTasks   int
+		expectNewer     bool
+		expectError     bool
+	}{
+		{
+			name:            "unversioned file is migrated",
+			contents:        `{"tasks": [{"id": "a", "text": "Task"}], "settings": {}}`,
+			expectedVersion: 0,
+			expectedTasks:   1,
+		},
+		{
+			name:            "unversioned file with null tasks",
+			contents:        `{"tasks": null}`,
+			expectedVersion: 0,
+			expectedTasks:   0,
+		},
+		{
+			name:            "current version loads unchanged",
+			contents:        `{"version": 1, "tasks": [{"id": "a"}, {"id": "b"}]}`,
+			expectedVersion: 1,
+			expectedTasks:   2,
+		},
+		{
+			name:        "newer version is refused",
+			contents:    `{"version": 999, "tasks": []}`,
+			expectNewer: true,
+			expectError: true,
+		},
+		{
+			name:        "invalid json",
+			contents:    `not json`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, version, err := decodeData([]byte(tt.contents))
+
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected error but got none")
+				}
+				if errors.Is(err, ErrNewerSchema) != tt.expectNewer {
+					t.Errorf("Expected ErrNewerSchema=%v, got %v", tt.expectNewer, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if version != tt.expectedVersion {
+				t.Errorf("Expected source version %d, got %d", tt.expectedVersion, version)
+			}
+			if data.Version != CurrentSchemaVersion {
+				t.Errorf("Expected migrated version %d, got %d", CurrentSchemaVersion, data.Version)
+			}
+			if data.Tasks == nil {
+				t.Error("Tasks should never be nil after decoding")
+			}
+			if len(data.Tasks) != tt.expectedTasks {
+				t.Errorf("Expected %d tasks, got %d", tt.expectedTasks, len(data.Tasks))
+			}
+		})
+	}
+}
+
+func TestStorage_LoadDataMigratesOnDisk(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	dataPath := filepath.Join(tempDir, "data.json")
+
+	original := []byte(`{"tasks": [{"id": "legacy", "text": "Legacy task"}], "settings": {}}`)
+	if err := os.WriteFile(dataPath, original, 0644); err != nil {
+		t.Fatalf("Failed to write legacy data: %v", err)
+	}
+
+	storage := &Storage{
+		configDir: tempDir,
+		dataPath:  dataPath,
+	}
+
+	data, err := storage.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(data.Tasks) != 1 || data.Tasks[0].ID != "legacy" {
+		t.Errorf("Expected legacy task, got %+v", data.Tasks)
+	}
+
+	// The file on disk is upgraded
+	onDisk, err := os.ReadFile(dataPath)
+	if err != nil {
+		t.Fatalf("Failed to read data file: %v", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(onDisk, &raw); err != nil {
+		t.Fatalf("Failed to parse data file: %v", err)
+	}
+	if raw["version"] != float64(CurrentSchemaVersion) {
+		t.Errorf("Expected version %d on disk, got %v", CurrentSchemaVersion, raw["version"])
+	}
+
+	// A byte-for-byte pre-migration copy is kept
+	entries, err := os.ReadDir(filepath.Join(tempDir, "backups"))
+	if err != nil {
+		t.Fatalf("Failed to read backup dir: %v", err)
+	}
+	found := false
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "data-pre-migration-v0-") {
+			found = true
+			copied, err := os.ReadFile(filepath.Join(tempDir, "backups", entry.Name()))
+			if err != nil {
+				t.Fatalf("Failed to read pre-migration copy: %v", err)
+			}
+			if string(copied) != string(original) {
+				t.Error("Pre-migration copy does not match the original file")
+			}
+		}
+	}
+	if !found {
+		t.Error("Expected a pre-migration copy in the backup directory")
+	}
+}
+
+func TestStorage_ReadDataLeavesFilesAlone(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	dataPath := filepath.Join(tempDir, "data.json")
+
+	original := []byte(`{"tasks": [{"id": "legacy", "text": "Legacy task"}], "settings": {}}`)
+	if err := os.WriteFile(dataPath, original, 0644); err != nil {
+		t.Fatalf("Failed to write legacy data: %v", err)
+	}
+
+	storage := &Storage{
+		configDir: tempDir,
+		dataPath:  dataPath,
+		config:    &Config{ExportICS: filepath.Join(tempDir, "tasks.ics")},
+	}
+
+	data, err := storage.ReadData()
+	if err != nil {
+		t.Fatalf("ReadData() error = %v", err)
+	}
+	if len(data.Tasks) != 1 || data.Tasks[0].ID != "legacy" {
+		t.Errorf("Expected legacy task, got %+v", data.Tasks)
+	}
+	storage.Close()
+
+	// Migrated in memory only
+	onDisk, err := os.ReadFile(dataPath)
+	if err != nil {
+		t.Fatalf("Failed to read data file: %v", err)
+	}
+	if string(onDisk) != string(original) {
+		t.Error("Expected the data file to be unchanged")
+	}
+	testutil.AssertFileNotExists(t, filepath.Join(tempDir, "backups"))
+	testutil.AssertFileNotExists(t, filepath.Join(tempDir, "tasks.ics"))
+}
+
+func TestStorage_LoadDataRefusesNewerSchema(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	dataPath := filepath.Join(tempDir, "data.json")
+
+	storage := &Storage{
+		configDir: tempDir,
+		dataPath:  dataPath,
+	}
+
+	// A valid backup must not be used to paper over a newer data file
+	if err := storage.SaveData(&AppData{Tasks: []Task{{ID: "old"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if err := storage.SaveData(&AppData{Tasks: []Task{{ID: "old"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+
+	newer := []byte(`{"version": 999, "tasks": []}`)
+	if err := os.WriteFile(dataPath, newer, 0644); err != nil {
+		t.Fatalf("Failed to write newer data: %v", err)
+	}
+
+	_, err := storage.LoadData()
+	if !errors.Is(err, ErrNewerSchema) {
+		t.Fatalf("Expected ErrNewerSchema, got %v", err)
+	}
+
+	// The newer file is left untouched
+	onDisk, _ := os.ReadFile(dataPath)
+	if string(onDisk) != string(newer) {
+		t.Error("Newer data file should not be modified")
+	}
+}
+
+func TestMigrateV3ToV4(t *testing.T) {
+	contents := `{"version": 3, "tasks": [
+		{"id": "sub", "date": "2024-01-15T00:00:00Z", "priority": 4, "level": 1},
+		{"id": "root", "date": "2024-01-15T00:00:00Z", "priority": 5, "level": 0},
+		{"id": "deep", "date": "2024-01-15T00:00:00Z", "priority": 3, "level": 3},
+		{"id": "second", "date": "2024-01-15T00:00:00Z", "priority": 1, "level": 0},
+		{"id": "other-day", "date": "2024-01-16T00:00:00Z", "priority": 9, "level": 2},
+		{"id": "event", "date": "2024-01-15T00:00:00Z", "priority": -1, "is_calendar": true}
+	]}`
+
+	data, version, err := decodeData([]byte(contents))
+	if err != nil {
+		t.Fatalf("decodeData() error = %v", err)
+	}
+	if version != 3 {
+		t.Errorf("Expected source version 3, got %d", version)
+	}
+
+	byID := map[string]Task{}
+	for _, task := range data.Tasks {
+		byID[task.ID] = task
+	}
+
+	expectedParents := map[string]string{
+		"root":      "",
+		"sub":       "root",
+		"deep":      "sub", // Skipped levels attach to the closest shallower task
+		"second":    "",
+		"other-day": "", // Nothing above it on its own day
+		"event":     "",
+	}
+	for id, parentID := range expectedParents {
+		if byID[id].ParentID != parentID {
+			t.Errorf("Task %s: expected parent %q, got %q", id, parentID, byID[id].ParentID)
+		}
+	}
+
+	// Siblings keep their relative order
+	if byID["root"].Priority <= byID["second"].Priority {
+		t.Errorf("Expected root before second, got priorities %d and %d", byID["root"].Priority, byID["second"].Priority)
+	}
+
+	var order []string
+	for _, task := range FlattenTree([]Task{byID["root"], byID["sub"], byID["deep"], byID["second"]}) {
+		order = append(order, task.ID)
+	}
+	if strings.Join(order, ",") != "root,sub,deep,second" {
+		t.Errorf("Expected display order root,sub,deep,second, got %v", order)
+	}
+}
+
+func TestMigrateV5ToV6(t *testing.T) {
+	newYork := testutil.SetLocalZone(t, "America/New_York")
+
+	contents := `{"version": 5, "tasks": [
+		{"id": "a", "date": "2024-01-15T00:00:00Z", "overdue_since": "2024-01-10T00:00:00Z"},
+		{"id": "b", "date": "2024-01-15T09:30:00Z"}
+	]}`
+
+	data, _, err := decodeData([]byte(contents))
+	if err != nil {
+		t.Fatalf("decodeData() error = %v", err)
+	}
+
+	// Midnight UTC becomes local midnight of the same calendar day
+	if expected := time.Date(2024, 1, 15, 0, 0, 0, 0, newYork); !data.Tasks[0].Date.Equal(expected) {
+		t.Errorf("Expected date %v, got %v", expected, data.Tasks[0].Date)
+	}
+	if expected := time.Date(2024, 1, 10, 0, 0, 0, 0, newYork); !data.Tasks[0].OverdueSince.Equal(expected) {
+		t.Errorf("Expected overdue_since %v, got %v", expected, data.Tasks[0].OverdueSince)
+	}
+
+	// Other times are left alone
+	if expected := time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC); !data.Tasks[1].Date.Equal(expected) {
+		t.Errorf("Expected date %v to be unchanged, got %v", expected, data.Tasks[1].Date)
+	}
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// appDirName is the directory name used below the XDG base directories
+const appDirName = "personal-disorganizer"
+
+// ConfigDirEnv overrides the config directory, like the --config-dir flag
+const ConfigDirEnv = "PERSONAL_DISORGANIZER_CONFIG_DIR"
+
+// Paths holds the directories used for configuration and data
+type Paths struct {
+	ConfigDir string // config.json, themes, quotes, logs and backups
+	DataDir   string // Base directory for a relative Config.DataFile
+}
+
+// ResolvePaths determines where config and data live. An explicit configDir
+// (the --config-dir flag) wins over $PERSONAL_DISORGANIZER_CONFIG_DIR, and both
+// keep config and data together in that directory. Otherwise the XDG base
+// directories are used: $XDG_CONFIG_HOME (default ~/.config) for config and
+// $XDG_DATA_HOME for data. Without $XDG_DATA_HOME data stays next to the config,
+// which is where earlier versions kept it; a data file found there keeps being
+// used until it is moved (see legacyDataFile).
+func ResolvePaths(configDir string) (Paths, error) {
+	if configDir == "" {
+		configDir = os.Getenv(ConfigDirEnv)
+	}
+	if configDir != "" {
+		dir, err := expandHome(configDir)
+		if err != nil {
+			return Paths{}, err
+		}
+		return Paths{ConfigDir: dir, DataDir: dir}, nil
+	}
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" || !filepath.IsAbs(configHome) {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return Paths{}, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		configHome = filepath.Join(homeDir, ".config")
+	}
+
+	paths := Paths{
+		ConfigDir: filepath.Join(configHome, appDirName),
+	}
+	paths.DataDir = paths.ConfigDir
+
+	// The XDG spec says relative base directories must be ignored
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" && filepath.IsAbs(dataHome) {
+		paths.DataDir = filepath.Join(dataHome, appDirName)
+	}
+
+	return paths, nil
+}
+
+// resolveDataFile turns Config.DataFile into an absolute path
+func resolveDataFile(dataDir, dataFile string) (string, error) {
+	if dataFile == "" {
+		dataFile = "data.json"
+	}
+	return resolvePath(dataDir, dataFile)
+}
+
+// legacyDataFile returns where earlier versions, which kept data next to the
+// config, would find the data file, if it exists there while nothing was
+// written to the data directory yet. This keeps existing tasks when
+// $XDG_DATA_HOME starts pointing somewhere else.
+func legacyDataFile(paths Paths, dataPath, dataFile string) (string, bool) {
+	if paths.DataDir == paths.ConfigDir || fileExists(dataPath) || fileExists(sqlitePath(dataPath)) {
+		return "", false
+	}
+
+	legacyPath, err := resolveDataFile(paths.ConfigDir, dataFile)
+	if err != nil || legacyPath == dataPath {
+		return "", false
+	}
+	if !fileExists(legacyPath) && !fileExists(sqlitePath(legacyPath)) {
+		return "", false
+	}
+	return legacyPath, true
+}
+
+// fileExists reports whether a file exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// resolvePath turns a configured file path into an absolute path, relative
+// paths being taken from the data directory
+func resolvePath(dataDir, path string) (string, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return "", err
+	}
+	if filepath.IsAbs(path) {
+		return path, nil
+	}
+
+	return filepath.Join(dataDir, path), nil
+}
+
+// expandHome expands a leading ~ to the user's home directory
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"personal-disorganizer/internal/testutil"
+)
+
+func TestResolvePaths(t *testing.T) {
+	home := testutil.TempDir(t)
+
+	tests := []struct {
+		name              string
+		flag              string
+		env               map[string]string
+		expectedConfigDir string
+		expectedDataDir   string
+	}{
+		{
+			name:              "defaults to ~/.config",
+			expectedConfigDir: filepath.Join(home, ".config", appDirName),
+			expectedDataDir:   filepath.Join(home, ".config", appDirName),
+		},
+		{
+			name:              "XDG config home",
+			env:               map[string]string{"XDG_CONFIG_HOME": "/xdg/config"},
+			expectedConfigDir: filepath.Join("/xdg/config", appDirName),
+			expectedDataDir:   filepath.Join("/xdg/config", appDirName),
+		},
+		{
+			name:              "XDG data home",
+			env:               map[string]string{"XDG_CONFIG_HOME": "/xdg/config", "XDG_DATA_HOME": "/xdg/data"},
+			expectedConfigDir: filepath.Join("/xdg/config", appDirName),
+			expectedDataDir:   filepath.Join("/xdg/data", appDirName),
+		},
+		{
+			name:              "relative XDG directories are ignored",
+			env:               map[string]string{"XDG_CONFIG_HOME": "relative", "XDG_DATA_HOME": "relative"},
+			expectedConfigDir: filepath.Join(home, ".config", appDirName),
+			expectedDataDir:   filepath.Join(home, ".config", appDirName),
+		},
+		{
+			name:              "env override wins over XDG",
+			env:               map[string]string{ConfigDirEnv: "/override", "XDG_DATA_HOME": "/xdg/data"},
+			expectedConfigDir: "/override",
+			expectedDataDir:   "/override",
+		},
+		{
+			name:              "flag wins over env",
+			flag:              "/flag",
+			env:               map[string]string{ConfigDirEnv: "/override"},
+			expectedConfigDir: "/flag",
+			expectedDataDir:   "/flag",
+		},
+		{
+			name:              "flag expands home",
+			flag:              "~/work",
+			expectedConfigDir: filepath.Join(home, "work"),
+			expectedDataDir:   filepath.Join(home, "work"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", "")
+			t.Setenv("XDG_DATA_HOME", "")
+			t.Setenv(ConfigDirEnv, "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			paths, err := ResolvePaths(tt.flag)
+			if err != nil {
+				t.Fatalf("ResolvePaths() error = %v", err)
+			}
+
+			if paths.ConfigDir != tt.expectedConfigDir {
+				t.Errorf("Expected config dir %s, got %s", tt.expectedConfigDir, paths.ConfigDir)
+			}
+			if paths.DataDir != tt.expectedDataDir {
+				t.Errorf("Expected data dir %s, got %s", tt.expectedDataDir, paths.DataDir)
+			}
+		})
+	}
+}
+
+func TestResolveDataFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataFile string
+		expected string
+	}{
+		{name: "empty uses default", dataFile: "", expected: "/data/data.json"},
+		{name: "relative to data dir", dataFile: "work.json", expected: "/data/work.json"},
+		{name: "relative subdirectory", dataFile: "sets/personal.json", expected: "/data/sets/personal.json"},
+		{name: "absolute path", dataFile: "/elsewhere/tasks.json", expected: "/elsewhere/tasks.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := resolveDataFile("/data", tt.dataFile)
+			if err != nil {
+				t.Fatalf("resolveDataFile() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestNewStorageWithPaths_UsesDataFile(t *testing.T) {
+	configDir := testutil.TempDir(t)
+	dataDir := testutil.TempDir(t)
+
+	testutil.CreateTestConfig(t, configDir, &Config{DataFile: "work/tasks.json"})
+
+	storage, err := NewStorageWithPaths(Paths{ConfigDir: configDir, DataDir: dataDir})
+	if err != nil {
+		t.Fatalf("NewStorageWithPaths() error = %v", err)
+	}
+
+	if err := storage.SaveData(&AppData{Tasks: []Task{{ID: "work-task"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if err := storage.SaveData(&AppData{Tasks: []Task{{ID: "work-task"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+
+	testutil.AssertFileExists(t, filepath.Join(dataDir, "work", "tasks.json"))
+	testutil.AssertFileNotExists(t, filepath.Join(configDir, "data.json"))
+
+	// Backups stay under the config dir and are named after the data set
+	backups, err := storage.listBackups()
+	if err != nil {
+		t.Fatalf("listBackups() error = %v", err)
+	}
+	if len(backups) != 1 || filepath.Dir(backups[0]) != filepath.Join(configDir, "backups") {
+		t.Errorf("Expected one backup in the config dir, got %v", backups)
+	}
+	if base := filepath.Base(backups[0]); !strings.HasPrefix(base, "tasks-") {
+		t.Errorf("Expected backup to be named after the data set, got %s", base)
+	}
+
+	// Purging removes the data file even though it lives outside the config dir
+	if err := storage.PurgeData(); err != nil {
+		t.Fatalf("PurgeData() error = %v", err)
+	}
+	testutil.AssertFileNotExists(t, filepath.Join(dataDir, "work", "tasks.json"))
+	testutil.AssertFileNotExists(t, configDir)
+}
+
+func TestNewStorageWithPaths_KeepsLegacyDataFile(t *testing.T) {
+	configDir := testutil.TempDir(t)
+	dataDir := filepath.Join(testutil.TempDir(t), appDirName)
+
+	// Data written by a version that kept it next to the config
+	legacy := &Storage{configDir: configDir, dataPath: filepath.Join(configDir, "data.json")}
+	if err := legacy.SaveData(&AppData{Tasks: []Task{{ID: "existing"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+
+	storage, err := NewStorageWithPaths(Paths{ConfigDir: configDir, DataDir: dataDir})
+	if err != nil {
+		t.Fatalf("NewStorageWithPaths() error = %v", err)
+	}
+	data, err := storage.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(data.Tasks) != 1 || data.Tasks[0].ID != "existing" {
+		t.Errorf("Expected the tasks from the config directory, got %+v", data.Tasks)
+	}
+	testutil.AssertFileExists(t, filepath.Join(configDir, "error.log"))
+
+	// Once the file was moved, the data directory is used
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(configDir, "data.json"), filepath.Join(dataDir, "data.json")); err != nil {
+		t.Fatal(err)
+	}
+	storage, err = NewStorageWithPaths(Paths{ConfigDir: configDir, DataDir: dataDir})
+	if err != nil {
+		t.Fatalf("NewStorageWithPaths() error = %v", err)
+	}
+	if storage.dataPath != filepath.Join(dataDir, "data.json") {
+		t.Errorf("Expected the data directory to be used, got %s", storage.dataPath)
+	}
+}
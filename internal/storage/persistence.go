@@ -2,9 +2,12 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,30 +15,61 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	CalendarURLs    []string `json:"calendar_urls"`
-	DataFile        string   `json:"data_file"`
-	QuoteFiles      []string `json:"quote_files"`
-	RefreshInterval int      `json:"refresh_interval"`
-	DateFormat      string   `json:"date_format"`
-	TimeFormat      string   `json:"time_format"`
-	Theme           string   `json:"theme"`
+	CalendarURLs    []CalendarSource `json:"calendar_urls"` // Calendar URLs, paths or entries with options
+	DataFile        string           `json:"data_file"`
+	QuoteFiles      []string         `json:"quote_files"`
+	RefreshInterval int              `json:"refresh_interval"`
+	DateFormat      string           `json:"date_format"`
+	TimeFormat      string           `json:"time_format"`
+	Theme           string           `json:"theme"`
+	Backend         string           `json:"backend"`                 // Storage backend: "json" (default) or "sqlite"
+	BackupCount     int              `json:"backup_count"`            // Rolling data backups to keep (0 uses the default, negative disables backups)
+	Rollover        bool             `json:"rollover"`                // Carry unfinished past tasks forward to today on startup and at midnight
+	ExportICS       string           `json:"export_ics,omitempty"`    // iCalendar file rewritten with all tasks after changes (empty disables)
+	ExportFormat    string           `json:"export_format,omitempty"` // Export tasks as "todo" (default) or all-day "event"
 }
 
+// defaultBackupCount is used when the config does not set a backup count
+const defaultBackupCount = 5
+
+// backupTimeFormat is used for backup file names so they sort chronologically
+const backupTimeFormat = "20060102-150405.000000000"
+
+// backupInterval is the minimum age of the newest backup before a save takes
+// another one, so that the rolling backups reach further back than the last
+// few keystrokes
+var backupInterval = time.Hour
+
 // Task represents a single task or calendar event
 type Task struct {
-	ID         string    `json:"id"`
-	Text       string    `json:"text"`
-	Done       bool      `json:"done"`
-	Date       time.Time `json:"date"`
-	IsCalendar bool      `json:"is_calendar"`
-	StartTime  time.Time `json:"start_time"`
-	Priority   int       `json:"priority"`
-	CreatedAt  time.Time `json:"created_at"`
-	Level      int       `json:"level"` // Hierarchy level (0 = top level)
+	ID              string        `json:"id"`
+	Text            string        `json:"text"`
+	Done            bool          `json:"done"`
+	Date            time.Time     `json:"date"`
+	IsCalendar      bool          `json:"is_calendar"`
+	StartTime       time.Time     `json:"start_time"`
+	EndTime         time.Time     `json:"end_time,omitzero"`        // End of a calendar event (exclusive)
+	AllDay          bool          `json:"all_day,omitempty"`        // Calendar event that spans whole days
+	CalendarName    string        `json:"calendar_name,omitempty"`  // Configured name of the event's calendar
+	CalendarColor   string        `json:"calendar_color,omitempty"` // Configured color of the event's calendar
+	Details         *EventDetails `json:"details,omitempty"`        // Location, description and people of a calendar event
+	ParentID        string        `json:"parent_id,omitempty"`      // Parent task on the same day (empty for top-level tasks)
+	Priority        int           `json:"priority"`                 // Order among siblings, higher first
+	CreatedAt       time.Time     `json:"created_at"`
+	Level           int           `json:"-"`                          // Depth in the task tree, set by FlattenTree
+	Recurrence      *Recurrence   `json:"recurrence,omitempty"`       // Repeat rule; set on the next open occurrence only
+	OverdueSince    time.Time     `json:"overdue_since,omitzero"`     // Original date of a task carried forward by rollover
+	DeferCount      int           `json:"defer_count,omitempty"`      // How many times rollover carried the task forward
+	Collapsed       bool          `json:"collapsed,omitempty"`        // Whether the task's subtasks are hidden in the list
+	EventID         string        `json:"event_id,omitempty"`         // Calendar event the task prepares for, by its ID derived from the event UID
+	ScheduledAt     time.Time     `json:"scheduled_at,omitzero"`      // Planned start; its time of day applies on the task's date
+	DurationMinutes int           `json:"duration_minutes,omitempty"` // Planned length of a scheduled task (0 uses DefaultDuration)
+	Virtual         bool          `json:"-"`                          // Projected future occurrence of a recurring task, never persisted
 }
 
 // AppData represents all application data
 type AppData struct {
+	Version  int       `json:"version"` // Schema version, see CurrentSchemaVersion
 	Tasks    []Task    `json:"tasks"`
 	Settings Settings  `json:"settings"`
 }
@@ -48,28 +82,56 @@ type Settings struct {
 
 // Storage handles data persistence
 type Storage struct {
-	configDir string
-	dataPath  string
-	config    *Config
+	configDir     string
+	dataDir       string
+	dataPath      string
+	config        *Config
+	recoveredFrom string
+	data          *AppData  // Last loaded or saved data, used for per-task writes
+	synced        []Task    // Tasks as last read or written by this instance, the base for merging
+	fileState     fileState // Data file as last read or written by this instance
+	merged        bool      // A write had to merge changes from another instance
+	exportPending bool      // Tasks were written since the export file was last rewritten
+}
+
+// fileState identifies a version of the data file on disk
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// equal reports whether two states describe the same file version
+func (f fileState) equal(other fileState) bool {
+	return f.modTime.Equal(other.modTime) && f.size == other.size
 }
 
-// NewStorage creates a new storage instance
+// statDataFile returns the current state of a data file (zero if it does not exist)
+func statDataFile(path string) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}
+}
+
+// NewStorage creates a new storage instance in the default location
 func NewStorage() (*Storage, error) {
-	homeDir, err := os.UserHomeDir()
+	paths, err := ResolvePaths("")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return nil, err
 	}
-	
-	configDir := filepath.Join(homeDir, ".config", "personal-disorganizer")
-	dataPath := filepath.Join(configDir, "data.json")
-	
+	return NewStorageWithPaths(paths)
+}
+
+// NewStorageWithPaths creates a new storage instance using the given directories
+func NewStorageWithPaths(paths Paths) (*Storage, error) {
 	s := &Storage{
-		configDir: configDir,
-		dataPath:  dataPath,
+		configDir: paths.ConfigDir,
+		dataDir:   paths.DataDir,
 	}
 	
 	// Ensure config directory exists
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := os.MkdirAll(s.configDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create config directory: %w", err)
 	}
 	
@@ -78,6 +140,25 @@ func NewStorage() (*Storage, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 	
+	// Resolve the data file from the config
+	dataPath, err := resolveDataFile(s.dataDir, s.config.DataFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve data file: %w", err)
+	}
+	s.dataPath = dataPath
+	
+	// Keep using data stranded in the config directory by a newly set $XDG_DATA_HOME
+	if legacyPath, ok := legacyDataFile(paths, dataPath, s.config.DataFile); ok {
+		s.LogError(fmt.Errorf("no data in %s, using %s from the config directory; move it there to use the data directory", dataPath, legacyPath))
+		s.dataPath = legacyPath
+		s.dataDir = s.configDir
+	}
+	
+	// Ensure data directory exists
+	if err := os.MkdirAll(filepath.Dir(s.dataPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	
 	return s, nil
 }
 
@@ -88,13 +169,15 @@ func (s *Storage) loadConfig() error {
 	// Create default config if it doesn't exist
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		defaultConfig := &Config{
-			CalendarURLs:    []string{},
+			CalendarURLs:    []CalendarSource{},
 			DataFile:        "data.json",
 			QuoteFiles:      []string{},
 			RefreshInterval: 300,
 			DateFormat:      "2006-01-02",
 			TimeFormat:      "15:04",
 			Theme:           "dracula",
+			Backend:         BackendJSON,
+			BackupCount:     defaultBackupCount,
 		}
 		
 		if err := s.saveConfig(defaultConfig); err != nil {
@@ -138,10 +221,15 @@ func (s *Storage) saveConfig(config *Config) error {
 
 // LoadData loads application data from file
 func (s *Storage) LoadData() (*AppData, error) {
+	s.recoveredFrom = ""
+	s.merged = false
+	s.fileState = statDataFile(s.dataPath)
+	
 	// Create default data if file doesn't exist
 	if _, err := os.Stat(s.dataPath); os.IsNotExist(err) {
 		defaultData := &AppData{
-			Tasks: []Task{},
+			Version: CurrentSchemaVersion,
+			Tasks:   []Task{},
 			Settings: Settings{
 				LastQuoteIndex:      0,
 				TasksCompletedToday: 0,
@@ -150,30 +238,431 @@ func (s *Storage) LoadData() (*AppData, error) {
 		return defaultData, nil
 	}
 	
-	data, err := os.ReadFile(s.dataPath)
+	contents, err := os.ReadFile(s.dataPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read data file: %w", err)
 	}
 	
-	appData := &AppData{}
-	if err := json.Unmarshal(data, appData); err != nil {
-		return nil, fmt.Errorf("failed to parse data file: %w", err)
+	appData, version, err := decodeData(contents)
+	if err == nil {
+		if version < CurrentSchemaVersion {
+			if err := s.keepPreMigrationCopy(contents, version); err != nil {
+				return nil, err
+			}
+			if err := s.SaveData(appData); err != nil {
+				return nil, fmt.Errorf("failed to save migrated data: %w", err)
+			}
+		}
+		s.data = appData
+		s.markSynced(appData.Tasks)
+		return appData, nil
 	}
 	
-	return appData, nil
+	// Never silently downgrade data written by a newer binary
+	if errors.Is(err, ErrNewerSchema) {
+		return nil, err
+	}
+	
+	// Fall back to the newest backup that still parses
+	backups, listErr := s.listBackups()
+	if listErr != nil {
+		return nil, err
+	}
+	for i := len(backups) - 1; i >= 0; i-- {
+		if backupData, backupErr := readDataFile(backups[i]); backupErr == nil {
+			s.recoveredFrom = backups[i]
+			s.LogError(fmt.Errorf("recovered data from backup %s: %w", backups[i], err))
+			s.data = backupData
+			s.markSynced(backupData.Tasks)
+			return backupData, nil
+		}
+	}
+	
+	return nil, err
+}
+
+// ReadData reads the data of the configured backend for commands that only
+// read tasks. Unlike LoadData it never saves: older schemas are migrated in
+// memory only, data.json is not imported into a new database and the
+// iCalendar export is left alone.
+func (s *Storage) ReadData() (*AppData, error) {
+	if s.config != nil && s.config.Backend == BackendSQLite {
+		if data, found, err := readSQLiteData(s); err != nil || found {
+			return data, err
+		}
+	}
+	
+	if _, err := os.Stat(s.dataPath); os.IsNotExist(err) {
+		return &AppData{Version: CurrentSchemaVersion, Tasks: []Task{}}, nil
+	}
+	return readDataFile(s.dataPath)
 }
 
-// SaveData saves application data to file
+// readDataFile reads, parses and migrates a single data file
+func readDataFile(path string) (*AppData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+	
+	appData, _, err := decodeData(data)
+	return appData, err
+}
+
+// keepPreMigrationCopy stores the data as it was before a schema migration
+func (s *Storage) keepPreMigrationCopy(original []byte, fromVersion int) error {
+	if err := os.MkdirAll(s.backupDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	
+	name := fmt.Sprintf("%s-pre-migration-v%d-%s.json", s.dataSetName(), fromVersion, time.Now().Format(backupTimeFormat))
+	if err := WriteFileAtomic(filepath.Join(s.backupDir(), name), original, 0644); err != nil {
+		return fmt.Errorf("failed to keep pre-migration copy: %w", err)
+	}
+	
+	return nil
+}
+
+// RecoveredFrom returns the backup path the last LoadData fell back to, or "" if data.json loaded cleanly
+func (s *Storage) RecoveredFrom() string {
+	return s.recoveredFrom
+}
+
+// SaveData saves application data to file. Tasks another instance changed
+// since this one last read or wrote the file are merged into data first.
 func (s *Storage) SaveData(data *AppData) error {
+	lock, err := lockFile(s.lockPath())
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+	
+	current := statDataFile(s.dataPath)
+	if !current.equal(s.fileState) && !current.equal(fileState{}) {
+		remote, err := readDataFile(s.dataPath)
+		switch {
+		case errors.Is(err, ErrNewerSchema):
+			return err
+		case err != nil:
+			// A damaged file has nothing worth keeping; replace it
+			s.LogError(fmt.Errorf("could not merge changes from another instance: %w", err))
+		default:
+			data.Tasks, _ = MergeTasks(s.synced, data.Tasks, remote.Tasks)
+			s.merged = true
+		}
+	}
+	
+	return s.saveLocked(data)
+}
+
+// markSynced records the tasks as matching what is on disk
+func (s *Storage) markSynced(tasks []Task) {
+	s.synced = append([]Task(nil), tasks...)
+}
+
+// saveLocked writes the data file; the caller must hold the data file lock
+func (s *Storage) saveLocked(data *AppData) error {
+	data.Version = CurrentSchemaVersion
+	
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %w", err)
 	}
 	
-	if err := os.WriteFile(s.dataPath, jsonData, 0644); err != nil {
+	if err := s.backupDataFile(); err != nil {
+		// A failed backup must not prevent saving
+		s.LogError(err)
+	}
+	
+	if err := WriteFileAtomic(s.dataPath, jsonData, 0644); err != nil {
 		return fmt.Errorf("failed to write data file: %w", err)
 	}
 	
+	s.data = data
+	s.markSynced(data.Tasks)
+	s.fileState = statDataFile(s.dataPath)
+	s.exportPending = true
+	return nil
+}
+
+// lockPath returns the advisory lock file guarding the data file
+func (s *Storage) lockPath() string {
+	return s.dataPath + ".lock"
+}
+
+// currentData returns the data a per-task write should apply to. If another
+// instance changed the file, its version is read so that the write does not
+// clobber those changes. The caller must hold the data file lock.
+func (s *Storage) currentData() (*AppData, error) {
+	if s.data != nil && statDataFile(s.dataPath).equal(s.fileState) {
+		return s.data, nil
+	}
+	
+	if _, err := os.Stat(s.dataPath); os.IsNotExist(err) {
+		if s.data == nil {
+			s.data = &AppData{Version: CurrentSchemaVersion, Tasks: []Task{}}
+		}
+		return s.data, nil
+	}
+	
+	data, err := readDataFile(s.dataPath)
+	if err != nil {
+		return nil, err
+	}
+	
+	// The in-memory data is now behind the file; ExternalChange reports it
+	if s.data != nil {
+		s.merged = true
+	}
+	return data, nil
+}
+
+// UpsertTask inserts or replaces a single task. The JSON file has no per-record
+// updates, so this rewrites the whole file.
+func (s *Storage) UpsertTask(task Task) error {
+	lock, err := lockFile(s.lockPath())
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+	
+	data, err := s.currentData()
+	if err != nil {
+		return err
+	}
+	
+	for i := range data.Tasks {
+		if data.Tasks[i].ID == task.ID {
+			data.Tasks[i] = task
+			return s.saveLocked(data)
+		}
+	}
+	
+	data.Tasks = append(data.Tasks, task)
+	return s.saveLocked(data)
+}
+
+// DeleteTask removes a single task by ID and rewrites the file
+func (s *Storage) DeleteTask(taskID string) error {
+	lock, err := lockFile(s.lockPath())
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+	
+	data, err := s.currentData()
+	if err != nil {
+		return err
+	}
+	
+	for i := range data.Tasks {
+		if data.Tasks[i].ID == taskID {
+			data.Tasks = append(data.Tasks[:i], data.Tasks[i+1:]...)
+			break
+		}
+	}
+	
+	return s.saveLocked(data)
+}
+
+// ExternalChange reports whether another instance modified the data file
+// since this instance last loaded it
+func (s *Storage) ExternalChange() (bool, error) {
+	if s.merged {
+		return true, nil
+	}
+	
+	current := statDataFile(s.dataPath)
+	
+	// A vanished file is more likely a purge than an edit; keep what we have
+	if current.equal(fileState{}) {
+		return false, nil
+	}
+	
+	return !current.equal(s.fileState), nil
+}
+
+// FlushExport rewrites the configured export file if tasks were written since
+// it was last rewritten. Writes only mark the export as pending, so that a
+// burst of edits rewrites it once.
+func (s *Storage) FlushExport() {
+	if !s.exportPending {
+		return
+	}
+	s.exportPending = false
+	s.exportICalendar(s.synced)
+}
+
+// Close writes a pending export; JSON files hold no other resources
+func (s *Storage) Close() error {
+	s.FlushExport()
+	return nil
+}
+
+// WriteFileAtomic writes data to a temp file, syncs it and renames it over path
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	
+	// Clean up the temp file on any failure before the rename
+	committed := false
+	defer func() {
+		if !committed {
+			os.Remove(tmpPath)
+		}
+	}()
+	
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	committed = true
+	
+	// Persist the rename itself; not supported on every platform, so best effort
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	
+	return nil
+}
+
+// backupDir returns the directory holding rolling data backups
+func (s *Storage) backupDir() string {
+	return filepath.Join(s.configDir, "backups")
+}
+
+// dataSetName returns the data file name without extension, used to keep
+// backups of separate data files apart
+func (s *Storage) dataSetName() string {
+	base := filepath.Base(s.dataPath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// backupCount returns the configured number of backups to keep
+func (s *Storage) backupCount() int {
+	if s.config == nil || s.config.BackupCount == 0 {
+		return defaultBackupCount
+	}
+	return s.config.BackupCount
+}
+
+// backupDataFile copies the current data file into the backup directory and prunes old backups
+func (s *Storage) backupDataFile() error {
+	keep := s.backupCount()
+	if keep <= 0 {
+		return nil
+	}
+	
+	current, err := os.ReadFile(s.dataPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read data file for backup: %w", err)
+	}
+	
+	// Never rotate a corrupted file into the backups
+	if !json.Valid(current) {
+		return nil
+	}
+	
+	backups, err := s.listBackups()
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 && time.Since(backupTime(backups[len(backups)-1])) < backupInterval {
+		return nil
+	}
+	
+	if err := os.MkdirAll(s.backupDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	
+	name := fmt.Sprintf("%s-%s.json", s.dataSetName(), time.Now().Format(backupTimeFormat))
+	if err := WriteFileAtomic(filepath.Join(s.backupDir(), name), current, 0644); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	
+	return s.pruneBackups(keep)
+}
+
+// listBackups returns backup file paths sorted from oldest to newest
+func (s *Storage) listBackups() ([]string, error) {
+	entries, err := os.ReadDir(s.backupDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+	
+	prefix := s.dataSetName() + "-"
+	
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		
+		// Only accept names that carry a backup timestamp
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".json")
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(s.backupDir(), name))
+	}
+	
+	// Timestamped names sort chronologically
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// backupTime returns when a backup listed by listBackups was taken
+func backupTime(path string) time.Time {
+	name := strings.TrimSuffix(filepath.Base(path), ".json")
+	stamp := name[max(len(name)-len(backupTimeFormat), 0):]
+	taken, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return taken
+}
+
+// pruneBackups removes all but the newest keep backups
+func (s *Storage) pruneBackups(keep int) error {
+	backups, err := s.listBackups()
+	if err != nil {
+		return err
+	}
+	
+	for len(backups) > keep {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		backups = backups[1:]
+	}
+	
 	return nil
 }
 
@@ -182,6 +671,11 @@ func (s *Storage) GetConfig() *Config {
 	return s.config
 }
 
+// ConfigDir returns the directory holding config and data files
+func (s *Storage) ConfigDir() string {
+	return s.configDir
+}
+
 // CreateTask creates a new task with a unique ID
 func (s *Storage) CreateTask(text string, date time.Time) *Task {
 	return &Task{
@@ -214,10 +708,26 @@ func (s *Storage) LogError(err error) {
 
 // PurgeData deletes all application data and config files
 func (s *Storage) PurgeData() error {
+	// The data file may live outside the config directory
+	if s.dataPath != "" {
+		for _, path := range []string{s.dataPath, sqlitePath(s.dataPath), s.lockPath()} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove data file: %w", err)
+			}
+		}
+	}
+	
 	// Remove the entire config directory and all its contents
 	if err := os.RemoveAll(s.configDir); err != nil {
 		return fmt.Errorf("failed to remove config directory: %w", err)
 	}
 	
+	// Remove our own data directory when it is separate (e.g. under $XDG_DATA_HOME)
+	if s.dataDir != "" && s.dataDir != s.configDir && filepath.Base(s.dataDir) == appDirName {
+		if err := os.RemoveAll(s.dataDir); err != nil {
+			return fmt.Errorf("failed to remove data directory: %w", err)
+		}
+	}
+	
 	return nil
 }
\ No newline at end of file
@@ -145,4 +145,59 @@ func generateBenchmarkAppData(taskCount int) *AppData {
 			TasksCompletedToday: taskCount / 10,
 		},
 	}
-}
\ No newline at end of file
+}
+// Benchmark single-task writes, which is what most keystrokes persist
+func BenchmarkStorage_UpsertTask_Large(b *testing.B) {
+	tempDir := testutil.TempDir(&testing.T{})
+	
+	storage := &Storage{
+		configDir: tempDir,
+		dataPath:  tempDir + "/data.json",
+		config:    &Config{BackupCount: -1},
+	}
+	
+	data := generateBenchmarkAppData(1000)
+	if err := storage.SaveData(data); err != nil {
+		b.Fatalf("SaveData failed: %v", err)
+	}
+	task := data.Tasks[500]
+	
+	b.ResetTimer()
+	b.ReportAllocs()
+	
+	for i := 0; i < b.N; i++ {
+		task.Done = !task.Done
+		if err := storage.UpsertTask(task); err != nil {
+			b.Fatalf("UpsertTask failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkSQLiteStorage_UpsertTask_Large(b *testing.B) {
+	tempDir := testutil.TempDir(&testing.T{})
+	
+	storage, err := NewSQLiteStorage(&Storage{
+		configDir: tempDir,
+		dataPath:  tempDir + "/data.json",
+	})
+	if err != nil {
+		b.Fatalf("NewSQLiteStorage failed: %v", err)
+	}
+	defer storage.Close()
+	
+	data := generateBenchmarkAppData(1000)
+	if err := storage.SaveData(data); err != nil {
+		b.Fatalf("SaveData failed: %v", err)
+	}
+	task := data.Tasks[500]
+	
+	b.ResetTimer()
+	b.ReportAllocs()
+	
+	for i := 0; i < b.N; i++ {
+		task.Done = !task.Done
+		if err := storage.UpsertTask(task); err != nil {
+			b.Fatalf("UpsertTask failed: %v", err)
+		}
+	}
+}
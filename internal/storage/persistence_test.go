@@ -29,6 +29,9 @@ func TestNewStorage(t *testing.T) {
 			tempDir := testutil.TempDir(t)
 			os.Setenv("HOME", tempDir)
 			defer os.Setenv("HOME", originalHome)
+			t.Setenv("XDG_CONFIG_HOME", "")
+			t.Setenv("XDG_DATA_HOME", "")
+			t.Setenv(ConfigDirEnv, "")
 
 			storage, err := NewStorage()
 			if (err != nil) != tt.wantErr {
@@ -74,7 +77,7 @@ func TestStorage_LoadConfig(t *testing.T) {
 			name: "load existing valid config",
 			setupConfig: func(dir string) error {
 				configData := &Config{
-					CalendarURLs:    []string{"https://example.com/calendar.ics"},
+					CalendarURLs:    []CalendarSource{{URL: "https://example.com/calendar.ics"}},
 					DataFile:        "data.json",
 					QuoteFiles:      []string{"quotes/test.json"},
 					RefreshInterval: 300,
@@ -483,4 +486,343 @@ func TestTask_Validation(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestStorage_SaveDataAtomic(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	dataPath := filepath.Join(tempDir, "data.json")
+
+	storage := &Storage{
+		configDir: tempDir,
+		dataPath:  dataPath,
+	}
+
+	data := &AppData{Tasks: []Task{{ID: "task-1", Text: "Task 1"}}}
+	if err := storage.SaveData(data); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+
+	// No temp files should be left behind next to the data file
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to read temp dir: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp-") {
+			t.Errorf("Temp file left behind: %s", entry.Name())
+		}
+	}
+
+	loaded, err := storage.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(loaded.Tasks) != 1 || loaded.Tasks[0].ID != "task-1" {
+		t.Errorf("Expected saved task to round-trip, got %+v", loaded.Tasks)
+	}
+}
+
+func TestStorage_RollingBackups(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+
+	storage := &Storage{
+		configDir: tempDir,
+		dataPath:  filepath.Join(tempDir, "data.json"),
+		config:    &Config{BackupCount: 3},
+	}
+
+	original := backupInterval
+	backupInterval = 0
+	defer func() { backupInterval = original }()
+
+	// The first save has nothing to back up, every later save backs up the previous file
+	for i := 0; i < 6; i++ {
+		data := &AppData{Tasks: []Task{{ID: testutil.MockUUID(i), Text: testutil.MockTaskText(i)}}}
+		if err := storage.SaveData(data); err != nil {
+			t.Fatalf("SaveData() error = %v", err)
+		}
+	}
+
+	backups, err := storage.listBackups()
+	if err != nil {
+		t.Fatalf("listBackups() error = %v", err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("Expected 3 backups, got %d", len(backups))
+	}
+
+	// The newest backup holds the state before the last save
+	newest, err := readDataFile(backups[len(backups)-1])
+	if err != nil {
+		t.Fatalf("Failed to read newest backup: %v", err)
+	}
+	if newest.Tasks[0].ID != testutil.MockUUID(4) {
+		t.Errorf("Expected newest backup to contain %s, got %s", testutil.MockUUID(4), newest.Tasks[0].ID)
+	}
+}
+
+func TestStorage_BackupInterval(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+
+	storage := &Storage{
+		configDir: tempDir,
+		dataPath:  filepath.Join(tempDir, "data.json"),
+	}
+
+	// Saves within the interval of the newest backup take no new one
+	for i := 0; i < 4; i++ {
+		if err := storage.SaveData(&AppData{Tasks: []Task{{ID: testutil.MockUUID(i)}}}); err != nil {
+			t.Fatalf("SaveData() error = %v", err)
+		}
+	}
+	backups, err := storage.listBackups()
+	if err != nil {
+		t.Fatalf("listBackups() error = %v", err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 backup within the interval, got %d", len(backups))
+	}
+
+	// Once the newest backup is old enough, the next save backs up again
+	aged := filepath.Join(tempDir, "backups", "data-"+time.Now().Add(-2*backupInterval).Format(backupTimeFormat)+".json")
+	if err := os.Rename(backups[0], aged); err != nil {
+		t.Fatalf("Failed to age backup: %v", err)
+	}
+	if err := storage.SaveData(&AppData{Tasks: []Task{{ID: testutil.MockUUID(9)}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if backups, _ = storage.listBackups(); len(backups) != 2 {
+		t.Errorf("Expected a new backup after the interval, got %d backups", len(backups))
+	}
+}
+
+func TestStorage_BackupsDisabled(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+
+	storage := &Storage{
+		configDir: tempDir,
+		dataPath:  filepath.Join(tempDir, "data.json"),
+		config:    &Config{BackupCount: -1},
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := storage.SaveData(&AppData{}); err != nil {
+			t.Fatalf("SaveData() error = %v", err)
+		}
+	}
+
+	testutil.AssertFileNotExists(t, filepath.Join(tempDir, "backups"))
+}
+
+func TestStorage_LoadDataFallsBackToBackup(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	dataPath := filepath.Join(tempDir, "data.json")
+
+	storage := &Storage{
+		configDir: tempDir,
+		dataPath:  dataPath,
+	}
+
+	if err := storage.SaveData(&AppData{Tasks: []Task{{ID: "old", Text: "Old"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if err := storage.SaveData(&AppData{Tasks: []Task{{ID: "new", Text: "New"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+
+	// Add a corrupted backup that is newer than the valid one; it must be skipped
+	corruptBackup := filepath.Join(tempDir, "backups", "data-99991231-235959.000000000.json")
+	if err := os.WriteFile(corruptBackup, []byte("{broken"), 0644); err != nil {
+		t.Fatalf("Failed to write corrupted backup: %v", err)
+	}
+
+	// Simulate a torn write of the main data file
+	if err := os.WriteFile(dataPath, []byte(`{"tasks": [`), 0644); err != nil {
+		t.Fatalf("Failed to corrupt data file: %v", err)
+	}
+
+	data, err := storage.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() should fall back to backup, got error: %v", err)
+	}
+	if len(data.Tasks) != 1 || data.Tasks[0].ID != "old" {
+		t.Errorf("Expected task from backup, got %+v", data.Tasks)
+	}
+	if storage.RecoveredFrom() == "" {
+		t.Error("RecoveredFrom() should report the backup that was used")
+	}
+
+	// A clean load resets the recovery marker
+	if err := storage.SaveData(data); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if _, err := storage.LoadData(); err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if storage.RecoveredFrom() != "" {
+		t.Errorf("Expected no recovery after clean load, got %s", storage.RecoveredFrom())
+	}
+}
+
+func TestStorage_UpsertAndDeleteTask(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+
+	storage := &Storage{
+		configDir: tempDir,
+		dataPath:  filepath.Join(tempDir, "data.json"),
+	}
+
+	// Works before anything was loaded or saved
+	if err := storage.UpsertTask(Task{ID: "a", Text: "First"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	if err := storage.UpsertTask(Task{ID: "b", Text: "Second"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	if err := storage.UpsertTask(Task{ID: "a", Text: "First (edited)"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	if err := storage.DeleteTask("b"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+
+	// Read back through a fresh instance to make sure it reached the disk
+	reloaded := &Storage{
+		configDir: tempDir,
+		dataPath:  filepath.Join(tempDir, "data.json"),
+	}
+	data, err := reloaded.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(data.Tasks) != 1 || data.Tasks[0].Text != "First (edited)" {
+		t.Errorf("Expected only the edited task, got %+v", data.Tasks)
+	}
+}
+
+func TestStorage_ExternalChange(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	dataPath := filepath.Join(tempDir, "data.json")
+
+	first := &Storage{configDir: tempDir, dataPath: dataPath}
+	second := &Storage{configDir: tempDir, dataPath: dataPath}
+
+	if err := first.SaveData(&AppData{Tasks: []Task{{ID: "a", Text: "A"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if _, err := second.LoadData(); err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+
+	// Own writes are not external changes
+	changed, err := first.ExternalChange()
+	if err != nil || changed {
+		t.Fatalf("Expected no external change after own write, got %v (err %v)", changed, err)
+	}
+
+	if err := second.UpsertTask(Task{ID: "b", Text: "B"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+
+	changed, err = first.ExternalChange()
+	if err != nil || !changed {
+		t.Fatalf("Expected external change after other instance wrote, got %v (err %v)", changed, err)
+	}
+
+	// Reloading clears the change
+	if _, err := first.LoadData(); err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	changed, _ = first.ExternalChange()
+	if changed {
+		t.Error("Expected no external change after reload")
+	}
+}
+
+func TestStorage_UpsertTaskDoesNotClobberOtherInstance(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	dataPath := filepath.Join(tempDir, "data.json")
+
+	first := &Storage{configDir: tempDir, dataPath: dataPath}
+	second := &Storage{configDir: tempDir, dataPath: dataPath}
+
+	if err := first.SaveData(&AppData{Tasks: []Task{{ID: "a", Text: "A"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if _, err := second.LoadData(); err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+
+	// Both instances write a different task without reloading in between
+	if err := second.UpsertTask(Task{ID: "from-second", Text: "Second"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	if err := first.UpsertTask(Task{ID: "from-first", Text: "First"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	if err := first.DeleteTask("a"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+
+	// The first instance must still be told to reload
+	if changed, _ := first.ExternalChange(); !changed {
+		t.Error("Expected first instance to report the merged external change")
+	}
+
+	data, err := (&Storage{configDir: tempDir, dataPath: dataPath}).LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+
+	ids := map[string]bool{}
+	for _, task := range data.Tasks {
+		ids[task.ID] = true
+	}
+	if !ids["from-first"] || !ids["from-second"] || ids["a"] {
+		t.Errorf("Expected both new tasks and no deleted task on disk, got %v", ids)
+	}
+}
+
+func TestStorage_SaveDataMergesOtherInstance(t *testing.T) {
+	tempDir := testutil.TempDir(t)
+	dataPath := filepath.Join(tempDir, "data.json")
+
+	first := &Storage{configDir: tempDir, dataPath: dataPath}
+	second := &Storage{configDir: tempDir, dataPath: dataPath}
+
+	if err := first.SaveData(&AppData{Tasks: []Task{{ID: "a", Text: "A"}, {ID: "b", Text: "B"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	data, err := first.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if _, err := second.LoadData(); err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+
+	// The other instance adds a task and edits one
+	if err := second.UpsertTask(Task{ID: "c", Text: "C"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	if err := second.UpsertTask(Task{ID: "b", Text: "B (edited)"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+
+	// A full save of stale data keeps those changes
+	data.Tasks = []Task{{ID: "a", Text: "A", Done: true}, {ID: "b", Text: "B"}}
+	if err := first.SaveData(data); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if changed, _ := first.ExternalChange(); !changed {
+		t.Error("Expected the merge to be reported as an external change")
+	}
+
+	saved, err := (&Storage{configDir: tempDir, dataPath: dataPath}).LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	byID := indexTasks(saved.Tasks)
+	if len(saved.Tasks) != 3 || !byID["a"].Done || byID["b"].Text != "B (edited)" || byID["c"].Text != "C" {
+		t.Errorf("Expected both instances' changes on disk, got %+v", saved.Tasks)
+	}
+}
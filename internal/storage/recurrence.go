@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence frequencies
+const (
+	FreqDaily   = "daily"
+	FreqWeekly  = "weekly"
+	FreqMonthly = "monthly"
+)
+
+// Recurrence describes how a task repeats, modelled after a subset of RFC 5545 RRULEs
+type Recurrence struct {
+	Freq            string         `json:"freq"`                       // FreqDaily, FreqWeekly or FreqMonthly
+	Interval        int            `json:"interval,omitempty"`         // Every N days/weeks/months (0 means 1)
+	Weekdays        []time.Weekday `json:"weekdays,omitempty"`         // Weekly only: days the task occurs on
+	MonthDay        int            `json:"month_day,omitempty"`        // Monthly only: day of the month (0 keeps the task's day)
+	AfterCompletion bool           `json:"after_completion,omitempty"` // Count the interval from the completion date instead of the due date
+}
+
+// weekdayNames maps the accepted weekday abbreviations
+var weekdayNames = map[string]time.Weekday{
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+	"sun": time.Sunday,
+}
+
+// interval returns the effective interval
+func (r Recurrence) interval() int {
+	if r.Interval < 1 {
+		return 1
+	}
+	return r.Interval
+}
+
+// hasWeekday reports whether the rule includes the given weekday
+func (r Recurrence) hasWeekday(day time.Weekday) bool {
+	for _, weekday := range r.Weekdays {
+		if weekday == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the first occurrence strictly after from. Dates are handled as
+// whole local days, the same way as task dates (see StartOfDay).
+func (r Recurrence) Next(from time.Time) time.Time {
+	date := StartOfDay(from)
+	year, month, day := date.Date()
+
+	switch r.Freq {
+	case FreqWeekly:
+		if len(r.Weekdays) == 0 {
+			return date.AddDate(0, 0, 7*r.interval())
+		}
+
+		// Remaining days of the current week (weeks start on Monday)
+		for next := date.AddDate(0, 0, 1); next.Weekday() != time.Monday; next = next.AddDate(0, 0, 1) {
+			if r.hasWeekday(next.Weekday()) {
+				return next
+			}
+		}
+
+		// First matching day of the next week in the interval
+		monday := date.AddDate(0, 0, -daysSinceMonday(date))
+		next := monday.AddDate(0, 0, 7*r.interval())
+		for !r.hasWeekday(next.Weekday()) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+
+	case FreqMonthly:
+		monthDay := r.MonthDay
+		if monthDay == 0 {
+			monthDay = day
+		}
+
+		// A later day in the same month still counts
+		if candidate := clampedMonthDay(year, month, monthDay); candidate.After(date) {
+			return candidate
+		}
+		return clampedMonthDay(year, month+time.Month(r.interval()), monthDay)
+
+	default:
+		return date.AddDate(0, 0, r.interval())
+	}
+}
+
+// AnchorTo fixes the day of the month of a monthly rule to the day of the
+// task it is attached to. Otherwise each occurrence would repeat the day of
+// the previous one, drifting to the 28th after a short month.
+func (r *Recurrence) AnchorTo(date time.Time) {
+	if r.Freq == FreqMonthly && r.MonthDay == 0 && !r.AfterCompletion {
+		r.MonthDay = StartOfDay(date).Day()
+	}
+}
+
+// OccursOn reports whether a task first due on start also occurs on date
+func (r Recurrence) OccursOn(start, date time.Time) bool {
+	target := StartOfDay(date)
+	next := StartOfDay(start)
+	for next.Before(target) {
+		next = r.Next(next)
+	}
+	return next.Equal(target)
+}
+
+// daysSinceMonday returns how many days date is after the Monday of its week
+func daysSinceMonday(date time.Time) int {
+	return (int(date.Weekday()) + 6) % 7
+}
+
+// clampedMonthDay returns the given day of a month, or the month's last day if it is shorter
+func clampedMonthDay(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// String describes the rule in the syntax accepted by ParseRecurrence
+func (r Recurrence) String() string {
+	var parts []string
+
+	switch {
+	case r.Freq == FreqDaily && r.interval() == 1:
+		parts = append(parts, "daily")
+	case r.Freq == FreqDaily:
+		parts = append(parts, fmt.Sprintf("every %d days", r.interval()))
+	case r.Freq == FreqWeekly && r.interval() == 1:
+		parts = append(parts, "weekly")
+	case r.Freq == FreqWeekly:
+		parts = append(parts, fmt.Sprintf("every %d weeks", r.interval()))
+	case r.Freq == FreqMonthly && r.interval() == 1:
+		parts = append(parts, "monthly")
+	case r.Freq == FreqMonthly:
+		parts = append(parts, fmt.Sprintf("every %d months", r.interval()))
+	}
+
+	if r.Freq == FreqWeekly && len(r.Weekdays) > 0 {
+		var days []string
+		for _, weekday := range r.Weekdays {
+			days = append(days, strings.ToLower(weekday.String()[:3]))
+		}
+		parts = append(parts, "on "+strings.Join(days, ","))
+	}
+	if r.Freq == FreqMonthly && r.MonthDay > 0 {
+		parts = append(parts, "on "+strconv.Itoa(r.MonthDay))
+	}
+	if r.AfterCompletion {
+		parts = append(parts, "after done")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ParseRecurrence parses a rule such as "daily", "weekdays", "every 2 weeks on
+// mon,thu", "monthly on 15" or "every 3 days after done". An empty string or
+// "none" returns nil, which removes a task's recurrence.
+func ParseRecurrence(text string) (*Recurrence, error) {
+	fields := strings.Fields(strings.ToLower(text))
+	if len(fields) == 0 || (len(fields) == 1 && fields[0] == "none") {
+		return nil, nil
+	}
+
+	rule := &Recurrence{}
+
+	// Trailing "after done" / "after completion"
+	if n := len(fields); n >= 2 && fields[n-2] == "after" && (fields[n-1] == "done" || fields[n-1] == "completion") {
+		rule.AfterCompletion = true
+		fields = fields[:n-2]
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("missing frequency in recurrence %q", text)
+	}
+
+	// Frequency, optionally with an interval
+	switch fields[0] {
+	case "daily":
+		rule.Freq = FreqDaily
+		fields = fields[1:]
+	case "weekly":
+		rule.Freq = FreqWeekly
+		fields = fields[1:]
+	case "monthly":
+		rule.Freq = FreqMonthly
+		fields = fields[1:]
+	case "weekdays":
+		rule.Freq = FreqWeekly
+		rule.Weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+		fields = fields[1:]
+	case "every":
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("expected \"every N days|weeks|months\" in recurrence %q", text)
+		}
+		interval, err := strconv.Atoi(fields[1])
+		if err != nil || interval < 1 {
+			return nil, fmt.Errorf("invalid interval %q in recurrence %q", fields[1], text)
+		}
+		rule.Interval = interval
+		switch strings.TrimSuffix(fields[2], "s") {
+		case "day":
+			rule.Freq = FreqDaily
+		case "week":
+			rule.Freq = FreqWeekly
+		case "month":
+			rule.Freq = FreqMonthly
+		default:
+			return nil, fmt.Errorf("unknown unit %q in recurrence %q", fields[2], text)
+		}
+		fields = fields[3:]
+	default:
+		return nil, fmt.Errorf("unknown frequency %q in recurrence %q", fields[0], text)
+	}
+
+	// Optional "on ..." for weekly and monthly rules
+	if len(fields) > 0 {
+		if fields[0] != "on" || len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected %q in recurrence %q", strings.Join(fields, " "), text)
+		}
+
+		switch rule.Freq {
+		case FreqWeekly:
+			if rule.Weekdays != nil {
+				return nil, fmt.Errorf("weekdays already set in recurrence %q", text)
+			}
+			for _, name := range strings.Split(fields[1], ",") {
+				weekday, ok := weekdayNames[name]
+				if !ok {
+					return nil, fmt.Errorf("unknown weekday %q in recurrence %q", name, text)
+				}
+				if !rule.hasWeekday(weekday) {
+					rule.Weekdays = append(rule.Weekdays, weekday)
+				}
+			}
+		case FreqMonthly:
+			day, err := strconv.Atoi(fields[1])
+			if err != nil || day < 1 || day > 31 {
+				return nil, fmt.Errorf("invalid day of month %q in recurrence %q", fields[1], text)
+			}
+			rule.MonthDay = day
+		default:
+			return nil, fmt.Errorf("daily recurrences cannot have \"on\" in %q", text)
+		}
+	}
+
+	return rule, nil
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// day returns local midnight of the given date
+func day(year int, month time.Month, dayOfMonth int) time.Time {
+	return time.Date(year, month, dayOfMonth, 0, 0, 0, 0, time.Local)
+}
+
+func TestRecurrence_Next(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     Recurrence
+		from     time.Time
+		expected time.Time
+	}{
+		{
+			name:     "daily",
+			rule:     Recurrence{Freq: FreqDaily},
+			from:     day(2024, 1, 31),
+			expected: day(2024, 2, 1),
+		},
+		{
+			name:     "every 3 days",
+			rule:     Recurrence{Freq: FreqDaily, Interval: 3},
+			from:     day(2024, 1, 1),
+			expected: day(2024, 1, 4),
+		},
+		{
+			name:     "weekly without weekdays",
+			rule:     Recurrence{Freq: FreqWeekly},
+			from:     day(2024, 1, 3),
+			expected: day(2024, 1, 10),
+		},
+		{
+			name:     "weekly later in the same week",
+			rule:     Recurrence{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Monday, time.Thursday}},
+			from:     day(2024, 1, 1), // Monday
+			expected: day(2024, 1, 4),
+		},
+		{
+			name:     "weekly wraps to next week",
+			rule:     Recurrence{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Monday, time.Thursday}},
+			from:     day(2024, 1, 4), // Thursday
+			expected: day(2024, 1, 8),
+		},
+		{
+			name:     "weekdays skip the weekend",
+			rule:     Recurrence{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+			from:     day(2024, 1, 5), // Friday
+			expected: day(2024, 1, 8),
+		},
+		{
+			name:     "every 2 weeks on sunday",
+			rule:     Recurrence{Freq: FreqWeekly, Interval: 2, Weekdays: []time.Weekday{time.Sunday}},
+			from:     day(2024, 1, 7), // Sunday
+			expected: day(2024, 1, 21),
+		},
+		{
+			name:     "monthly keeps the day",
+			rule:     Recurrence{Freq: FreqMonthly},
+			from:     day(2024, 1, 15),
+			expected: day(2024, 2, 15),
+		},
+		{
+			name:     "monthly on a later day of the same month",
+			rule:     Recurrence{Freq: FreqMonthly, MonthDay: 20},
+			from:     day(2024, 1, 15),
+			expected: day(2024, 1, 20),
+		},
+		{
+			name:     "monthly clamps to the end of short months",
+			rule:     Recurrence{Freq: FreqMonthly, MonthDay: 31},
+			from:     day(2024, 1, 31),
+			expected: day(2024, 2, 29),
+		},
+		{
+			name:     "every 3 months across the year",
+			rule:     Recurrence{Freq: FreqMonthly, Interval: 3},
+			from:     day(2024, 11, 5),
+			expected: day(2025, 2, 5),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := tt.rule.Next(tt.from)
+			if !next.Equal(tt.expected) {
+				t.Errorf("Expected %s, got %s", tt.expected.Format("2006-01-02"), next.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestRecurrence_AnchorTo(t *testing.T) {
+	rule, err := ParseRecurrence("monthly")
+	if err != nil {
+		t.Fatalf("ParseRecurrence() error = %v", err)
+	}
+	rule.AnchorTo(day(2024, 1, 31))
+
+	// Each occurrence is counted from the previous one without drifting to the 29th
+	expected := []time.Time{day(2024, 2, 29), day(2024, 3, 31), day(2024, 4, 30), day(2024, 5, 31)}
+	next := day(2024, 1, 31)
+	for _, date := range expected {
+		next = rule.Next(next)
+		if !next.Equal(date) {
+			t.Fatalf("Expected %s, got %s", date.Format("2006-01-02"), next.Format("2006-01-02"))
+		}
+	}
+
+	// Explicit days and rules counted from completion are left alone
+	explicit := Recurrence{Freq: FreqMonthly, MonthDay: 15}
+	explicit.AnchorTo(day(2024, 1, 31))
+	afterDone := Recurrence{Freq: FreqMonthly, AfterCompletion: true}
+	afterDone.AnchorTo(day(2024, 1, 31))
+	if explicit.MonthDay != 15 || afterDone.MonthDay != 0 {
+		t.Errorf("Expected anchoring to keep explicit and completion-based rules, got %d and %d", explicit.MonthDay, afterDone.MonthDay)
+	}
+}
+
+func TestRecurrence_OccursOn(t *testing.T) {
+	rule := Recurrence{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Monday, time.Wednesday}}
+	start := day(2024, 1, 1) // Monday
+
+	tests := []struct {
+		date     time.Time
+		expected bool
+	}{
+		{day(2024, 1, 1), true},
+		{day(2024, 1, 2), false},
+		{day(2024, 1, 3), true},
+		{day(2024, 1, 8), true},
+		{day(2024, 1, 12), false},
+	}
+
+	for _, tt := range tests {
+		if got := rule.OccursOn(start, tt.date); got != tt.expected {
+			t.Errorf("OccursOn(%s) = %v, expected %v", tt.date.Format("2006-01-02"), got, tt.expected)
+		}
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    *Recurrence
+		expectError bool
+	}{
+		{input: "", expected: nil},
+		{input: "none", expected: nil},
+		{input: "daily", expected: &Recurrence{Freq: FreqDaily}},
+		{input: "Every 3 days after done", expected: &Recurrence{Freq: FreqDaily, Interval: 3, AfterCompletion: true}},
+		{input: "weekly on mon,thu", expected: &Recurrence{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Monday, time.Thursday}}},
+		{input: "every 2 weeks", expected: &Recurrence{Freq: FreqWeekly, Interval: 2}},
+		{input: "weekdays", expected: &Recurrence{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}}},
+		{input: "monthly on 15", expected: &Recurrence{Freq: FreqMonthly, MonthDay: 15}},
+		{input: "every 1 month after completion", expected: &Recurrence{Freq: FreqMonthly, Interval: 1, AfterCompletion: true}},
+		{input: "hourly", expectError: true},
+		{input: "every 0 days", expectError: true},
+		{input: "every 2 fortnights", expectError: true},
+		{input: "weekly on funday", expectError: true},
+		{input: "monthly on 32", expectError: true},
+		{input: "daily on mon", expectError: true},
+		{input: "after done", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			rule, err := ParseRecurrence(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", rule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rule, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, rule)
+			}
+		})
+	}
+}
+
+func TestRecurrence_StringRoundTrip(t *testing.T) {
+	rules := []Recurrence{
+		{Freq: FreqDaily},
+		{Freq: FreqDaily, Interval: 3, AfterCompletion: true},
+		{Freq: FreqWeekly, Weekdays: []time.Weekday{time.Tuesday, time.Friday}},
+		{Freq: FreqWeekly, Interval: 2},
+		{Freq: FreqMonthly, MonthDay: 1},
+		{Freq: FreqMonthly, Interval: 6},
+	}
+
+	for _, rule := range rules {
+		parsed, err := ParseRecurrence(rule.String())
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q) error = %v", rule.String(), err)
+		}
+		if parsed.String() != rule.String() {
+			t.Errorf("Expected %q after round trip, got %q", rule.String(), parsed.String())
+		}
+	}
+}
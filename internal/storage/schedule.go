@@ -0,0 +1,40 @@
+package storage
+
+import "time"
+
+// DefaultDuration is the planned length of scheduled tasks without a duration
+const DefaultDuration = 30 * time.Minute
+
+// Duration returns the planned length of a scheduled task
+func (t Task) Duration() time.Duration {
+	if t.DurationMinutes <= 0 {
+		return DefaultDuration
+	}
+	return time.Duration(t.DurationMinutes) * time.Minute
+}
+
+// Schedule returns when a task is planned on its date, and false if it has
+// no scheduled start. Only the local time of day of ScheduledAt is used, so
+// tasks moved to another day or repeated keep their time slot.
+func (t Task) Schedule() (start, end time.Time, ok bool) {
+	if t.ScheduledAt.IsZero() {
+		return time.Time{}, time.Time{}, false
+	}
+	at := t.ScheduledAt.In(time.Local)
+	year, month, day := t.Date.In(time.Local).Date()
+	start = time.Date(year, month, day, at.Hour(), at.Minute(), 0, 0, time.Local)
+	return start, start.Add(t.Duration()), true
+}
+
+// SetSchedule plans a task to start at a time of its day for a duration,
+// which is rounded down to whole minutes
+func (t *Task) SetSchedule(start time.Time, duration time.Duration) {
+	t.ScheduledAt = start
+	t.DurationMinutes = int(duration / time.Minute)
+}
+
+// ClearSchedule removes a task's planned start and duration
+func (t *Task) ClearSchedule() {
+	t.ScheduledAt = time.Time{}
+	t.DurationMinutes = 0
+}
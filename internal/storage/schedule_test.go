@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/testutil"
+)
+
+func TestTask_Schedule(t *testing.T) {
+	berlin := testutil.SetLocalZone(t, "Europe/Berlin")
+	day := time.Date(2024, 3, 15, 0, 0, 0, 0, berlin)
+	at := time.Date(2024, 3, 15, 9, 30, 0, 0, berlin)
+
+	tests := []struct {
+		name          string
+		task          Task
+		expectedStart time.Time
+		expectedEnd   time.Time
+		expectedOK    bool
+	}{
+		{
+			name:       "not scheduled",
+			task:       Task{Date: day},
+			expectedOK: false,
+		},
+		{
+			name:          "default duration",
+			task:          Task{Date: day, ScheduledAt: at},
+			expectedStart: at,
+			expectedEnd:   at.Add(DefaultDuration),
+			expectedOK:    true,
+		},
+		{
+			name:          "with duration",
+			task:          Task{Date: day, ScheduledAt: at, DurationMinutes: 90},
+			expectedStart: at,
+			expectedEnd:   at.Add(90 * time.Minute),
+			expectedOK:    true,
+		},
+		{
+			name:          "moved to another day keeps the time of day",
+			task:          Task{Date: AddDays(day, 17), ScheduledAt: at},
+			expectedStart: time.Date(2024, 4, 1, 9, 30, 0, 0, berlin),
+			expectedEnd:   time.Date(2024, 4, 1, 10, 0, 0, 0, berlin),
+			expectedOK:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := tt.task.Schedule()
+			if ok != tt.expectedOK {
+				t.Fatalf("Expected ok %v, got %v", tt.expectedOK, ok)
+			}
+			if !start.Equal(tt.expectedStart) || !end.Equal(tt.expectedEnd) {
+				t.Errorf("Expected %v - %v, got %v - %v", tt.expectedStart, tt.expectedEnd, start, end)
+			}
+		})
+	}
+}
+
+func TestTask_SetSchedule(t *testing.T) {
+	testutil.SetLocalZone(t, "UTC")
+	start := time.Date(2024, 3, 15, 14, 0, 0, 0, time.UTC)
+
+	task := Task{ID: "a", Date: StartOfDay(start)}
+	task.SetSchedule(start, 45*time.Minute)
+	if !task.ScheduledAt.Equal(start) || task.DurationMinutes != 45 {
+		t.Errorf("Expected 14:00 for 45 minutes, got %v for %d minutes", task.ScheduledAt, task.DurationMinutes)
+	}
+
+	// The schedule survives a JSON round trip
+	data, err := json.Marshal(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded Task
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.ScheduledAt.Equal(start) || loaded.Duration() != 45*time.Minute {
+		t.Errorf("Expected the schedule to round-trip, got %v for %v", loaded.ScheduledAt, loaded.Duration())
+	}
+
+	task.ClearSchedule()
+	if _, _, ok := task.Schedule(); ok {
+		t.Error("Expected no schedule after ClearSchedule")
+	}
+	data, err = json.Marshal(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "scheduled_at") || strings.Contains(string(data), "duration_minutes") {
+		t.Errorf("Expected an unscheduled task to store no schedule fields, got %s", data)
+	}
+}
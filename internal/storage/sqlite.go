@@ -0,0 +1,399 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema creates the tables used by the SQLite backend. Tasks are stored
+// as JSON documents so that schema migrations can share the JSON code path.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS tasks (
+	id       TEXT PRIMARY KEY,
+	position INTEGER NOT NULL,
+	data     TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+`
+
+// SQLiteStorage persists data in a SQLite database with per-task writes.
+// Config, logging and purging are shared with the JSON Storage.
+type SQLiteStorage struct {
+	*Storage
+	db          *sql.DB
+	dbPath      string
+	dataVersion int64 // PRAGMA data_version as of the last load
+}
+
+// NewSQLiteStorage opens (or creates) the SQLite database next to the configured data file
+func NewSQLiteStorage(base *Storage) (*SQLiteStorage, error) {
+	dbPath := sqlitePath(base.dataPath)
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite allows a single writer; serialise access through one connection
+	db.SetMaxOpenConns(1)
+
+	for _, pragma := range []string{"PRAGMA journal_mode=WAL", "PRAGMA synchronous=NORMAL", "PRAGMA busy_timeout=5000"} {
+		if _, err := db.Exec(pragma); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to configure database: %w", err)
+		}
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create database schema: %w", err)
+	}
+
+	return &SQLiteStorage{
+		Storage: base,
+		db:      db,
+		dbPath:  dbPath,
+	}, nil
+}
+
+// sqlitePath derives the database path from the JSON data file path (data.json -> data.db)
+func sqlitePath(dataPath string) string {
+	return strings.TrimSuffix(dataPath, filepath.Ext(dataPath)) + ".db"
+}
+
+// LoadData loads application data from the database. On first use an existing
+// data.json is imported.
+func (s *SQLiteStorage) LoadData() (*AppData, error) {
+	dataVersion, err := s.readDataVersion()
+	if err != nil {
+		return nil, err
+	}
+	s.dataVersion = dataVersion
+
+	version, found, err := s.getMeta("version")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return s.importJSON()
+	}
+
+	contents, err := s.readDocument(version)
+	if err != nil {
+		return nil, err
+	}
+
+	appData, fromVersion, err := decodeData(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromVersion < CurrentSchemaVersion {
+		if err := s.keepPreMigrationCopy(contents, fromVersion); err != nil {
+			return nil, err
+		}
+		if err := s.SaveData(appData); err != nil {
+			return nil, fmt.Errorf("failed to save migrated data: %w", err)
+		}
+	}
+
+	s.markSynced(appData.Tasks)
+	return appData, nil
+}
+
+// readSQLiteData reads the database next to the data file without writing
+// to it. found is false if there is no database yet or data.json was never
+// imported into it.
+func readSQLiteData(base *Storage) (data *AppData, found bool, err error) {
+	dbPath := sqlitePath(base.dataPath)
+	if !fileExists(dbPath) {
+		return nil, false, nil
+	}
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("PRAGMA busy_timeout=5000"); err != nil {
+		return nil, false, fmt.Errorf("failed to configure database: %w", err)
+	}
+
+	s := &SQLiteStorage{Storage: base, db: db, dbPath: dbPath}
+	version, found, err := s.getMeta("version")
+	if err != nil || !found {
+		return nil, false, err
+	}
+	contents, err := s.readDocument(version)
+	if err != nil {
+		return nil, false, err
+	}
+	data, _, err = decodeData(contents)
+	return data, true, err
+}
+
+// importJSON seeds an empty database from the JSON data file
+func (s *SQLiteStorage) importJSON() (*AppData, error) {
+	appData, err := s.Storage.LoadData()
+	if err != nil {
+		return nil, fmt.Errorf("failed to import data.json: %w", err)
+	}
+
+	if err := s.SaveData(appData); err != nil {
+		return nil, fmt.Errorf("failed to import data.json: %w", err)
+	}
+
+	return appData, nil
+}
+
+// readDocument assembles the stored rows into the JSON document format
+func (s *SQLiteStorage) readDocument(version string) ([]byte, error) {
+	rows, err := s.db.Query("SELECT data FROM tasks ORDER BY position")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []json.RawMessage{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read task: %w", err)
+		}
+		tasks = append(tasks, json.RawMessage(data))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tasks: %w", err)
+	}
+
+	settings, found, err := s.getMeta("settings")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		settings = "{}"
+	}
+
+	versionNumber, err := strconv.Atoi(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema version in database: %s", version)
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"version":  versionNumber,
+		"tasks":    tasks,
+		"settings": json.RawMessage(settings),
+	})
+}
+
+// SaveData replaces all tasks and settings in a single transaction. Tasks
+// another connection changed since the last load are merged into data first.
+func (s *SQLiteStorage) SaveData(data *AppData) error {
+	data.Version = CurrentSchemaVersion
+
+	settings, err := json.Marshal(data.Settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Reading inside the transaction makes the commit fail rather than
+	// overwrite anything written after this read
+	var dataVersion int64
+	if err := tx.QueryRow("PRAGMA data_version").Scan(&dataVersion); err != nil {
+		return fmt.Errorf("failed to read data version: %w", err)
+	}
+	if dataVersion != s.dataVersion {
+		remote, err := readTasks(tx)
+		if err != nil {
+			return err
+		}
+		// data_version is left behind so that ExternalChange reports the merge
+		data.Tasks, _ = MergeTasks(s.synced, data.Tasks, remote)
+	}
+
+	if _, err := tx.Exec("DELETE FROM tasks"); err != nil {
+		return fmt.Errorf("failed to clear tasks: %w", err)
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO tasks (id, position, data) VALUES (?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for i, task := range data.Tasks {
+		taskData, err := json.Marshal(task)
+		if err != nil {
+			return fmt.Errorf("failed to marshal task %s: %w", task.ID, err)
+		}
+		if _, err := stmt.Exec(task.ID, i, string(taskData)); err != nil {
+			return fmt.Errorf("failed to write task %s: %w", task.ID, err)
+		}
+	}
+
+	if err := setMeta(tx, "settings", string(settings)); err != nil {
+		return err
+	}
+	if err := setMeta(tx, "version", strconv.Itoa(CurrentSchemaVersion)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit data: %w", err)
+	}
+
+	s.markSynced(data.Tasks)
+	s.exportPending = true
+	return nil
+}
+
+// UpsertTask writes a single task without touching the others
+func (s *SQLiteStorage) UpsertTask(task Task) error {
+	taskData, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task %s: %w", task.ID, err)
+	}
+
+	_, err = s.db.Exec(`INSERT INTO tasks (id, position, data)
+		VALUES (?, (SELECT COALESCE(MAX(position), -1) + 1 FROM tasks), ?)
+		ON CONFLICT(id) DO UPDATE SET data = excluded.data`, task.ID, string(taskData))
+	if err != nil {
+		return fmt.Errorf("failed to write task %s: %w", task.ID, err)
+	}
+
+	s.synced = append(removeTask(s.synced, task.ID), task)
+	s.exportPending = true
+	return nil
+}
+
+// DeleteTask removes a single task
+func (s *SQLiteStorage) DeleteTask(taskID string) error {
+	if _, err := s.db.Exec("DELETE FROM tasks WHERE id = ?", taskID); err != nil {
+		return fmt.Errorf("failed to delete task %s: %w", taskID, err)
+	}
+	s.synced = removeTask(s.synced, taskID)
+	s.exportPending = true
+	return nil
+}
+
+// FlushExport rewrites the configured export file from the tasks in the
+// database if any were written since it was last rewritten
+func (s *SQLiteStorage) FlushExport() {
+	if !s.exportPending || s.config == nil || s.config.ExportICS == "" {
+		return
+	}
+	s.exportPending = false
+
+	tasks, err := readTasks(s.db)
+	if err != nil {
+		s.LogError(fmt.Errorf("failed to export tasks: %w", err))
+		return
+	}
+
+	s.exportICalendar(tasks)
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// readTasks decodes all stored tasks in order
+func readTasks(q querier) ([]Task, error) {
+	rows, err := q.Query("SELECT data FROM tasks ORDER BY position")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []Task
+	for rows.Next() {
+		var data string
+		var task Task
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read task: %w", err)
+		}
+		if err := json.Unmarshal([]byte(data), &task); err != nil {
+			return nil, fmt.Errorf("failed to decode task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// ExternalChange reports whether another connection committed changes since
+// the last load. SQLite bumps data_version only for commits by other connections.
+func (s *SQLiteStorage) ExternalChange() (bool, error) {
+	dataVersion, err := s.readDataVersion()
+	if err != nil {
+		return false, err
+	}
+	return dataVersion != s.dataVersion, nil
+}
+
+// readDataVersion reads SQLite's per-connection change counter
+func (s *SQLiteStorage) readDataVersion() (int64, error) {
+	var version int64
+	if err := s.db.QueryRow("PRAGMA data_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read data version: %w", err)
+	}
+	return version, nil
+}
+
+// PurgeData closes the database and deletes all application data and config files
+func (s *SQLiteStorage) PurgeData() error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+	return s.Storage.PurgeData()
+}
+
+// Close writes a pending export and closes the database
+func (s *SQLiteStorage) Close() error {
+	s.FlushExport()
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+	return nil
+}
+
+// getMeta reads a value from the meta table
+func (s *SQLiteStorage) getMeta(key string) (string, bool, error) {
+	var value string
+	err := s.db.QueryRow("SELECT value FROM meta WHERE key = ?", key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// setMeta writes a value to the meta table
+func setMeta(tx *sql.Tx, key, value string) error {
+	_, err := tx.Exec("INSERT INTO meta (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value", key, value)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return nil
+}
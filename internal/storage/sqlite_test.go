@@ -0,0 +1,340 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"personal-disorganizer/internal/testutil"
+)
+
+// newTestSQLiteStorage creates a SQLite backend in a temporary config directory
+func newTestSQLiteStorage(t *testing.T, dir string) *SQLiteStorage {
+	t.Helper()
+
+	base := &Storage{
+		configDir: dir,
+		dataPath:  filepath.Join(dir, "data.json"),
+	}
+
+	s, err := NewSQLiteStorage(base)
+	if err != nil {
+		t.Fatalf("NewSQLiteStorage() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func TestSQLiteStorage_EmptyDatabase(t *testing.T) {
+	s := newTestSQLiteStorage(t, testutil.TempDir(t))
+
+	data, err := s.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(data.Tasks) != 0 {
+		t.Errorf("Expected no tasks, got %d", len(data.Tasks))
+	}
+	if data.Version != CurrentSchemaVersion {
+		t.Errorf("Expected version %d, got %d", CurrentSchemaVersion, data.Version)
+	}
+}
+
+func TestSQLiteStorage_SaveAndLoad(t *testing.T) {
+	dir := testutil.TempDir(t)
+	s := newTestSQLiteStorage(t, dir)
+
+	date := testutil.FixedTime()
+	data := &AppData{
+		Tasks: []Task{
+			{ID: "a", Text: "First", Date: date, Priority: 2},
+			{ID: "b", Text: "Second", Date: date, Priority: 1, Level: 1},
+			{ID: "c", Text: "Third", Date: date, Done: true},
+		},
+		Settings: Settings{LastQuoteIndex: 7},
+	}
+	if err := s.SaveData(data); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+
+	loaded, err := s.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	testutil.AssertJSONEqual(t, data, loaded)
+
+	// Saving fewer tasks removes the missing ones
+	data.Tasks = data.Tasks[:1]
+	if err := s.SaveData(data); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	loaded, err = s.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(loaded.Tasks) != 1 {
+		t.Errorf("Expected 1 task, got %d", len(loaded.Tasks))
+	}
+}
+
+func TestSQLiteStorage_UpsertAndDeleteTask(t *testing.T) {
+	s := newTestSQLiteStorage(t, testutil.TempDir(t))
+
+	if err := s.SaveData(&AppData{Tasks: []Task{{ID: "a", Text: "First"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+
+	// Insert appends after existing tasks
+	if err := s.UpsertTask(Task{ID: "b", Text: "Second"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	// Update replaces in place
+	if err := s.UpsertTask(Task{ID: "a", Text: "First (edited)", Done: true}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+
+	data, err := s.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(data.Tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d", len(data.Tasks))
+	}
+	if data.Tasks[0].ID != "a" || data.Tasks[0].Text != "First (edited)" || !data.Tasks[0].Done {
+		t.Errorf("Expected updated task a first, got %+v", data.Tasks[0])
+	}
+	if data.Tasks[1].ID != "b" {
+		t.Errorf("Expected task b second, got %s", data.Tasks[1].ID)
+	}
+
+	if err := s.DeleteTask("a"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	// Deleting an unknown task is not an error
+	if err := s.DeleteTask("missing"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+
+	data, err = s.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(data.Tasks) != 1 || data.Tasks[0].ID != "b" {
+		t.Errorf("Expected only task b, got %+v", data.Tasks)
+	}
+}
+
+func TestSQLiteStorage_ImportsJSON(t *testing.T) {
+	dir := testutil.TempDir(t)
+
+	testutil.CreateTestData(t, dir, &AppData{
+		Version: CurrentSchemaVersion,
+		Tasks:   []Task{{ID: "from-json", Text: "Imported", Date: time.Now()}},
+	})
+
+	s := newTestSQLiteStorage(t, dir)
+	data, err := s.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(data.Tasks) != 1 || data.Tasks[0].ID != "from-json" {
+		t.Fatalf("Expected imported task, got %+v", data.Tasks)
+	}
+
+	// Later changes to data.json are not re-imported
+	testutil.CreateTestData(t, dir, &AppData{Tasks: []Task{}})
+	data, err = s.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(data.Tasks) != 1 {
+		t.Errorf("Expected database contents to win after import, got %d tasks", len(data.Tasks))
+	}
+}
+
+func TestSQLiteStorage_ReadData(t *testing.T) {
+	dir := testutil.TempDir(t)
+	testutil.CreateTestData(t, dir, &AppData{
+		Version: CurrentSchemaVersion,
+		Tasks:   []Task{{ID: "from-json", Text: "Not imported yet", Date: time.Now()}},
+	})
+	base := &Storage{
+		configDir: dir,
+		dataPath:  filepath.Join(dir, "data.json"),
+		config:    &Config{Backend: BackendSQLite},
+	}
+
+	// Before the first import, data.json is read without creating the database
+	data, err := base.ReadData()
+	if err != nil {
+		t.Fatalf("ReadData() error = %v", err)
+	}
+	if len(data.Tasks) != 1 || data.Tasks[0].ID != "from-json" {
+		t.Fatalf("Expected the task from data.json, got %+v", data.Tasks)
+	}
+	testutil.AssertFileNotExists(t, filepath.Join(dir, "data.db"))
+
+	s := newTestSQLiteStorage(t, dir)
+	if err := s.SaveData(&AppData{Tasks: []Task{{ID: "from-db", Text: "Stored", Date: time.Now()}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+
+	data, err = base.ReadData()
+	if err != nil {
+		t.Fatalf("ReadData() error = %v", err)
+	}
+	if len(data.Tasks) != 1 || data.Tasks[0].ID != "from-db" {
+		t.Errorf("Expected the task from the database, got %+v", data.Tasks)
+	}
+}
+
+func TestSQLiteStorage_RefusesNewerSchema(t *testing.T) {
+	s := newTestSQLiteStorage(t, testutil.TempDir(t))
+
+	if err := s.SaveData(&AppData{}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if _, err := s.db.Exec("UPDATE meta SET value = '999' WHERE key = 'version'"); err != nil {
+		t.Fatalf("Failed to bump version: %v", err)
+	}
+
+	if _, err := s.LoadData(); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Expected ErrNewerSchema, got %v", err)
+	}
+}
+
+func TestSQLiteStorage_PurgeData(t *testing.T) {
+	dir := testutil.TempDir(t)
+	s := newTestSQLiteStorage(t, dir)
+
+	if err := s.SaveData(&AppData{Tasks: []Task{{ID: "a"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	testutil.AssertFileExists(t, filepath.Join(dir, "data.db"))
+
+	if err := s.PurgeData(); err != nil {
+		t.Fatalf("PurgeData() error = %v", err)
+	}
+	testutil.AssertFileNotExists(t, dir)
+}
+
+func TestNewBackend(t *testing.T) {
+	tests := []struct {
+		name        string
+		backend     string
+		expectSQL   bool
+		expectError bool
+	}{
+		{name: "default is json", backend: ""},
+		{name: "explicit json", backend: BackendJSON},
+		{name: "sqlite", backend: BackendSQLite, expectSQL: true},
+		{name: "unknown backend", backend: "postgres", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configDir := testutil.TempDir(t)
+			testutil.CreateTestConfig(t, configDir, &Config{Backend: tt.backend})
+
+			backend, err := NewBackend(Paths{ConfigDir: configDir, DataDir: configDir})
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewBackend() error = %v", err)
+			}
+			defer backend.Close()
+
+			_, isSQL := backend.(*SQLiteStorage)
+			if isSQL != tt.expectSQL {
+				t.Errorf("Expected SQLite backend %v, got %T", tt.expectSQL, backend)
+			}
+		})
+	}
+}
+
+func TestSQLiteStorage_ExternalChange(t *testing.T) {
+	dir := testutil.TempDir(t)
+	first := newTestSQLiteStorage(t, dir)
+	second := newTestSQLiteStorage(t, dir)
+
+	if err := first.SaveData(&AppData{Tasks: []Task{{ID: "a"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if _, err := first.LoadData(); err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if _, err := second.LoadData(); err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+
+	// Own writes are not external changes
+	if err := first.UpsertTask(Task{ID: "b"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	if changed, err := first.ExternalChange(); err != nil || changed {
+		t.Fatalf("Expected no external change after own write, got %v (err %v)", changed, err)
+	}
+
+	if changed, err := second.ExternalChange(); err != nil || !changed {
+		t.Fatalf("Expected external change after other connection wrote, got %v (err %v)", changed, err)
+	}
+
+	data, err := second.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if len(data.Tasks) != 2 {
+		t.Errorf("Expected 2 tasks after reload, got %d", len(data.Tasks))
+	}
+	if changed, _ := second.ExternalChange(); changed {
+		t.Error("Expected no external change after reload")
+	}
+}
+
+func TestSQLiteStorage_SaveDataMergesOtherConnection(t *testing.T) {
+	dir := testutil.TempDir(t)
+	first := newTestSQLiteStorage(t, dir)
+	second := newTestSQLiteStorage(t, dir)
+
+	if err := first.SaveData(&AppData{Tasks: []Task{{ID: "a", Text: "A"}, {ID: "b", Text: "B"}}}); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	data, err := first.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	if _, err := second.LoadData(); err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+
+	if err := second.UpsertTask(Task{ID: "c", Text: "C"}); err != nil {
+		t.Fatalf("UpsertTask() error = %v", err)
+	}
+	if err := second.DeleteTask("b"); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+
+	// A full save of stale data keeps those changes
+	data.Tasks = []Task{{ID: "a", Text: "A", Done: true}, {ID: "b", Text: "B"}}
+	if err := first.SaveData(data); err != nil {
+		t.Fatalf("SaveData() error = %v", err)
+	}
+	if changed, _ := first.ExternalChange(); !changed {
+		t.Error("Expected the merge to be reported as an external change")
+	}
+
+	saved, err := second.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData() error = %v", err)
+	}
+	byID := indexTasks(saved.Tasks)
+	if len(saved.Tasks) != 2 || !byID["a"].Done || byID["c"].Text != "C" {
+		t.Errorf("Expected both connections' changes in the database, got %+v", saved.Tasks)
+	}
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"sort"
+)
+
+// FlattenTree returns tasks in display order: depth first, with siblings
+// ordered by Priority (higher first). Level is set to each task's depth. Tasks
+// whose parent is not among the given tasks are treated as top-level tasks, so
+// the input should hold the tasks of a single day.
+func FlattenTree(tasks []Task) []Task {
+	present := make(map[string]bool, len(tasks))
+	for _, task := range tasks {
+		present[task.ID] = true
+	}
+
+	children := make(map[string][]Task)
+	var roots []Task
+	for _, task := range tasks {
+		if task.ParentID == "" || task.ParentID == task.ID || !present[task.ParentID] {
+			roots = append(roots, task)
+			continue
+		}
+		children[task.ParentID] = append(children[task.ParentID], task)
+	}
+
+	byPriority := func(group []Task) {
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].Priority > group[j].Priority
+		})
+	}
+	byPriority(roots)
+	for _, group := range children {
+		byPriority(group)
+	}
+
+	ordered := make([]Task, 0, len(tasks))
+	visited := make(map[string]bool, len(tasks))
+
+	var walk func(task Task, depth int)
+	walk = func(task Task, depth int) {
+		if visited[task.ID] {
+			return
+		}
+		visited[task.ID] = true
+		task.Level = depth
+		ordered = append(ordered, task)
+		for _, child := range children[task.ID] {
+			walk(child, depth+1)
+		}
+	}
+
+	for _, root := range roots {
+		walk(root, 0)
+	}
+
+	// Tasks in a parent cycle are unreachable from any root; show them at the top level
+	for _, task := range tasks {
+		if !visited[task.ID] {
+			walk(task, 0)
+		}
+	}
+
+	return ordered
+}
+
+// SubtreeIDs returns the ID of a task followed by the IDs of all its descendants
+func SubtreeIDs(tasks []Task, rootID string) []string {
+	children := make(map[string][]string)
+	for _, task := range tasks {
+		if task.ParentID != "" {
+			children[task.ParentID] = append(children[task.ParentID], task.ID)
+		}
+	}
+
+	ids := []string{rootID}
+	seen := map[string]bool{rootID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+
+	return ids
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenTree(t *testing.T) {
+	tests := []struct {
+		name           string
+		tasks          []Task
+		expectedOrder  []string
+		expectedLevels []int
+	}{
+		{
+			name:           "empty",
+			tasks:          nil,
+			expectedOrder:  nil,
+			expectedLevels: nil,
+		},
+		{
+			name: "siblings ordered by priority",
+			tasks: []Task{
+				{ID: "b", Priority: 1},
+				{ID: "a", Priority: 2},
+			},
+			expectedOrder:  []string{"a", "b"},
+			expectedLevels: []int{0, 0},
+		},
+		{
+			name: "children follow their parent",
+			tasks: []Task{
+				{ID: "second", Priority: 1},
+				{ID: "child-2", ParentID: "first", Priority: 1},
+				{ID: "grandchild", ParentID: "child-1", Priority: 1},
+				{ID: "child-1", ParentID: "first", Priority: 2},
+				{ID: "first", Priority: 2},
+			},
+			expectedOrder:  []string{"first", "child-1", "grandchild", "child-2", "second"},
+			expectedLevels: []int{0, 1, 2, 1, 0},
+		},
+		{
+			name: "missing parent becomes top-level",
+			tasks: []Task{
+				{ID: "orphan", ParentID: "elsewhere", Priority: 1},
+				{ID: "root", Priority: 2},
+			},
+			expectedOrder:  []string{"root", "orphan"},
+			expectedLevels: []int{0, 0},
+		},
+		{
+			name: "parent cycle is still shown",
+			tasks: []Task{
+				{ID: "a", ParentID: "b"},
+				{ID: "b", ParentID: "a"},
+			},
+			expectedOrder:  []string{"a", "b"},
+			expectedLevels: []int{0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var order []string
+			var levels []int
+			for _, task := range FlattenTree(tt.tasks) {
+				order = append(order, task.ID)
+				levels = append(levels, task.Level)
+			}
+
+			if !reflect.DeepEqual(order, tt.expectedOrder) {
+				t.Errorf("Expected order %v, got %v", tt.expectedOrder, order)
+			}
+			if !reflect.DeepEqual(levels, tt.expectedLevels) {
+				t.Errorf("Expected levels %v, got %v", tt.expectedLevels, levels)
+			}
+		})
+	}
+}
+
+func TestSubtreeIDs(t *testing.T) {
+	tasks := []Task{
+		{ID: "root"},
+		{ID: "child", ParentID: "root"},
+		{ID: "grandchild", ParentID: "child"},
+		{ID: "other"},
+		{ID: "loop-a", ParentID: "loop-b"},
+		{ID: "loop-b", ParentID: "loop-a"},
+	}
+
+	if ids := SubtreeIDs(tasks, "root"); !reflect.DeepEqual(ids, []string{"root", "child", "grandchild"}) {
+		t.Errorf("Expected root subtree, got %v", ids)
+	}
+	if ids := SubtreeIDs(tasks, "other"); !reflect.DeepEqual(ids, []string{"other"}) {
+		t.Errorf("Expected a single task, got %v", ids)
+	}
+	if ids := SubtreeIDs(tasks, "loop-a"); !reflect.DeepEqual(ids, []string{"loop-a", "loop-b"}) {
+		t.Errorf("Expected cycle to terminate, got %v", ids)
+	}
+}
@@ -25,6 +25,24 @@ func TempDir(t *testing.T) string {
 	return dir
 }
 
+// SetLocalZone makes the named zone the local time zone for the rest of the test
+func SetLocalZone(t *testing.T, name string) *time.Location {
+	t.Helper()
+	
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("Failed to load time zone %s: %v", name, err)
+	}
+	
+	previous := time.Local
+	time.Local = loc
+	t.Cleanup(func() {
+		time.Local = previous
+	})
+	
+	return loc
+}
+
 // CreateTestConfig creates a test configuration file with any interface{}
 func CreateTestConfig(t *testing.T, dir string, config interface{}) string {
 	t.Helper()
@@ -36,10 +36,12 @@ type Styles struct {
 	CheckboxActive lipgloss.Style
 	CheckboxDone   lipgloss.Style
 	Calendar       lipgloss.Style
+	AllDayEvent    lipgloss.Style
 	Footer         lipgloss.Style
 	Quote          lipgloss.Style
 	Help           lipgloss.Style
 	Search         lipgloss.Style
+	Overdue        lipgloss.Style
 }
 
 // Manager handles theme loading and style creation
@@ -184,6 +186,11 @@ func (m *Manager) createStyles() {
 			Foreground(lipgloss.Color(theme.Accent)).
 			Italic(true),
 			
+		AllDayEvent: lipgloss.NewStyle().
+			Background(lipgloss.Color(theme.Accent)).
+			Foreground(lipgloss.Color(theme.Background)).
+			Padding(0, 1),
+			
 		Footer: lipgloss.NewStyle().
 			Background(lipgloss.Color(theme.Muted)).
 			Foreground(lipgloss.Color(theme.Foreground)).
@@ -203,6 +210,10 @@ func (m *Manager) createStyles() {
 			Foreground(lipgloss.Color(theme.Background)).
 			Bold(true).
 			Padding(0, 1),
+			
+		Overdue: lipgloss.NewStyle().
+			Foreground(lipgloss.Color(theme.Warning)).
+			Italic(true),
 	}
 }
 
@@ -300,9 +300,11 @@ func TestManager_CreateStyles(t *testing.T) {
 		{"Secondary", styles.Secondary},
 		{"TaskActive", styles.TaskActive},
 		{"TaskCompleted", styles.TaskCompleted},
+		{"Overdue", styles.Overdue},
 		{"CheckboxActive", styles.CheckboxActive},
 		{"CheckboxDone", styles.CheckboxDone},
 		{"Calendar", styles.Calendar},
+		{"AllDayEvent", styles.AllDayEvent},
 		{"Footer", styles.Footer},
 		{"Quote", styles.Quote},
 		{"Help", styles.Help},
@@ -3,7 +3,8 @@
 # Script to safely add Terry Pratchett quotes to user configuration
 # Only adds if not already present to prevent duplicates
 
-CONFIG_FILE="$HOME/.config/personal-disorganizer/config.json"
+CONFIG_DIR="${CONFIG_DIR:-${PERSONAL_DISORGANIZER_CONFIG_DIR:-${XDG_CONFIG_HOME:-$HOME/.config}/personal-disorganizer}}"
+CONFIG_FILE="$CONFIG_DIR/config.json"
 QUOTE_FILE="quotes/pratchett.json"
 
 # Create config file if it doesn't exist
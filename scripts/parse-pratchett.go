@@ -3,10 +3,13 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"personal-disorganizer/internal/storage"
 )
 
 // Quote represents a single quote
@@ -16,13 +19,16 @@ type Quote struct {
 }
 
 func main() {
-	homeDir, err := os.UserHomeDir()
+	configDir := flag.String("config-dir", "", "Config directory (overrides $"+storage.ConfigDirEnv+" and XDG directories)")
+	flag.Parse()
+	
+	paths, err := storage.ResolvePaths(*configDir)
 	if err != nil {
-		fmt.Printf("Error getting home directory: %v\n", err)
+		fmt.Printf("Error resolving config directory: %v\n", err)
 		os.Exit(1)
 	}
 	
-	quotesDir := filepath.Join(homeDir, ".config", "personal-disorganizer", "quotes")
+	quotesDir := filepath.Join(paths.ConfigDir, "quotes")
 	inputFile := filepath.Join(quotesDir, "pratchett.pqf")
 	outputFile := filepath.Join(quotesDir, "pratchett.json")
 	